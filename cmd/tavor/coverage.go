@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tavorFuzzStrategy "github.com/zimmski/tavor/fuzz/strategy"
+)
+
+// readCoverageBitmap returns the index of every non-zero byte of the given coverage bitmap file. A bitmap file which does not exist holds no coverage.
+func readCoverageBitmap(file string) ([]uint64, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var coverage []uint64
+
+	for i, b := range data {
+		if b != 0 {
+			coverage = append(coverage, uint64(i))
+		}
+	}
+
+	return coverage, nil
+}
+
+// readGoCoverage returns the coverage which was written by a binary built with "go build -cover" to the given GOCOVERDIR directory
+func readGoCoverage(dir string) ([]uint64, error) {
+	profile := filepath.Join(dir, "profile.txt")
+
+	out, err := exec.Command("go", "tool", "covdata", "textfmt", "-i="+dir, "-o="+profile).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("%v: %s", err, out)
+	}
+
+	f, err := os.Open(profile)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			panic(err)
+		}
+	}()
+
+	return parseGoCoverageProfile(f)
+}
+
+// parseGoCoverageProfile returns an identifier for every covered block of the given Go coverage profile
+func parseGoCoverageProfile(r io.Reader) ([]uint64, error) {
+	var coverage []uint64
+
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+
+		// a block is defined as "file:startLine.startColumn,endLine.endColumn statements count"
+		i := strings.LastIndex(line, " ")
+		if i == -1 {
+			return nil, fmt.Errorf("invalid coverage block %q", line)
+		}
+
+		count, err := strconv.ParseUint(line[i+1:], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid coverage block %q", line)
+		}
+
+		if count == 0 {
+			continue
+		}
+
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(line[:i]))
+
+		coverage = append(coverage, h.Sum64())
+	}
+
+	return coverage, scanner.Err()
+}
+
+// parseScriptFeedback returns the command of the given script feedback and the feedback which can follow the command as arguments separated by white spaces. An argument is either an unsigned integer which identifies covered code or the fitness of the generation given as "fitness=<number>".
+func parseScriptFeedback(feed string) (string, tavorFuzzStrategy.Feedback, error) {
+	var feedback tavorFuzzStrategy.Feedback
+
+	fields := strings.Fields(feed)
+	if len(fields) == 0 {
+		return "", feedback, nil
+	}
+
+	for _, f := range fields[1:] {
+		if strings.HasPrefix(f, "fitness=") {
+			v, err := strconv.ParseFloat(f[len("fitness="):], 64)
+			if err != nil {
+				return "", feedback, fmt.Errorf("%q is not a valid fitness", f)
+			}
+
+			feedback.Fitness = v
+
+			continue
+		}
+
+		c, err := strconv.ParseUint(f, 10, 64)
+		if err != nil {
+			return "", feedback, fmt.Errorf("%q is not an unsigned integer", f)
+		}
+
+		feedback.Coverage = append(feedback.Coverage, c)
+	}
+
+	return fields[0], feedback, nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadCoverageBitmap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tavor-coverage-test")
+	assert.Nil(t, err)
+
+	defer func() {
+		assert.Nil(t, os.RemoveAll(dir))
+	}()
+
+	file := filepath.Join(dir, "bitmap")
+
+	coverage, err := readCoverageBitmap(file)
+	assert.Nil(t, err)
+	assert.Nil(t, coverage)
+
+	assert.Nil(t, ioutil.WriteFile(file, []byte{0, 1, 0, 0, 255}, 0644))
+
+	coverage, err = readCoverageBitmap(file)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{1, 4}, coverage)
+}
+
+func TestParseGoCoverageProfile(t *testing.T) {
+	coverage, err := parseGoCoverageProfile(strings.NewReader(`mode: set
+example.com/pkg/file.go:3.13,5.2 1 1
+example.com/pkg/file.go:7.13,9.2 1 0
+example.com/pkg/file.go:11.13,13.2 2 1
+`))
+	assert.Nil(t, err)
+	assert.Len(t, coverage, 2)
+	assert.NotEqual(t, coverage[0], coverage[1])
+
+	_, err = parseGoCoverageProfile(strings.NewReader("mode: set\nfile.go:3.13,5.2 1 x\n"))
+	assert.NotNil(t, err)
+}
+
+func TestParseScriptFeedback(t *testing.T) {
+	command, feedback, err := parseScriptFeedback("YES\n")
+	assert.Nil(t, err)
+	assert.Equal(t, "YES", command)
+	assert.Nil(t, feedback.Coverage)
+
+	command, feedback, err = parseScriptFeedback("NO 3 17 fitness=0.5 42\n")
+	assert.Nil(t, err)
+	assert.Equal(t, "NO", command)
+	assert.Equal(t, []uint64{3, 17, 42}, feedback.Coverage)
+	assert.Equal(t, 0.5, feedback.Fitness)
+
+	_, _, err = parseScriptFeedback("YES a\n")
+	assert.NotNil(t, err)
+	_, _, err = parseScriptFeedback("YES fitness=a\n")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const diffContext = 3
+
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// unifiedDiff returns the line based differences of a and b in the unified diff format
+func unifiedDiff(name string, a, b []byte) string {
+	al := diffSplit(a)
+	bl := diffSplit(b)
+
+	// longest common subsequence of the lines
+	lcs := make([][]int, len(al)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bl)+1)
+	}
+	for i := len(al) - 1; i >= 0; i-- {
+		for j := len(bl) - 1; j >= 0; j-- {
+			if al[i] == bl[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(al) || j < len(bl) {
+		switch {
+		case i < len(al) && j < len(bl) && al[i] == bl[j]:
+			lines = append(lines, diffLine{' ', al[i]})
+			i++
+			j++
+		case j < len(bl) && (i == len(al) || lcs[i][j+1] > lcs[i+1][j]):
+			lines = append(lines, diffLine{'+', bl[j]})
+			j++
+		default:
+			lines = append(lines, diffLine{'-', al[i]})
+			i++
+		}
+	}
+
+	var out bytes.Buffer
+
+	out.WriteString(fmt.Sprintf("--- %s.orig\n+++ %s\n", name, name))
+
+	for start := 0; start < len(lines); {
+		if lines[start].op == ' ' {
+			start++
+
+			continue
+		}
+
+		// collect one hunk with its surrounding context
+		from := start - diffContext
+		if from < 0 {
+			from = 0
+		}
+
+		to := start
+		for k := start; k < len(lines) && k < to+2*diffContext+1; k++ {
+			if lines[k].op != ' ' {
+				to = k
+			}
+		}
+		to += diffContext
+		if to >= len(lines) {
+			to = len(lines) - 1
+		}
+
+		aStart, bStart := 1, 1
+		for _, l := range lines[:from] {
+			if l.op != '+' {
+				aStart++
+			}
+			if l.op != '-' {
+				bStart++
+			}
+		}
+		aLen, bLen := 0, 0
+		for _, l := range lines[from : to+1] {
+			if l.op != '+' {
+				aLen++
+			}
+			if l.op != '-' {
+				bLen++
+			}
+		}
+
+		out.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen))
+		for _, l := range lines[from : to+1] {
+			out.WriteByte(l.op)
+			out.WriteString(l.text)
+			out.WriteByte('\n')
+		}
+
+		start = to + 1
+	}
+
+	return out.String()
+}
+
+func diffSplit(data []byte) []string {
+	s := string(data)
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
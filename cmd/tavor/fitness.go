@@ -0,0 +1,75 @@
+package main
+
+import (
+	"crypto/md5"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// fitnessGeneration holds a generation and its fitness
+type fitnessGeneration struct {
+	out     string
+	fitness float64
+}
+
+// bestGenerations holds the distinct generations with the highest fitness
+type bestGenerations struct {
+	max         int
+	generations []fitnessGeneration
+}
+
+// add adds the given generation if its fitness is one of the highest
+func (b *bestGenerations) add(out string, fitness float64) {
+	for i, g := range b.generations {
+		if g.out == out {
+			if fitness > g.fitness {
+				b.generations[i].fitness = fitness
+			}
+
+			return
+		}
+	}
+
+	b.generations = append(b.generations, fitnessGeneration{
+		out:     out,
+		fitness: fitness,
+	})
+
+	sort.SliceStable(b.generations, func(i, j int) bool {
+		return b.generations[i].fitness > b.generations[j].fitness
+	})
+
+	if len(b.generations) > b.max {
+		b.generations = b.generations[:b.max]
+	}
+}
+
+// write saves every generation with the MD5 checksum and the given extension as filename in the given folder
+func (b *bestGenerations) write(folder string, extension string) error {
+	for _, g := range b.generations {
+		file := filepath.Join(folder, fmt.Sprintf("%x%s", md5.Sum([]byte(g.out)), extension))
+
+		if err := ioutil.WriteFile(file, []byte(g.out), 0644); err != nil {
+			return fmt.Errorf("error writing to %s: %v", file, err)
+		}
+	}
+
+	return nil
+}
+
+// parseFitness returns the fitness of the last non-empty line of the given output
+func parseFitness(out string) (float64, error) {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	line := strings.TrimSpace(lines[len(lines)-1])
+
+	v, err := strconv.ParseFloat(line, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a valid fitness", line)
+	}
+
+	return v, nil
+}
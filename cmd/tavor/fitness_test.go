@@ -0,0 +1,55 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFitness(t *testing.T) {
+	fitness, err := parseFitness("some output\n0.75\n\n")
+	assert.Nil(t, err)
+	assert.Equal(t, 0.75, fitness)
+
+	_, err = parseFitness("")
+	assert.NotNil(t, err)
+	_, err = parseFitness("0.75\nno fitness")
+	assert.NotNil(t, err)
+}
+
+func TestBestGenerations(t *testing.T) {
+	b := &bestGenerations{
+		max: 2,
+	}
+
+	b.add("a", 1)
+	b.add("b", 3)
+	b.add("a", 2)
+	b.add("c", 0)
+
+	assert.Equal(t, []fitnessGeneration{
+		{out: "b", fitness: 3},
+		{out: "a", fitness: 2},
+	}, b.generations)
+
+	dir, err := ioutil.TempDir("", "tavor-fitness-test")
+	assert.Nil(t, err)
+
+	defer func() {
+		err := os.RemoveAll(dir)
+		assert.Nil(t, err)
+	}()
+
+	assert.Nil(t, b.write(dir, ".txt"))
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "92eb5ffee6ae2fec3ad71c777531578f.txt"))
+	assert.Nil(t, err)
+	assert.Equal(t, "b", string(data))
+
+	files, err := ioutil.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Len(t, files, 2)
+}
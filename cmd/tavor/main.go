@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"crypto/md5"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -11,6 +12,7 @@ import (
 	"os"
 	"os/exec"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
@@ -24,9 +26,11 @@ import (
 	tavorFuzzStrategy "github.com/zimmski/tavor/fuzz/strategy"
 	"github.com/zimmski/tavor/graph"
 	"github.com/zimmski/tavor/log"
+	"github.com/zimmski/tavor/lsp"
 	"github.com/zimmski/tavor/parser"
 	tavorReduceStrategy "github.com/zimmski/tavor/reduce/strategy"
 	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/conditions"
 )
 
 type exitCodeType int
@@ -54,9 +58,11 @@ type options struct {
 
 	Format struct {
 		Check         bool           `long:"check" description:"Checks the syntax of the format file and exits"`
+		Define        []string       `long:"define" description:"Overrides the value of a constant definition of the format file with Name=value"`
 		FormatFile    flags.Filename `long:"format-file" description:"Input Tavor format file" required:"true"`
 		Print         bool           `long:"print" description:"Prints the AST of the parsed format file and exits"`
 		PrintInternal bool           `long:"print-internal" description:"Prints the internal AST of the parsed format file and exits"`
+		Start         string         `long:"start" description:"Token definition which is used as entry point of the format" default:"START"`
 	} `group:"Format file options"`
 
 	Fuzz struct {
@@ -71,6 +77,9 @@ type options struct {
 			ExecDoNotRemoveTmpFilesOnError bool             `long:"exec-do-not-remove-tmp-files-on-error" description:"If set, tmp files are not removed on error"`
 			ExecArgumentType               execArgumentType `long:"exec-argument-type" description:"How the generation is given to the binary" default:"stdin"`
 			ListExecArgumentTypes          bool             `long:"list-exec-argument-types" description:"List all available exec argument types"`
+			ExecCoverageBitmap             flags.Filename   `long:"exec-coverage-bitmap" description:"Read the coverage of an execution from this bitmap file which is written by the binary"`
+			ExecCoverageGo                 bool             `long:"exec-coverage-go" description:"Read the coverage of an execution from a binary built with \"go build -cover\""`
+			ExecFitness                    bool             `long:"exec-fitness" description:"Read the fitness of an execution from the last line of its stdout"`
 
 			Script string `long:"script" description:"Execute this binary which gets fed with the generation and should return feedback"`
 
@@ -79,18 +88,44 @@ type options struct {
 
 		Filter optsFuzzingFilters
 
-		Strategy       fuzzStrategy `long:"strategy" description:"The fuzzing strategy" default:"random"`
-		ListStrategies bool         `long:"list-strategies" description:"List all available fuzzing strategies"`
+		Strategy         fuzzStrategy `long:"strategy" description:"The fuzzing strategy" default:"random"`
+		ListStrategies   bool         `long:"list-strategies" description:"List all available fuzzing strategies"`
+		MaxAssertRetries int          `long:"max-assert-retries" description:"How many times the random fuzzing strategy retries a generation which violates an assertion or a unique repeat" default:"100"`
+
+		MaxFeedbackIterations int            `long:"max-feedback-iterations" description:"How many generations a feedback-driven fuzzing strategy produces" default:"1000"`
+		CorpusFolder          flags.Filename `long:"corpus-folder" description:"Folder of the inputs which are mutated by the mutation-based fuzzing strategy"`
+
+		GeneticPopulation  int `long:"genetic-population" description:"How many individuals are in one generation of the genetic fuzzing strategy" default:"20"`
+		GeneticGenerations int `long:"genetic-generations" description:"How many generations the genetic fuzzing strategy evolves" default:"10"`
+		GeneticElitism     int `long:"genetic-elitism" description:"How many of the fittest individuals the genetic fuzzing strategy carries over to the next generation" default:"2"`
+
+		PairWiseStrength int `long:"pairwise-strength" description:"How many parameters of every combination the pairwise fuzzing strategy covers" default:"2"`
+
+		BestFolder flags.Filename `long:"best-folder" description:"Save the fuzzing results with the highest fitness with the MD5 checksum as filename in this folder"`
+		BestCount  int            `long:"best-count" description:"How many fuzzing results with the highest fitness are saved" default:"10"`
 
 		ResultFolder     flags.Filename `long:"result-folder" description:"Save every fuzzing result with the MD5 checksum as filename in this folder"`
 		ResultExtensions string         `long:"result-extension" description:"If result-folder is used this will be the extension of every filename"`
 		ResultSeparator  string         `long:"result-separator" description:"Separates result outputs of each fuzzing step" default:"\n"`
 	} `command:"fuzz" description:"Fuzz the given format file"`
 
+	Fmt struct {
+		Diff  bool `short:"d" long:"diff" description:"Print the differences to the canonical format instead of the formatted format file"`
+		List  bool `short:"l" long:"list" description:"Print the name of the format file if its formatting differs from the canonical format"`
+		Write bool `short:"w" long:"write" description:"Write the result to the format file instead of STDOUT"`
+	} `command:"fmt" description:"Format the given format file in the canonical format"`
+
 	Graph struct {
 		Filter optsFuzzingFilters
 	} `command:"graph" description:"Generate a DOT file out of the internal AST"`
 
+	Lint struct {
+		JSON bool `long:"json" description:"Output the findings as JSON"`
+	} `command:"lint" description:"Statically analyze the given format file"`
+
+	LSP struct {
+	} `command:"lsp" description:"Start a language server for format files which communicates over STDIN and STDOUT"`
+
 	Reduce struct {
 		Exec struct {
 			Exec                    string           `long:"exec" description:"Execute this binary with possible arguments to test a generation"`
@@ -114,14 +149,18 @@ type options struct {
 		ResultSeparator string `long:"result-separator" description:"Separates result outputs of each reducing step" default:"\n"`
 	} `command:"reduce" description:"Reduce the given input file"`
 
+	Test struct {
+	} `command:"test" description:"Run the tests embedded in the given format file"`
+
 	Validate struct {
 		InputFile flags.Filename `long:"input-file" description:"Input file which gets parsed and validated via the format file" required:"true"`
 	} `command:"validate" description:"Validate the given input file"`
 }
 
 type optsFuzzingFilters struct {
-	Filters     fuzzFilters `long:"filter" description:"Fuzzing filter to apply"`
-	ListFilters bool        `long:"list-filters" description:"List all available fuzzing filters"`
+	Filters           fuzzFilters `long:"filter" description:"Fuzzing filter to apply"`
+	FilterDefinitions []string    `long:"filter-definition" description:"Apply the fuzzing filters only onto tokens of this token definition"`
+	ListFilters       bool        `long:"list-filters" description:"List all available fuzzing filters"`
 }
 
 var execArgumentTypes = []string{
@@ -243,7 +282,8 @@ func checkArguments(args []string, opts *options) (string, exitCodeType) {
 
 	if err != nil {
 		e, ok := err.(*flags.Error)
-		if !ok || e.Type != flags.ErrCommandRequired {
+		// the language server gets its format files from the client
+		if !ok || (e.Type != flags.ErrCommandRequired && !(e.Type == flags.ErrRequired && p.Active != nil && p.Active.Name == "lsp")) {
 			return "", exitError(err.Error())
 		}
 	}
@@ -268,11 +308,56 @@ func checkArguments(args []string, opts *options) (string, exitCodeType) {
 		return "", exitError("max repeats has to be at least 1")
 	}
 
+	if opts.Fuzz.MaxAssertRetries < 0 {
+		return "", exitError("max assert retries has to be at least 0")
+	}
+
+	if opts.Fuzz.MaxFeedbackIterations < 1 {
+		return "", exitError("max feedback iterations has to be at least 1")
+	}
+
+	if opts.Fuzz.GeneticPopulation < 1 {
+		return "", exitError("genetic population has to be at least 1")
+	}
+
+	if opts.Fuzz.GeneticGenerations < 1 {
+		return "", exitError("genetic generations has to be at least 1")
+	}
+
+	if opts.Fuzz.GeneticElitism < 0 || opts.Fuzz.GeneticElitism >= opts.Fuzz.GeneticPopulation {
+		return "", exitError("genetic elitism has to be at least 0 and smaller than the genetic population")
+	}
+
+	if opts.Fuzz.PairWiseStrength < 1 {
+		return "", exitError("pairwise strength has to be at least 1")
+	}
+
+	if opts.Fuzz.BestCount < 1 {
+		return "", exitError("best count has to be at least 1")
+	}
+
+	for _, d := range opts.Format.Define {
+		if i := strings.Index(d, "="); i < 1 {
+			return "", exitError("define %q invalid: has to be of the form Name=value", d)
+		}
+	}
+
+	if opts.Fuzz.CorpusFolder != "" {
+		if err := osutil.DirExists(string(opts.Fuzz.CorpusFolder)); err != nil {
+			return "", exitError("corpus-folder invalid: %v", err)
+		}
+	}
+
 	if opts.Fuzz.ResultFolder != "" {
 		if err := osutil.DirExists(string(opts.Fuzz.ResultFolder)); err != nil {
 			return "", exitError("result-folder invalid: %v", err)
 		}
 	}
+	if opts.Fuzz.BestFolder != "" {
+		if err := osutil.DirExists(string(opts.Fuzz.BestFolder)); err != nil {
+			return "", exitError("best-folder invalid: %v", err)
+		}
+	}
 	if opts.Fuzz.ResultSeparator != "" {
 		if t, err := strconv.Unquote(`"` + opts.Fuzz.ResultSeparator + `"`); err == nil {
 			opts.Fuzz.ResultSeparator = t
@@ -322,17 +407,21 @@ func exitError(format string, args ...interface{}) exitCodeType {
 	return exitCodeError
 }
 
-func applyFilters(opts *options, filterNames []fuzzFilter, doc token.Token) (token.Token, error) {
-	if len(filterNames) > 0 {
+func applyFilters(opts *options, filterOpts optsFuzzingFilters, doc token.Token) (token.Token, error) {
+	if len(filterOpts.Filters) > 0 {
 		var err error
 		var filters []tavorFuzzFilter.Filter
 
-		for _, name := range filterNames {
+		for _, name := range filterOpts.Filters {
 			filt, err := tavorFuzzFilter.New(string(name))
 			if err != nil {
 				return nil, err
 			}
 
+			if len(filterOpts.FilterDefinitions) > 0 {
+				filt = tavorFuzzFilter.Restrict(filt, filterOpts.FilterDefinitions...)
+			}
+
 			filters = append(filters, filt)
 
 			log.Infof("using %s fuzzing filter", name)
@@ -359,6 +448,157 @@ func applyFilters(opts *options, filterNames []fuzzFilter, doc token.Token) (tok
 	return doc, nil
 }
 
+func printConstants(constants map[string]string) {
+	if len(constants) == 0 {
+		return
+	}
+
+	log.Info("Constants:")
+
+	var names []string
+	for name := range constants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Printf("Const %s = %s\n", name, constants[name])
+	}
+}
+
+func printAssertStatistics(doc token.Token) {
+	for _, a := range conditions.Asserts(doc) {
+		stats := a.Statistics()
+		if stats.Checks == 0 {
+			continue
+		}
+
+		log.Infof("assertion%s was violated %d of %d times (%.1f%% rejection rate)", token.SourceSuffix(a), stats.Violations, stats.Checks, 100*float64(stats.Violations)/float64(stats.Checks))
+	}
+}
+
+func fmtCmd(opts *options, file io.Reader) exitCodeType {
+	original, err := ioutil.ReadAll(file)
+	if err != nil {
+		return exitError("cannot read tavor file: %v", err)
+	}
+
+	formatted, err := parser.FormatTavor(bytes.NewReader(original))
+	if err != nil {
+		return exitError("cannot format tavor file: %v", err)
+	}
+
+	name := string(opts.Format.FormatFile)
+
+	if opts.Fmt.List && !bytes.Equal(original, formatted) {
+		fmt.Println(name)
+	}
+
+	if opts.Fmt.Write && !bytes.Equal(original, formatted) {
+		if err := ioutil.WriteFile(name, formatted, 0644); err != nil {
+			return exitError("cannot write tavor file: %v", err)
+		}
+	}
+
+	if opts.Fmt.Diff && !bytes.Equal(original, formatted) {
+		fmt.Print(unifiedDiff(name, original, formatted))
+	}
+
+	if !opts.Fmt.List && !opts.Fmt.Write && !opts.Fmt.Diff {
+		fmt.Print(string(formatted))
+	}
+
+	return exitCodeOk
+}
+
+type lintFinding struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity string `json:"severity"`
+	Type     string `json:"type"`
+	Message  string `json:"message"`
+}
+
+func lintCmd(opts *options, file io.Reader) exitCodeType {
+	findings, err := parser.LintTavor(file)
+	if err != nil {
+		return exitError("cannot lint tavor file: %v", err)
+	}
+
+	exitCode := exitCodeOk
+
+	out := make([]lintFinding, len(findings))
+	for i, f := range findings {
+		if f.Severity == parser.LintError {
+			exitCode = exitCodeError
+		}
+
+		out[i] = lintFinding{
+			File:     string(opts.Format.FormatFile),
+			Line:     f.Position.Line,
+			Column:   f.Position.Column,
+			Severity: f.Severity.String(),
+			Type:     f.Type.String(),
+			Message:  f.Message,
+		}
+	}
+
+	if opts.Lint.JSON {
+		o, err := json.MarshalIndent(out, "", "\t")
+		if err != nil {
+			return exitError("cannot encode lint findings: %v", err)
+		}
+
+		fmt.Println(string(o))
+	} else {
+		for _, f := range out {
+			fmt.Printf("%s:%d:%d: %s: %s (%s)\n", f.File, f.Line, f.Column, f.Severity, f.Message, f.Type)
+		}
+	}
+
+	return exitCode
+}
+
+func testCmd(opts *options, file io.Reader, defines map[string]string) exitCodeType {
+	results, err := parser.RunTavorTests(file, defines)
+	if err != nil {
+		if errs, ok := err.(token.ParserErrors); ok {
+			return exitError("cannot parse tavor file, found %d errors:\n%v", len(errs), errs)
+		}
+
+		return exitError("cannot parse tavor file: %v", err)
+	}
+
+	failed := 0
+
+	for _, r := range results {
+		if r.Passed() {
+			continue
+		}
+
+		failed++
+
+		if r.Valid {
+			fmt.Printf("%s:%d:%d: valid input %q is invalid for %s:\n", opts.Format.FormatFile, r.Position.Line, r.Position.Column, r.Input, r.Start)
+
+			for _, err := range r.Errors {
+				fmt.Printf("\t%v\n", err)
+			}
+		} else {
+			fmt.Printf("%s:%d:%d: invalid input %q is valid for %s\n", opts.Format.FormatFile, r.Position.Line, r.Position.Column, r.Input, r.Start)
+		}
+	}
+
+	fmt.Printf("%d tests, %d failed\n", len(results), failed)
+
+	if failed != 0 {
+		return exitCodeError
+	}
+
+	return exitCodeOk
+}
+
 func mainCmd(args []string) exitCodeType {
 	var opts = new(options)
 
@@ -368,6 +608,21 @@ func mainCmd(args []string) exitCodeType {
 	}
 
 	tavor.MaxRepeat = opts.Global.MaxRepeat
+	tavor.MaxAssertRetries = opts.Fuzz.MaxAssertRetries
+	tavor.MaxFeedbackIterations = opts.Fuzz.MaxFeedbackIterations
+	tavor.CorpusFolder = string(opts.Fuzz.CorpusFolder)
+	tavor.GeneticPopulation = opts.Fuzz.GeneticPopulation
+	tavor.GeneticGenerations = opts.Fuzz.GeneticGenerations
+	tavor.GeneticElitism = opts.Fuzz.GeneticElitism
+	tavor.PairWiseStrength = opts.Fuzz.PairWiseStrength
+
+	if command == "lsp" {
+		if err := lsp.Serve(os.Stdin, os.Stdout); err != nil {
+			return exitError("language server failed: %v", err)
+		}
+
+		return exitCodeOk
+	}
 
 	log.Infof("open file %s", opts.Format.FormatFile)
 
@@ -381,14 +636,38 @@ func mainCmd(args []string) exitCodeType {
 		}
 	}()
 
-	doc, err := parser.ParseTavor(file)
+	switch command {
+	case "fmt":
+		return fmtCmd(opts, file)
+	case "lint":
+		return lintCmd(opts, file)
+	}
+
+	defines := make(map[string]string, len(opts.Format.Define))
+	for _, d := range opts.Format.Define {
+		i := strings.Index(d, "=")
+
+		defines[d[:i]] = d[i+1:]
+	}
+
+	if command == "test" {
+		return testCmd(opts, file, defines)
+	}
+
+	doc, constants, err := parser.ParseTavorTokenWithConstants(file, opts.Format.Start, defines)
 	if err != nil {
+		if errs, ok := err.(token.ParserErrors); ok {
+			return exitError("cannot parse tavor file, found %d errors:\n%v", len(errs), errs)
+		}
+
 		return exitError("cannot parse tavor file: %v", err)
 	}
 
 	log.Info("format file is valid")
 
 	if opts.Format.PrintInternal {
+		printConstants(constants)
+
 		log.Info("Internal AST:")
 
 		token.PrettyPrintInternalTree(os.Stdout, doc)
@@ -397,6 +676,8 @@ func mainCmd(args []string) exitCodeType {
 	}
 
 	if opts.Format.Print {
+		printConstants(constants)
+
 		log.Info("AST:")
 
 		token.PrettyPrintTree(os.Stdout, doc)
@@ -416,28 +697,47 @@ func mainCmd(args []string) exitCodeType {
 
 	switch command {
 	case "fuzz":
-		doc, err = applyFilters(opts, opts.Fuzz.Filter.Filters, doc)
+		doc, err = applyFilters(opts, opts.Fuzz.Filter, doc)
 		if err != nil {
 			return exitError("cannot apply filters: %v", err)
 		}
 
 		log.Infof("counted %d overall permutations", doc.PermutationsAll())
 
-		strat, err := tavorFuzzStrategy.New(string(opts.Fuzz.Strategy))
-		if err != nil {
-			return exitError(err.Error())
-		}
+		var ch chan struct{}
+		var feedback chan<- tavorFuzzStrategy.Feedback
+
+		if strat, err := tavorFuzzStrategy.NewFeedback(string(opts.Fuzz.Strategy)); err == nil {
+			log.Infof("using %s feedback fuzzing strategy", opts.Fuzz.Strategy)
+
+			ch, feedback, err = strat(doc, r)
+			if err != nil {
+				return exitError(err.Error())
+			}
+		} else {
+			strat, err := tavorFuzzStrategy.New(string(opts.Fuzz.Strategy))
+			if err != nil {
+				return exitError(err.Error())
+			}
+
+			log.Infof("using %s fuzzing strategy", opts.Fuzz.Strategy)
 
-		log.Infof("using %s fuzzing strategy", opts.Fuzz.Strategy)
+			ch, err = strat(doc, r)
+			if err != nil {
+				// the statistics show which assertion rejected the generations
+				printAssertStatistics(doc)
+
+				return exitError(err.Error())
+			}
+		}
 
 		folder := opts.Fuzz.ResultFolder
 		if len(folder) > 0 && folder[len(folder)-1] != '/' {
 			folder += "/"
 		}
 
-		ch, err := strat(doc, r)
-		if err != nil {
-			return exitError(err.Error())
+		best := &bestGenerations{
+			max: opts.Fuzz.BestCount,
 		}
 
 		if opts.Fuzz.Exec.Exec != "" {
@@ -508,6 +808,30 @@ func mainCmd(args []string) exitCodeType {
 					execCommand.Env = []string{fmt.Sprintf("TAVOR_FUZZ_FILE=%s", tmp.Name())}
 				}
 
+				var coverageDir string
+
+				if opts.Fuzz.Exec.ExecCoverageBitmap != "" {
+					if err := os.Truncate(string(opts.Fuzz.Exec.ExecCoverageBitmap), 0); err != nil && !os.IsNotExist(err) {
+						return exitError("Could not reset coverage bitmap: %s", err)
+					}
+
+					if execCommand.Env == nil {
+						execCommand.Env = os.Environ()
+					}
+					execCommand.Env = append(execCommand.Env, fmt.Sprintf("TAVOR_COVERAGE_FILE=%s", opts.Fuzz.Exec.ExecCoverageBitmap))
+				}
+				if opts.Fuzz.Exec.ExecCoverageGo {
+					coverageDir, err = ioutil.TempDir("", "tavor-coverage-")
+					if err != nil {
+						return exitError("Could not create coverage directory: %s", err)
+					}
+
+					if execCommand.Env == nil {
+						execCommand.Env = os.Environ()
+					}
+					execCommand.Env = append(execCommand.Env, fmt.Sprintf("GOCOVERDIR=%s", coverageDir))
+				}
+
 				if opts.General.Verbose || opts.General.Debug {
 					execCommand.Stderr = io.MultiWriter(&cmdStderr, os.Stderr)
 					execCommand.Stdout = io.MultiWriter(&cmdStdout, os.Stdout)
@@ -553,6 +877,44 @@ func mainCmd(args []string) exitCodeType {
 
 				log.Infof("Exit status was %d", cmdExitCode)
 
+				var coverage []uint64
+
+				if opts.Fuzz.Exec.ExecCoverageBitmap != "" {
+					c, err := readCoverageBitmap(string(opts.Fuzz.Exec.ExecCoverageBitmap))
+					if err != nil {
+						return exitError("Could not read coverage bitmap: %s", err)
+					}
+
+					coverage = append(coverage, c...)
+				}
+				if coverageDir != "" {
+					c, err := readGoCoverage(coverageDir)
+					if err != nil {
+						return exitError("Could not read Go coverage: %s", err)
+					}
+
+					coverage = append(coverage, c...)
+
+					if err := os.RemoveAll(coverageDir); err != nil {
+						log.Errorf("Could not remove coverage directory %q: %s", coverageDir, err)
+					}
+				}
+
+				log.Infof("Covered %d", len(coverage))
+
+				var fitness float64
+
+				if opts.Fuzz.Exec.ExecFitness {
+					fitness, err = parseFitness(cmdStdout.String())
+					if err != nil {
+						return exitError("Could not read fitness: %s", err)
+					}
+
+					log.Infof("Fitness is %g", fitness)
+				}
+
+				best.add(docOut, fitness)
+
 				oks := 0
 				oksNeeded := 0
 
@@ -650,6 +1012,13 @@ func mainCmd(args []string) exitCodeType {
 					}
 				}
 
+				if feedback != nil {
+					feedback <- tavorFuzzStrategy.Feedback{
+						Coverage: coverage,
+						Fitness:  fitness,
+					}
+				}
+
 				ch <- i
 
 				stepID++
@@ -692,11 +1061,13 @@ func mainCmd(args []string) exitCodeType {
 
 		GENERATIONSC:
 			for i := range ch {
+				docOut := doc.String()
+
 				_, err = stdin.Write([]byte("Generation\n"))
 				if err != nil {
 					return exitError("Could not write stdin to script: %s", err)
 				}
-				_, err = stdin.Write([]byte(doc.String()))
+				_, err = stdin.Write([]byte(docOut))
 				if err != nil {
 					return exitError("Could not write stdin to script: %s", err)
 				}
@@ -710,10 +1081,18 @@ func mainCmd(args []string) exitCodeType {
 					return exitError("Could not read stdout from script: %s", err)
 				}
 
-				switch feed {
-				case "YES\n":
+				// the feedback command can be followed by the coverage and the fitness of the generation
+				command, f, err := parseScriptFeedback(feed)
+				if err != nil {
+					return exitError("Feedback from script is invalid: %s", err)
+				}
+
+				best.add(docOut, f.Fitness)
+
+				switch command {
+				case "YES":
 					log.Infof("Same output")
-				case "NO\n":
+				case "NO":
 					log.Infof("Not the same output")
 
 					if opts.Fuzz.Exec.ExitOnError {
@@ -723,6 +1102,10 @@ func mainCmd(args []string) exitCodeType {
 					return exitError("Feedback from script was not YES nor NO: %s", feed)
 				}
 
+				if feedback != nil {
+					feedback <- f
+				}
+
 				ch <- i
 			}
 
@@ -781,11 +1164,23 @@ func mainCmd(args []string) exitCodeType {
 					}
 				}
 
+				if feedback != nil {
+					feedback <- tavorFuzzStrategy.Feedback{}
+				}
+
 				ch <- i
 			}
 		}
+
+		if opts.Fuzz.BestFolder != "" {
+			if err := best.write(string(opts.Fuzz.BestFolder), opts.Fuzz.ResultExtensions); err != nil {
+				return exitError(err.Error())
+			}
+		}
+
+		printAssertStatistics(doc)
 	case "graph":
-		doc, err = applyFilters(opts, opts.Graph.Filter.Filters, doc)
+		doc, err = applyFilters(opts, opts.Graph.Filter, doc)
 		if err != nil {
 			return exitError("cannot apply filters: %v", err)
 		}
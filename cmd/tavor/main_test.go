@@ -2,9 +2,11 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -32,6 +34,353 @@ func TestMain(t *testing.T) {
 	assert.Contains(t, out, "1\n2\n3")
 }
 
+func TestMainCheck(t *testing.T) {
+	f, err := ioutil.TempFile("", "tavor-main-test")
+	assert.Nil(t, err)
+
+	_, err = f.WriteString("START = A B\nA = (1\nB = 2 )\n")
+	assert.Nil(t, err)
+
+	err = f.Close()
+	assert.Nil(t, err)
+
+	defer func() {
+		err := os.Remove(f.Name())
+		assert.Nil(t, err)
+	}()
+
+	exitCode, out := execMain(t, []string{"--format-file", f.Name(), "--check"})
+
+	assert.Equal(t, exitCodeError, exitCode)
+	assert.Equal(t, "cannot parse tavor file, found 2 errors:\nL:3, C:1 - expected \")\" but got \"\\n\"\nL:3, C:8 - expected \"\\n\" but got \")\"\n", out)
+}
+
+func TestMainDefine(t *testing.T) {
+	f, err := ioutil.TempFile("", "tavor-main-test")
+	assert.Nil(t, err)
+
+	_, err = f.WriteString("Const N = 1\nConst Host = \"localhost\"\nSTART = Host +N(\"a\")\n")
+	assert.Nil(t, err)
+
+	err = f.Close()
+	assert.Nil(t, err)
+
+	defer func() {
+		err := os.Remove(f.Name())
+		assert.Nil(t, err)
+	}()
+
+	exitCode, out := execMain(t, []string{"--format-file", f.Name(), "--define", "N=3", "fuzz"})
+
+	assert.Equal(t, exitCodeOk, exitCode)
+	assert.Equal(t, "localhostaaa", out)
+
+	exitCode, out = execMain(t, []string{"--format-file", f.Name(), "--define", "N=3", "--define", "Host=example", "--print"})
+
+	assert.Equal(t, exitCodeOk, exitCode)
+	assert.True(t, strings.HasPrefix(out, "Const Host = \"example\"\nConst N = 3\n"))
+
+	exitCode, out = execMain(t, []string{"--format-file", f.Name(), "--define", "N=a", "fuzz"})
+
+	assert.Equal(t, exitCodeError, exitCode)
+	assert.Contains(t, out, `constant "N" needs an integer value but got "a"`)
+
+	exitCode, out = execMain(t, []string{"--format-file", f.Name(), "--define", "N", "fuzz"})
+
+	assert.Equal(t, exitCodeError, exitCode)
+	assert.Contains(t, out, `define "N" invalid`)
+}
+
+func TestMainStart(t *testing.T) {
+	f, err := ioutil.TempFile("", "tavor-main-test")
+	assert.Nil(t, err)
+
+	_, err = f.WriteString("START = \"a\" Header\nExport Header = \"b\" +2(Field)\nExport Field = \"c\"\n")
+	assert.Nil(t, err)
+
+	err = f.Close()
+	assert.Nil(t, err)
+
+	defer func() {
+		err := os.Remove(f.Name())
+		assert.Nil(t, err)
+	}()
+
+	exitCode, out := execMain(t, []string{"--format-file", f.Name(), "--start", "Header", "fuzz"})
+
+	assert.Equal(t, exitCodeOk, exitCode)
+	assert.Equal(t, "bcc", out)
+
+	exitCode, out = execMain(t, []string{"--format-file", f.Name(), "--start", "Nope", "fuzz"})
+
+	assert.Equal(t, exitCodeError, exitCode)
+	assert.Contains(t, out, `token "Nope" is not defined`)
+}
+
+func TestMainTest(t *testing.T) {
+	f, err := ioutil.TempFile("", "tavor-main-test")
+	assert.Nil(t, err)
+
+	_, err = f.WriteString("START = \"<\" [a-z] \">\"\n\ntest valid \"<a>\"\ntest invalid \"<1>\"\n")
+	assert.Nil(t, err)
+
+	err = f.Close()
+	assert.Nil(t, err)
+
+	defer func() {
+		err := os.Remove(f.Name())
+		assert.Nil(t, err)
+	}()
+
+	exitCode, out := execMain(t, []string{"--format-file", f.Name(), "test"})
+
+	assert.Equal(t, exitCodeOk, exitCode)
+	assert.Equal(t, "2 tests, 0 failed\n", out)
+
+	err = ioutil.WriteFile(f.Name(), []byte("START = \"<\" [a-z] \">\"\n\ntest valid \"<1>\"\ntest invalid \"<a>\"\n"), 0644)
+	assert.Nil(t, err)
+
+	exitCode, out = execMain(t, []string{"--format-file", f.Name(), "test"})
+
+	assert.Equal(t, exitCodeError, exitCode)
+	assert.Contains(t, out, f.Name()+":3:1: valid input \"<1>\" is invalid for START:\n\t")
+	assert.Contains(t, out, f.Name()+":4:1: invalid input \"<a>\" is valid for START\n")
+	assert.Contains(t, out, "2 tests, 2 failed\n")
+}
+
+func TestMainFuzzAssertionsViolated(t *testing.T) {
+	f, err := ioutil.TempFile("", "tavor-main-test")
+	assert.Nil(t, err)
+
+	_, err = f.WriteString("START = 1<a> Check\nCheck = {assert a.Value == 2}\n")
+	assert.Nil(t, err)
+
+	err = f.Close()
+	assert.Nil(t, err)
+
+	defer func() {
+		err := os.Remove(f.Name())
+		assert.Nil(t, err)
+	}()
+
+	exitCode, out := execMain(t, []string{"--format-file", f.Name(), "fuzz", "--max-assert-retries", "3"})
+
+	assert.Equal(t, exitCodeError, exitCode)
+	assert.Equal(t, "assertions are still violated after 3 retries\n", out)
+}
+
+func TestMainFuzzCoverage(t *testing.T) {
+	f, err := ioutil.TempFile("", "tavor-main-test")
+	assert.Nil(t, err)
+
+	_, err = f.WriteString("START = \"a\" | \"b\"\n")
+	assert.Nil(t, err)
+
+	err = f.Close()
+	assert.Nil(t, err)
+
+	defer func() {
+		err := os.Remove(f.Name())
+		assert.Nil(t, err)
+	}()
+
+	exitCode, out := execMain(t, []string{"--seed", "1", "--format-file", f.Name(), "fuzz", "--strategy", "Coverage", "--max-feedback-iterations", "5"})
+
+	assert.Equal(t, exitCodeOk, exitCode)
+	assert.Len(t, strings.Split(out, "\n"), 5)
+	for _, g := range strings.Split(out, "\n") {
+		assert.Contains(t, []string{"a", "b"}, g)
+	}
+}
+
+func TestMainFuzzMutation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tavor-main-test")
+	assert.Nil(t, err)
+
+	defer func() {
+		err := os.RemoveAll(dir)
+		assert.Nil(t, err)
+	}()
+
+	format := filepath.Join(dir, "format.tavor")
+	assert.Nil(t, ioutil.WriteFile(format, []byte("START = +1,3(\"a\" | \"b\")\n"), 0644))
+
+	corpus := filepath.Join(dir, "corpus")
+	assert.Nil(t, os.Mkdir(corpus, 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(corpus, "1"), []byte("aba"), 0644))
+
+	exitCode, out := execMain(t, []string{"--seed", "1", "--format-file", format, "fuzz", "--strategy", "Mutation", "--corpus-folder", corpus, "--max-feedback-iterations", "5"})
+
+	assert.Equal(t, exitCodeOk, exitCode)
+	assert.Len(t, strings.Split(out, "\n"), 5)
+	for _, g := range strings.Split(out, "\n") {
+		assert.Regexp(t, "^[ab]{1,3}$", g)
+	}
+
+	exitCode, _ = execMain(t, []string{"--format-file", format, "fuzz", "--strategy", "Mutation", "--corpus-folder", filepath.Join(dir, "missing")})
+
+	assert.Equal(t, exitCodeError, exitCode)
+}
+
+func TestMainFuzzPairWise(t *testing.T) {
+	f, err := ioutil.TempFile("", "tavor-main-test")
+	assert.Nil(t, err)
+
+	_, err = f.WriteString("START = (\"a\" | \"b\") (\"x\" | \"y\") ?(\"1\")\n")
+	assert.Nil(t, err)
+
+	err = f.Close()
+	assert.Nil(t, err)
+
+	defer func() {
+		err := os.Remove(f.Name())
+		assert.Nil(t, err)
+	}()
+
+	exitCode, out := execMain(t, []string{"--seed", "1", "--format-file", f.Name(), "fuzz", "--strategy", "PairWise"})
+
+	// the summary of the coverage is printed to STDERR after the generations
+	i := strings.Index(out, "covered ")
+	assert.Equal(t, exitCodeOk, exitCode)
+	assert.Len(t, strings.Split(out[:i], "\n"), 4)
+	assert.Contains(t, out[i:], "covered 12 of 12 2-wise combinations of 3 parameters with 4 rows (100.0%)")
+
+	exitCode, out = execMain(t, []string{"--seed", "1", "--format-file", f.Name(), "fuzz", "--strategy", "PairWise", "--pairwise-strength", "3"})
+
+	i = strings.Index(out, "covered ")
+	assert.Equal(t, exitCodeOk, exitCode)
+	assert.Len(t, strings.Split(out[:i], "\n"), 8)
+	assert.Contains(t, out[i:], "covered 8 of 8 3-wise combinations of 3 parameters with 8 rows (100.0%)")
+
+	exitCode, _ = execMain(t, []string{"--format-file", f.Name(), "fuzz", "--strategy", "PairWise", "--pairwise-strength", "0"})
+
+	assert.Equal(t, exitCodeError, exitCode)
+}
+
+func TestMainFuzzGenetic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tavor-main-test")
+	assert.Nil(t, err)
+
+	defer func() {
+		err := os.RemoveAll(dir)
+		assert.Nil(t, err)
+	}()
+
+	format := filepath.Join(dir, "format.tavor")
+	assert.Nil(t, ioutil.WriteFile(format, []byte("START = +1,4(\"a\" | \"b\")\n"), 0644))
+
+	// the fitness of a generation is its count of "b"
+	script := filepath.Join(dir, "fitness.sh")
+	assert.Nil(t, ioutil.WriteFile(script, []byte("tr -cd b | wc -c\n"), 0644))
+
+	best := filepath.Join(dir, "best")
+	assert.Nil(t, os.Mkdir(best, 0755))
+
+	exitCode, _ := execMain(t, []string{"--seed", "1", "--format-file", format, "fuzz", "--strategy", "Genetic", "--genetic-population", "6", "--genetic-generations", "8", "--exec", "sh " + script, "--exec-exact-exit-code", "0", "--exec-fitness", "--best-folder", best, "--best-count", "1"})
+
+	assert.Equal(t, exitCodeOk, exitCode)
+
+	files, err := ioutil.ReadDir(best)
+	assert.Nil(t, err)
+	assert.Len(t, files, 1)
+
+	data, err := ioutil.ReadFile(filepath.Join(best, files[0].Name()))
+	assert.Nil(t, err)
+	assert.Regexp(t, "^a?b{3,4}a?$", string(data))
+
+	exitCode, _ = execMain(t, []string{"--format-file", format, "fuzz", "--strategy", "Genetic", "--genetic-population", "2", "--genetic-elitism", "2"})
+
+	assert.Equal(t, exitCodeError, exitCode)
+}
+
+func TestMainFmt(t *testing.T) {
+	f, err := ioutil.TempFile("", "tavor-main-test")
+	assert.Nil(t, err)
+
+	_, err = f.WriteString("START  =  A|B\nA = 1\nB = 2\n")
+	assert.Nil(t, err)
+
+	err = f.Close()
+	assert.Nil(t, err)
+
+	defer func() {
+		err := os.Remove(f.Name())
+		assert.Nil(t, err)
+	}()
+
+	exitCode, out := execMain(t, []string{"--format-file", f.Name(), "fmt"})
+
+	assert.Equal(t, exitCodeOk, exitCode)
+	assert.Equal(t, "START = A | B\nA     = 1\nB     = 2\n", out)
+
+	exitCode, out = execMain(t, []string{"--format-file", f.Name(), "fmt", "-l"})
+
+	assert.Equal(t, exitCodeOk, exitCode)
+	assert.Equal(t, f.Name()+"\n", out)
+
+	exitCode, out = execMain(t, []string{"--format-file", f.Name(), "fmt", "-d"})
+
+	assert.Equal(t, exitCodeOk, exitCode)
+	assert.Equal(t, "--- "+f.Name()+".orig\n+++ "+f.Name()+"\n@@ -1,3 +1,3 @@\n-START  =  A|B\n-A = 1\n-B = 2\n+START = A | B\n+A     = 1\n+B     = 2\n", out)
+
+	exitCode, out = execMain(t, []string{"--format-file", f.Name(), "fmt", "-w"})
+
+	assert.Equal(t, exitCodeOk, exitCode)
+	assert.Equal(t, "", out)
+
+	exitCode, out = execMain(t, []string{"--format-file", f.Name(), "fmt", "-l", "-d"})
+
+	assert.Equal(t, exitCodeOk, exitCode)
+	assert.Equal(t, "", out)
+}
+
+func TestMainLint(t *testing.T) {
+	f, err := ioutil.TempFile("", "tavor-main-test")
+	assert.Nil(t, err)
+
+	_, err = f.WriteString("A = A 1 | 2\nSTART = A | \"a\" | \"a\"\n")
+	assert.Nil(t, err)
+
+	err = f.Close()
+	assert.Nil(t, err)
+
+	defer func() {
+		err := os.Remove(f.Name())
+		assert.Nil(t, err)
+	}()
+
+	exitCode, out := execMain(t, []string{"--format-file", f.Name(), "lint"})
+
+	assert.Equal(t, exitCodeError, exitCode)
+	assert.Contains(t, out, f.Name()+":1:1: error: token \"A\" is left-recursive (A -> A) (left-recursion)")
+	assert.Contains(t, out, f.Name()+":2:19: warning: alternative 3 can never be chosen while parsing since it is equal to alternative 2 \"a\" (never-chosen-alternative)")
+
+	exitCode, out = execMain(t, []string{"--format-file", f.Name(), "lint", "--json"})
+
+	assert.Equal(t, exitCodeError, exitCode)
+
+	var findings []lintFinding
+	assert.Nil(t, json.Unmarshal([]byte(out), &findings))
+	assert.Equal(t, []lintFinding{
+		{
+			File:     f.Name(),
+			Line:     1,
+			Column:   1,
+			Severity: "error",
+			Type:     "left-recursion",
+			Message:  "token \"A\" is left-recursive (A -> A)",
+		},
+		{
+			File:     f.Name(),
+			Line:     2,
+			Column:   19,
+			Severity: "warning",
+			Type:     "never-chosen-alternative",
+			Message:  "alternative 3 can never be chosen while parsing since it is equal to alternative 2 \"a\"",
+		},
+	}, findings)
+}
+
 func TestMainCommandListingOptions(t *testing.T) {
 
 	exitCode, out := execMain(t, []string{"fuzz", "--list-exec-argument-types"})
@@ -52,6 +52,27 @@ func Register(name string, filt Filter) {
 	filterLookup[name] = filt
 }
 
+// Restrict returns a fuzzing filter which applies the given filter only onto tokens originating from one of the given token definitions.
+func Restrict(filt Filter, definitionNames ...string) Filter {
+	names := make(map[string]struct{}, len(definitionNames))
+	for _, name := range definitionNames {
+		names[name] = struct{}{}
+	}
+
+	return func(tok token.Token) (token.Token, error) {
+		src, ok := token.GetSource(tok)
+		if !ok {
+			return nil, nil
+		}
+
+		if _, ok := names[src.Name]; !ok {
+			return nil, nil
+		}
+
+		return filt(tok)
+	}
+}
+
 // ApplyFilters applies a set of filters onto a token.
 // Filters are applied in the order in which they are given. If multiple filters are replacing the same token, only the first replacement will be applied.
 // Filters are not applied onto filter generated tokens.
@@ -114,3 +114,21 @@ func TestStrategyReplaces(t *testing.T) {
 		Equal(t, "ab", rootNew.String())
 	}
 }
+
+func TestRestrict(t *testing.T) {
+	a := primitives.NewConstantString("a")
+	b := primitives.NewConstantString("b")
+	c := primitives.NewConstantString("c")
+	root := lists.NewConcatenation(a, b, c)
+
+	token.SetSource(a, token.Source{Name: "A"})
+	token.SetSource(b, token.Source{Name: "B"})
+
+	filters := []Filter{
+		Restrict(NewMockReplaceFilter("x"), "A", "C"),
+	}
+
+	rootNew, err := ApplyFilters(filters, root)
+	Nil(t, err)
+	Equal(t, "axbc", rootNew.String())
+}
@@ -0,0 +1,28 @@
+package filter
+
+import (
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/lists"
+	"github.com/zimmski/tavor/token/primitives"
+)
+
+func init() {
+	Register("InconsistentLength", NewInconsistentLength)
+}
+
+// NewInconsistentLength implements a fuzzing filter for inconsistent lengths of length-prefixed tokens.
+// This filter searches the token graph for TLV tokens and makes their length differ by one byte from the length of their value, i.e. the length is either one byte too short or one byte too long. Since the length of an empty value which is one byte too short wraps around to the maximum length of the encoding, this filter always generates invalid data, which can be used for example for negative tests.
+func NewInconsistentLength(tok token.Token) (token.Token, error) {
+	t, ok := tok.(*lists.TLV)
+	if !ok || t.LengthDelta() != nil {
+		return nil, nil
+	}
+
+	// the token itself is the replacement since only its length is changed
+	t.SetLengthDelta(lists.NewOne(
+		primitives.NewConstantInt(-1),
+		primitives.NewConstantInt(1),
+	))
+
+	return t, nil
+}
@@ -0,0 +1,49 @@
+package filter
+
+import (
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/token/lists"
+	"github.com/zimmski/tavor/token/primitives"
+)
+
+func TestNewInconsistentLengthFilter(t *testing.T) {
+	// not a TLV token
+	{
+		replacement, err := NewInconsistentLength(primitives.NewConstantString("ab"))
+		Nil(t, err)
+		Nil(t, replacement)
+	}
+	// lengths are one byte too short or too long
+	{
+		value := primitives.NewConstantString("ab")
+		root := lists.NewTLV(lists.IntEncoding{}, nil, lists.IntEncodings["UInt8"], value)
+
+		rootNew, err := ApplyFilters([]Filter{NewInconsistentLength}, root)
+		Nil(t, err)
+		Equal(t, root, rootNew)
+		Equal(t, "\x01ab", rootNew.String())
+		Equal(t, 2, rootNew.PermutationsAll())
+
+		v, _ := rootNew.(*lists.TLV).Get(0)
+		Equal(t, value, v)
+
+		Nil(t, rootNew.(*lists.TLV).LengthDelta().Permutation(1))
+		Equal(t, "\x03ab", rootNew.String())
+
+		// already inconsistent lengths are not changed again
+		replacement, err := NewInconsistentLength(rootNew)
+		Nil(t, err)
+		Nil(t, replacement)
+	}
+	// empty values wrap around
+	{
+		root := lists.NewTLV(lists.IntEncoding{}, nil, lists.IntEncodings["UInt16be"], lists.NewRepeat(primitives.NewConstantString("a"), 0, 0))
+
+		rootNew, err := ApplyFilters([]Filter{NewInconsistentLength}, root)
+		Nil(t, err)
+		Equal(t, "\xff\xff", rootNew.String())
+	}
+}
@@ -95,4 +95,24 @@ func TestNewPositiveBoundaryValueAnalysisFilter(t *testing.T) {
 			primitives.NewConstantString("z"),
 		))
 	}
+	// fields of bits
+	{
+		root := lists.NewBits(
+			lists.BitOrderMSB,
+			lists.BitField{Width: 4, Token: primitives.NewRangeInt(0, 15)},
+			lists.BitField{Width: 4, Token: primitives.NewConstantInt(1)},
+		)
+		rootNew, err := ApplyFilters([]Filter{NewPositiveBoundaryValueAnalysis}, root)
+		Nil(t, err)
+		Equal(t, rootNew, lists.NewBits(
+			lists.BitOrderMSB,
+			lists.BitField{Width: 4, Token: lists.NewOne(
+				primitives.NewConstantInt(0),
+				primitives.NewConstantInt(8),
+				primitives.NewConstantInt(15),
+			)},
+			lists.BitField{Width: 4, Token: primitives.NewConstantInt(1)},
+		))
+		Equal(t, "\x01", rootNew.String())
+	}
 }
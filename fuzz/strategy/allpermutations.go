@@ -4,6 +4,7 @@ import (
 	"github.com/zimmski/tavor/log"
 	"github.com/zimmski/tavor/rand"
 	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/conditions"
 )
 
 func init() {
@@ -22,7 +23,7 @@ type allPermutations struct {
 }
 
 // NewAllPermutations implements a fuzzing strategy that generates all possible permutations of a token graph.
-// Every iteration of the strategy generates a new permutation. The generation is deterministic. Since this strategy really produces every possible permutation of a token graph, it is advised to only use the strategy on graphs with few states since the state explosion problem manifests itself quite fast.
+// Every iteration of the strategy generates a new permutation. The generation is deterministic. Since this strategy really produces every possible permutation of a token graph, it is advised to only use the strategy on graphs with few states since the state explosion problem manifests itself quite fast. Permutations which violate an assertion or a unique repeat of the graph are skipped.
 func NewAllPermutations(root token.Token, r rand.Rand) (chan struct{}, error) {
 	if token.LoopExists(root) {
 		return nil, &Error{
@@ -177,6 +178,12 @@ func (s *allPermutations) nextStep(continueFuzzing chan struct{}) bool {
 	_ = token.ResetResetTokens(s.root)
 	token.ResetCombinedScope(s.root)
 
+	if !conditions.CheckConstraints(s.root) {
+		log.Debug("skip fuzzing step since an assertion is violated")
+
+		return true
+	}
+
 	log.Debug("done with fuzzing step")
 
 	// done with this fuzzing step
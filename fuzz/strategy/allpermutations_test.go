@@ -586,6 +586,45 @@ func TestAllPermutationsStrategy(t *testing.T) {
 			},
 		)
 	}
+	{
+		// Permutations which violate an assertion are skipped
+		validateTavorAllPermutations(
+			t,
+			`
+				START = (1 | 2 | 3)<a> (1 | 2)<b> Check
+
+				Check = {assert a.Value + b.Value <= 3}
+			`,
+			[]string{
+				"11",
+				"21",
+				"12",
+			},
+		)
+	}
+	{
+		// Permutations which violate a unique repeat are skipped
+		validateTavorAllPermutations(
+			t,
+			`
+				START = +2,3unique(1 | 2 | 3)
+			`,
+			[]string{
+				"21",
+				"31",
+				"12",
+				"32",
+				"13",
+				"23",
+				"321",
+				"231",
+				"312",
+				"132",
+				"213",
+				"123",
+			},
+		)
+	}
 }
 
 func validateTavorAllPermutations(t *testing.T, format string, expect []string) {
@@ -624,6 +663,25 @@ func validateTokenAllPermutations(t *testing.T, tok token.Token, expect []string
 	Equal(t, expect, got)
 }
 
+func TestAllPermutationsStrategySymbols(t *testing.T) {
+	for _, format := range symbolsFormats {
+		o, err := parser.ParseTavor(strings.NewReader(format))
+		Nil(t, err)
+
+		ch, err := NewAllPermutations(o, test.NewRandTest(1))
+		Nil(t, err)
+
+		uses := 0
+		for i := range ch {
+			uses += checkSymbolUses(t, o.String())
+
+			ch <- i
+		}
+
+		True(t, uses > 0, format)
+	}
+}
+
 func TestAllPermutationsStrategyLoopDetection(t *testing.T) {
 	testStrategyLoopDetection(t, NewAllPermutations)
 }
@@ -4,6 +4,7 @@ import (
 	"github.com/zimmski/tavor/log"
 	"github.com/zimmski/tavor/rand"
 	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/conditions"
 )
 
 func init() {
@@ -49,7 +50,7 @@ func newAlmostAllPermutations(root token.Token) *almostAllPermutations {
 }
 
 // NewAlmostAllPermutations implements a fuzzing strategy that generates "almost" all possible permutations of a token graph.
-// Every iteration of the strategy generates a new permutation. The generation is deterministic. This strategy does not cover all repititional permutations which can be helpful when less permutations are needed but a almost complete permutation coverage is still needed. For example the definition +2(?(1)?(2)) does not result in 16 permutations but instead it results in only 7.
+// Every iteration of the strategy generates a new permutation. The generation is deterministic. This strategy does not cover all repititional permutations which can be helpful when less permutations are needed but a almost complete permutation coverage is still needed. For example the definition +2(?(1)?(2)) does not result in 16 permutations but instead it results in only 7. Permutations which violate an assertion or a unique repeat of the graph are skipped.
 func NewAlmostAllPermutations(root token.Token, r rand.Rand) (chan struct{}, error) {
 	if token.LoopExists(root) {
 		return nil, &Error{
@@ -79,6 +80,14 @@ func NewAlmostAllPermutations(root token.Token, r rand.Rand) (chan struct{}, err
 		_ = token.ResetResetTokens(s.root)
 		token.ResetCombinedScope(s.root)
 
+		if !conditions.CheckConstraints(s.root) {
+			log.Debug("skip last fuzzing step since an assertion is violated")
+
+			close(continueFuzzing)
+
+			return
+		}
+
 		log.Debug("done with fuzzing step")
 
 		// done with the last fuzzing step
@@ -174,16 +183,20 @@ STEP:
 			_ = token.ResetResetTokens(s.root)
 			token.ResetCombinedScope(s.root)
 
-			log.Debug("done with fuzzing step")
+			if conditions.CheckConstraints(s.root) {
+				log.Debug("done with fuzzing step")
 
-			// done with this fuzzing step
-			continueFuzzing <- struct{}{}
+				// done with this fuzzing step
+				continueFuzzing <- struct{}{}
 
-			// wait until we are allowed to continue
-			if _, ok := <-continueFuzzing; !ok {
-				log.Debug("fuzzing channel closed from outside")
+				// wait until we are allowed to continue
+				if _, ok := <-continueFuzzing; !ok {
+					log.Debug("fuzzing channel closed from outside")
 
-				return false
+					return false
+				}
+			} else {
+				log.Debug("skip fuzzing step since an assertion is violated")
 			}
 
 			log.Debug("start fuzzing step")
@@ -0,0 +1,104 @@
+package strategy
+
+import (
+	"github.com/zimmski/tavor/rand"
+)
+
+// choiceRand implements a random generator which records every choice of a generation.
+// Given choices are replayed before the wrapped random generator is used. Recorded choices of a generation can therefore be replayed, mutated and spliced to generate similar generations.
+type choiceRand struct {
+	r rand.Rand
+
+	replay  []int64
+	choices []int64
+}
+
+func newChoiceRand(r rand.Rand, replay []int64) *choiceRand {
+	return &choiceRand{
+		r:      r,
+		replay: replay,
+	}
+}
+
+// next returns the next replayed choice or a new random one
+func (c *choiceRand) next() int64 {
+	var v int64
+
+	if len(c.choices) < len(c.replay) {
+		v = c.replay[len(c.choices)]
+	} else {
+		v = c.r.Int63()
+	}
+
+	c.choices = append(c.choices, v)
+
+	return v
+}
+
+// Int returns a non-negative pseudo-random int
+func (c *choiceRand) Int() int {
+	return int(c.next())
+}
+
+// Intn returns, as an int, a non-negative pseudo-random number in [0,n). It panics if n <= 0.
+func (c *choiceRand) Intn(n int) int {
+	if n <= 0 {
+		panic("invalid argument to Intn")
+	}
+
+	return int(c.next() % int64(n))
+}
+
+// Int63 returns a non-negative pseudo-random 63-bit integer as an int64.
+func (c *choiceRand) Int63() int64 {
+	return c.next()
+}
+
+// Int63n returns, as an int64, a non-negative pseudo-random number in [0,n). It panics if n <= 0.
+func (c *choiceRand) Int63n(n int64) int64 {
+	if n <= 0 {
+		panic("invalid argument to Int63n")
+	}
+
+	return c.next() % n
+}
+
+// Seed uses the provided seed value to initialize the generator to a deterministic state.
+func (c *choiceRand) Seed(seed int64) {
+	c.r.Seed(seed)
+}
+
+// mutateChoices returns a mutated copy of the given choices.
+// A mutation either replaces one choice, cuts the choices at a random position so that the rest is generated anew or splices the beginning of the choices with the end of other choices.
+func mutateChoices(r rand.Rand, choices []int64, other []int64) []int64 {
+	if len(choices) == 0 {
+		return nil
+	}
+
+	i := r.Intn(len(choices))
+
+	switch r.Intn(3) {
+	case 0:
+		m := make([]int64, len(choices))
+		copy(m, choices)
+
+		m[i] = r.Int63()
+
+		return m
+	case 1:
+		m := make([]int64, i)
+		copy(m, choices)
+
+		return m
+	default:
+		j := 0
+		if len(other) != 0 {
+			j = r.Intn(len(other))
+		}
+
+		m := make([]int64, 0, i+len(other)-j)
+		m = append(m, choices[:i]...)
+
+		return append(m, other[j:]...)
+	}
+}
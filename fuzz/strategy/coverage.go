@@ -0,0 +1,135 @@
+package strategy
+
+import (
+	"github.com/zimmski/tavor"
+	"github.com/zimmski/tavor/log"
+	"github.com/zimmski/tavor/rand"
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/conditions"
+)
+
+func init() {
+	RegisterFeedback("Coverage", NewCoverage)
+}
+
+type coverage struct {
+	random
+
+	covered coverageSet
+	corpus  [][]int64
+}
+
+// coverageSet holds everything which was covered by the generations of a fuzzing strategy
+type coverageSet map[uint64]struct{}
+
+// NewCoverage implements a coverage-guided fuzzing strategy which keeps a corpus of generations that increased the coverage of the tested program.
+// Every iteration either generates a new random permutation of the token graph or mutates the recorded random choices of a corpus generation. Choices are mutated by replacing one choice, by generating the choices after a random position anew or by splicing the choices of two corpus generations. A generation is added to the corpus if its feedback holds coverage which was not covered by a previous generation. The strategy does tavor.MaxFeedbackIterations iterations and permutations which violate an assertion of the graph are retried at most tavor.MaxAssertRetries times. The determinism is dependent on the random generator and the feedback.
+func NewCoverage(root token.Token, r rand.Rand) (chan struct{}, chan<- Feedback, error) {
+	if r == nil {
+		return nil, nil, &Error{
+			Message: "random generator is nil",
+			Type:    ErrNilRandomGenerator,
+		}
+	}
+
+	if token.LoopExists(root) {
+		return nil, nil, &Error{
+			Message: "found endless loop in graph. Cannot proceed.",
+			Type:    ErrEndlessLoopDetected,
+		}
+	}
+
+	s := &coverage{
+		random: random{
+			root: root,
+		},
+
+		covered: make(coverageSet),
+	}
+
+	continueFuzzing := make(chan struct{})
+	feedback := make(chan Feedback)
+
+	go func() {
+		log.Debug("start coverage fuzzing routine")
+
+		for i := 0; i < tavor.MaxFeedbackIterations; i++ {
+			choices, ok := s.generate(r)
+			if !ok {
+				log.Errorf("assertions are still violated after %d retries", tavor.MaxAssertRetries)
+
+				break
+			}
+
+			log.Debugf("done with fuzzing step %d", i)
+
+			continueFuzzing <- struct{}{}
+
+			f, ok := <-feedback
+			if !ok {
+				log.Debug("feedback channel closed from outside")
+
+				return
+			}
+
+			if s.covered.add(f.Coverage) != 0 {
+				s.corpus = append(s.corpus, choices)
+			}
+
+			log.Infof("covered %d in total, corpus has %d generations", len(s.covered), len(s.corpus))
+
+			if _, ok := <-continueFuzzing; !ok {
+				log.Debug("fuzzing channel closed from outside")
+
+				close(feedback)
+
+				return
+			}
+		}
+
+		close(continueFuzzing)
+		close(feedback)
+	}()
+
+	return continueFuzzing, feedback, nil
+}
+
+// generate permutates the token graph using new or mutated choices of the corpus and returns the recorded choices of the generation
+func (s *coverage) generate(r rand.Rand) ([]int64, bool) {
+	for i := 0; i <= tavor.MaxAssertRetries; i++ {
+		var replay []int64
+
+		// explore new generations from time to time even if there is a corpus
+		if len(s.corpus) != 0 && r.Intn(4) != 0 {
+			replay = mutateChoices(r, s.corpus[r.Intn(len(s.corpus))], s.corpus[r.Intn(len(s.corpus))])
+		}
+
+		c := newChoiceRand(r, replay)
+
+		s.fuzz(s.root, c, token.NewVariableScope())
+		s.fuzzYADDA(s.root, c)
+
+		if conditions.CheckConstraints(s.root) {
+			return c.choices, true
+		}
+
+		log.Debug("retry fuzzing step since an assertion is violated")
+	}
+
+	return nil, false
+}
+
+// add records the given coverage and returns how much of it was not covered before
+func (cs coverageSet) add(coverage []uint64) int {
+	n := 0
+
+	for _, c := range coverage {
+		if _, ok := cs[c]; !ok {
+			cs[c] = struct{}{}
+
+			n++
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,117 @@
+package strategy
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor"
+	"github.com/zimmski/tavor/parser"
+)
+
+func TestCoverageStrategyNilRandomGenerator(t *testing.T) {
+	ch, feedback, err := NewCoverage(nil, nil)
+	Nil(t, ch)
+	Nil(t, feedback)
+	Equal(t, ErrNilRandomGenerator, err.(*Error).Type)
+}
+
+func TestCoverageStrategy(t *testing.T) {
+	defer func(i int) {
+		tavor.MaxFeedbackIterations = i
+	}(tavor.MaxFeedbackIterations)
+	tavor.MaxFeedbackIterations = 200
+
+	root, err := parser.ParseTavor(strings.NewReader("START = +1,4(\"a\" | \"b\" | \"c\")\n"))
+	Nil(t, err)
+
+	r := rand.New(rand.NewSource(1))
+
+	ch, feedback, err := NewCoverage(root, r)
+	Nil(t, err)
+
+	// every pair of following characters is an edge of the tested program
+	edges := make(map[uint64]struct{})
+	generations := 0
+
+	for i := range ch {
+		generations++
+
+		var coverage []uint64
+
+		s := root.String()
+		for j := 1; j < len(s); j++ {
+			e := uint64(s[j-1])<<8 | uint64(s[j])
+
+			coverage = append(coverage, e)
+			edges[e] = struct{}{}
+		}
+
+		feedback <- Feedback{
+			Coverage: coverage,
+		}
+
+		ch <- i
+	}
+
+	Equal(t, 200, generations)
+	Equal(t, 9, len(edges))
+}
+
+func TestCoverageStrategyWithoutFeedback(t *testing.T) {
+	defer func(i int) {
+		tavor.MaxFeedbackIterations = i
+	}(tavor.MaxFeedbackIterations)
+	tavor.MaxFeedbackIterations = 3
+
+	root, err := parser.ParseTavor(strings.NewReader("START = \"a\" | \"b\"\n"))
+	Nil(t, err)
+
+	strat, err := New("Coverage")
+	Nil(t, err)
+
+	ch, err := strat(root, rand.New(rand.NewSource(1)))
+	Nil(t, err)
+
+	generations := 0
+
+	for i := range ch {
+		generations++
+
+		True(t, root.String() == "a" || root.String() == "b")
+
+		ch <- i
+	}
+
+	Equal(t, 3, generations)
+}
+
+func TestCoverageStrategyLoopDetection(t *testing.T) {
+	testStrategyLoopDetection(t, withoutFeedback(NewCoverage))
+}
+
+func TestChoiceRand(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	c := newChoiceRand(r, nil)
+	a := []int{c.Intn(10), c.Intn(100), c.Intn(1000)}
+
+	// replayed choices lead to the same values
+	c2 := newChoiceRand(r, c.choices)
+	Equal(t, a, []int{c2.Intn(10), c2.Intn(100), c2.Intn(1000)})
+	Equal(t, c.choices, c2.choices)
+
+	// choices after the replayed ones are random
+	c3 := newChoiceRand(r, c.choices[:1])
+	Equal(t, a[0], c3.Intn(10))
+	c3.Intn(100)
+	Equal(t, 2, len(c3.choices))
+
+	for i := 0; i < 100; i++ {
+		m := mutateChoices(r, c.choices, c2.choices)
+		True(t, len(m) <= 2*len(c.choices))
+	}
+	Nil(t, mutateChoices(r, nil, c.choices))
+}
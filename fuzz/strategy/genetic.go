@@ -0,0 +1,180 @@
+package strategy
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/zimmski/tavor"
+	"github.com/zimmski/tavor/log"
+	"github.com/zimmski/tavor/rand"
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/conditions"
+)
+
+func init() {
+	RegisterFeedback("Genetic", NewGenetic)
+}
+
+// geneticIndividual holds a generation and its fitness
+type geneticIndividual struct {
+	input   string
+	fitness float64
+}
+
+type genetic struct {
+	mutation
+
+	population []geneticIndividual
+}
+
+// NewGenetic implements a genetic fuzzing strategy which evolves a population of generations guided by their fitness.
+// The first generation of tavor.GeneticPopulation individuals is generated at random. Every following generation carries over the tavor.GeneticElitism fittest individuals unchanged and breeds the remaining individuals. Parents are chosen by tournaments between two random individuals of the previous generation. A child is the first parent whose subtree is exchanged with a compatible subtree of the second parent by a crossover, and whose random subtree is permutated anew by a mutation. Children which violate an assertion, a unique repeat or a symbol use are bred again at most tavor.MaxAssertRetries times before the first parent is used instead. The fitness of every individual is given by its feedback. Since individuals are bred by parsing them with the token graph, token graphs with tokens which do not implement parsing yet, e.g. sequences, are rejected with an error of type ErrInvalidConfiguration. The strategy evolves tavor.GeneticGenerations generations, individuals which are carried over are not generated again. The determinism is dependent on the random generator and the feedback.
+func NewGenetic(root token.Token, r rand.Rand) (chan struct{}, chan<- Feedback, error) {
+	if r == nil {
+		return nil, nil, &Error{
+			Message: "random generator is nil",
+			Type:    ErrNilRandomGenerator,
+		}
+	}
+
+	if tavor.GeneticPopulation < 1 || tavor.GeneticGenerations < 1 || tavor.GeneticElitism < 0 || tavor.GeneticElitism >= tavor.GeneticPopulation {
+		return nil, nil, &Error{
+			Message: "population and generations have to be at least 1 and elitism has to be smaller than the population",
+			Type:    ErrInvalidConfiguration,
+		}
+	}
+
+	if token.LoopExists(root) {
+		return nil, nil, &Error{
+			Message: "found endless loop in graph. Cannot proceed.",
+			Type:    ErrEndlessLoopDetected,
+		}
+	}
+
+	// individuals are kept as strings which are parsed again for breeding
+	if tok := unparseableToken(root); tok != nil {
+		return nil, nil, &Error{
+			Message: fmt.Sprintf("the genetic strategy cannot parse tokens of type %T%s", tok, token.SourceSuffix(tok)),
+			Type:    ErrInvalidConfiguration,
+		}
+	}
+
+	s := &genetic{
+		mutation: mutation{
+			random: random{
+				root: root,
+			},
+		},
+	}
+
+	continueFuzzing := make(chan struct{})
+	feedback := make(chan Feedback)
+
+	go func() {
+		log.Debug("start genetic fuzzing routine")
+
+		for g := 0; g < tavor.GeneticGenerations; g++ {
+			var next []geneticIndividual
+
+			if g != 0 {
+				// the fittest individuals survive
+				next = append(next, s.population[:tavor.GeneticElitism]...)
+			}
+
+			for len(next) < tavor.GeneticPopulation {
+				if g == 0 {
+					s.generateRandom(r)
+				} else {
+					s.breed(r)
+				}
+
+				log.Debugf("done with individual %d of generation %d", len(next), g)
+
+				continueFuzzing <- struct{}{}
+
+				f, ok := <-feedback
+				if !ok {
+					log.Debug("feedback channel closed from outside")
+
+					return
+				}
+
+				next = append(next, geneticIndividual{
+					input:   s.root.String(),
+					fitness: f.Fitness,
+				})
+
+				if _, ok := <-continueFuzzing; !ok {
+					log.Debug("fuzzing channel closed from outside")
+
+					close(feedback)
+
+					return
+				}
+			}
+
+			sort.SliceStable(next, func(i, j int) bool {
+				return next[i].fitness > next[j].fitness
+			})
+
+			s.population = next
+
+			log.Infof("generation %d has a best fitness of %g", g, s.population[0].fitness)
+		}
+
+		close(continueFuzzing)
+		close(feedback)
+	}()
+
+	return continueFuzzing, feedback, nil
+}
+
+// breed creates a child of two individuals of the population by crossover and mutation
+func (s *genetic) breed(r rand.Rand) {
+	a := s.tournament(r)
+	b := s.tournament(r)
+
+	if errs := parseInternal(s.root, a.input); len(errs) != 0 {
+		log.Debugf("cannot parse individual %q: %v", a.input, errs[0])
+
+		s.generateRandom(r)
+
+		return
+	}
+
+	for i := 0; i <= tavor.MaxAssertRetries; i++ {
+		if r.Intn(10) < 7 {
+			s.spliceWith(r, b.input)
+		}
+
+		if r.Intn(10) < 3 || s.root.String() == a.input {
+			s.permutate(r)
+		}
+
+		if conditions.CheckConstraints(s.root) {
+			return
+		}
+
+		log.Debug("retry breeding since an assertion is violated")
+
+		if !s.restore(a.input) {
+			s.generateRandom(r)
+
+			return
+		}
+	}
+
+	log.Debugf("fall back to the first parent since assertions are still violated after %d retries", tavor.MaxAssertRetries)
+}
+
+// tournament returns the fitter of two random individuals of the population
+func (s *genetic) tournament(r rand.Rand) geneticIndividual {
+	a := s.population[r.Intn(len(s.population))]
+	b := s.population[r.Intn(len(s.population))]
+
+	if b.fitness > a.fitness {
+		return b
+	}
+
+	return a
+}
@@ -0,0 +1,164 @@
+package strategy
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor"
+	"github.com/zimmski/tavor/parser"
+)
+
+func TestGeneticStrategyNilRandomGenerator(t *testing.T) {
+	ch, feedback, err := NewGenetic(nil, nil)
+	Nil(t, ch)
+	Nil(t, feedback)
+	Equal(t, ErrNilRandomGenerator, err.(*Error).Type)
+}
+
+func TestGeneticStrategyInvalidConfiguration(t *testing.T) {
+	defer func(population, elitism int) {
+		tavor.GeneticPopulation = population
+		tavor.GeneticElitism = elitism
+	}(tavor.GeneticPopulation, tavor.GeneticElitism)
+
+	root, err := parser.ParseTavor(strings.NewReader("START = \"a\"\n"))
+	Nil(t, err)
+
+	tavor.GeneticPopulation = 2
+	tavor.GeneticElitism = 2
+
+	ch, _, err := NewGenetic(root, rand.New(rand.NewSource(1)))
+	Nil(t, ch)
+	Equal(t, ErrInvalidConfiguration, err.(*Error).Type)
+}
+
+func TestGeneticStrategyUnparseableTokens(t *testing.T) {
+	root, err := parser.ParseTavor(strings.NewReader(`
+		$Id Sequence = start: 1,
+			step: 1
+
+		START = $Id.Next " " $Id.Existing
+	`))
+	Nil(t, err)
+
+	ch, feedback, err := NewGenetic(root, rand.New(rand.NewSource(1)))
+	Nil(t, ch)
+	Nil(t, feedback)
+	Equal(t, ErrInvalidConfiguration, err.(*Error).Type)
+}
+
+func TestGeneticStrategy(t *testing.T) {
+	defer func(population, generations, elitism int) {
+		tavor.GeneticPopulation = population
+		tavor.GeneticGenerations = generations
+		tavor.GeneticElitism = elitism
+	}(tavor.GeneticPopulation, tavor.GeneticGenerations, tavor.GeneticElitism)
+
+	tavor.GeneticPopulation = 10
+	tavor.GeneticGenerations = 15
+	tavor.GeneticElitism = 2
+
+	root, err := parser.ParseTavor(strings.NewReader("START = +1,10(\"a\" | \"b\")\n"))
+	Nil(t, err)
+
+	ch, feedback, err := NewGenetic(root, rand.New(rand.NewSource(1)))
+	Nil(t, err)
+
+	// the fitness is the number of "a" characters
+	var fitness []float64
+
+	for i := range ch {
+		f := float64(strings.Count(root.String(), "a"))
+		fitness = append(fitness, f)
+
+		feedback <- Feedback{
+			Fitness: f,
+		}
+
+		ch <- i
+	}
+
+	// elite individuals are not generated again
+	Equal(t, 10+14*8, len(fitness))
+
+	best := func(fs []float64) float64 {
+		b := fs[0]
+		for _, f := range fs {
+			if f > b {
+				b = f
+			}
+		}
+
+		return b
+	}
+
+	first := best(fitness[:10])
+	last := best(fitness[len(fitness)-8:])
+
+	True(t, last > first)
+	True(t, best(fitness) >= 8)
+}
+
+func TestGeneticStrategyConstraints(t *testing.T) {
+	defer func(population, generations, elitism int) {
+		tavor.GeneticPopulation = population
+		tavor.GeneticGenerations = generations
+		tavor.GeneticElitism = elitism
+	}(tavor.GeneticPopulation, tavor.GeneticGenerations, tavor.GeneticElitism)
+
+	tavor.GeneticPopulation = 10
+	tavor.GeneticGenerations = 15
+	tavor.GeneticElitism = 2
+
+	// children must not duplicate values of unique repeats
+	{
+		root, err := parser.ParseTavor(strings.NewReader(`
+			START = +1,4unique(Item)
+
+			Item = "a" | "b" | "c" | "d"
+		`))
+		Nil(t, err)
+
+		ch, feedback, err := NewGenetic(root, rand.New(rand.NewSource(1)))
+		Nil(t, err)
+
+		for i := range ch {
+			s := root.String()
+
+			found := make(map[rune]struct{})
+			for _, c := range s {
+				_, ok := found[c]
+				False(t, ok, s)
+
+				found[c] = struct{}{}
+			}
+
+			feedback <- Feedback{
+				Fitness: float64(len(s)),
+			}
+
+			ch <- i
+		}
+	}
+	// children must not use undeclared symbols
+	for _, format := range symbolsFormats {
+		root, err := parser.ParseTavor(strings.NewReader(format))
+		Nil(t, err)
+
+		ch, feedback, err := NewGenetic(root, rand.New(rand.NewSource(1)))
+		Nil(t, err)
+
+		for i := range ch {
+			s := root.String()
+
+			feedback <- Feedback{
+				Fitness: float64(checkSymbolUses(t, s)),
+			}
+
+			ch <- i
+		}
+	}
+}
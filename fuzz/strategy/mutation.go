@@ -0,0 +1,547 @@
+package strategy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/zimmski/tavor"
+	"github.com/zimmski/tavor/log"
+	"github.com/zimmski/tavor/rand"
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/conditions"
+	"github.com/zimmski/tavor/token/lists"
+)
+
+func init() {
+	RegisterFeedback("Mutation", NewMutation)
+}
+
+// maxMutations determines how many mutations are at most applied to a corpus input in one iteration
+const maxMutations = 4
+
+type mutation struct {
+	random
+
+	corpus  []string
+	covered coverageSet
+}
+
+// mutationNode holds a token of the current token tree
+type mutationNode struct {
+	token token.Token
+	// key identifies the token of the token graph which the token originates from. Tokens with the same key are compatible.
+	key string
+	// start and end define the data of the token in the string of the token tree. They are -1 if the position is unknown.
+	start int
+	end   int
+}
+
+// NewMutation implements a mutation-based fuzzing strategy which mutates the inputs of the corpus folder tavor.CorpusFolder.
+// See NewMutationWithCorpus for a description of the strategy.
+func NewMutation(root token.Token, r rand.Rand) (chan struct{}, chan<- Feedback, error) {
+	if tavor.CorpusFolder == "" {
+		return nil, nil, &Error{
+			Message: "no corpus folder defined",
+			Type:    ErrInvalidCorpus,
+		}
+	}
+
+	files, err := ioutil.ReadDir(tavor.CorpusFolder)
+	if err != nil {
+		return nil, nil, &Error{
+			Message: fmt.Sprintf("cannot read corpus folder: %v", err),
+			Type:    ErrInvalidCorpus,
+		}
+	}
+
+	var corpus []string
+
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(tavor.CorpusFolder, f.Name()))
+		if err != nil {
+			return nil, nil, &Error{
+				Message: fmt.Sprintf("cannot read corpus input: %v", err),
+				Type:    ErrInvalidCorpus,
+			}
+		}
+
+		corpus = append(corpus, string(data))
+	}
+
+	return NewMutationWithCorpus(corpus)(root, r)
+}
+
+// NewMutationWithCorpus returns a mutation-based fuzzing strategy which mutates the given corpus inputs guided by the token graph.
+// Every corpus input is parsed with the token graph. Inputs which cannot be parsed are ignored. Token graphs with tokens which do not implement parsing yet, e.g. sequences, are rejected with an error of type ErrInvalidConfiguration. Every iteration parses a random corpus input and applies between one and four structural mutations to its token tree. A mutation either permutates a random subtree anew, duplicates or removes an item of a repeat, chooses another alternative of an alternation or replaces a subtree with a compatible subtree of another corpus input. Mutations which violate an assertion, a unique repeat or a symbol use are rolled back and retried at most tavor.MaxAssertRetries times. Generations whose feedback holds coverage which was not covered by a previous generation are added to the corpus. The strategy does tavor.MaxFeedbackIterations iterations. The determinism is dependent on the random generator and the feedback.
+func NewMutationWithCorpus(corpus []string) FeedbackStrategy {
+	return func(root token.Token, r rand.Rand) (chan struct{}, chan<- Feedback, error) {
+		return newMutation(root, r, corpus)
+	}
+}
+
+func newMutation(root token.Token, r rand.Rand, corpus []string) (chan struct{}, chan<- Feedback, error) {
+	if r == nil {
+		return nil, nil, &Error{
+			Message: "random generator is nil",
+			Type:    ErrNilRandomGenerator,
+		}
+	}
+
+	if token.LoopExists(root) {
+		return nil, nil, &Error{
+			Message: "found endless loop in graph. Cannot proceed.",
+			Type:    ErrEndlessLoopDetected,
+		}
+	}
+
+	if tok := unparseableToken(root); tok != nil {
+		return nil, nil, &Error{
+			Message: fmt.Sprintf("the mutation strategy cannot parse tokens of type %T%s", tok, token.SourceSuffix(tok)),
+			Type:    ErrInvalidConfiguration,
+		}
+	}
+
+	s := &mutation{
+		random: random{
+			root: root,
+		},
+
+		covered: make(coverageSet),
+	}
+
+	for i, input := range corpus {
+		if errs := parseInternal(root, input); len(errs) != 0 {
+			log.Warnf("ignore corpus input %d since it cannot be parsed: %v", i, errs[0])
+
+			continue
+		}
+
+		s.corpus = append(s.corpus, input)
+	}
+
+	if len(s.corpus) == 0 {
+		return nil, nil, &Error{
+			Message: "corpus has no valid input",
+			Type:    ErrInvalidCorpus,
+		}
+	}
+
+	continueFuzzing := make(chan struct{})
+	feedback := make(chan Feedback)
+
+	go func() {
+		log.Debug("start mutation fuzzing routine")
+
+		for i := 0; i < tavor.MaxFeedbackIterations; i++ {
+			s.generate(r)
+
+			log.Debugf("done with fuzzing step %d", i)
+
+			continueFuzzing <- struct{}{}
+
+			f, ok := <-feedback
+			if !ok {
+				log.Debug("feedback channel closed from outside")
+
+				return
+			}
+
+			if s.covered.add(f.Coverage) != 0 {
+				s.corpus = append(s.corpus, s.root.String())
+			}
+
+			if _, ok := <-continueFuzzing; !ok {
+				log.Debug("fuzzing channel closed from outside")
+
+				close(feedback)
+
+				return
+			}
+		}
+
+		close(continueFuzzing)
+		close(feedback)
+	}()
+
+	return continueFuzzing, feedback, nil
+}
+
+// generate parses a random corpus input and mutates its token tree
+func (s *mutation) generate(r rand.Rand) {
+	input := s.corpus[r.Intn(len(s.corpus))]
+
+	if errs := parseInternal(s.root, input); len(errs) != 0 {
+		// generations of the corpus are not always parsable
+		log.Debugf("cannot parse corpus input %q: %v", input, errs[0])
+
+		s.generateRandom(r)
+
+		return
+	}
+
+	n := 1 + r.Intn(maxMutations)
+
+	for i := 0; i < n; i++ {
+		if !s.mutateChecked(r) {
+			s.generateRandom(r)
+
+			return
+		}
+	}
+
+	if !conditions.CheckConstraints(s.root) {
+		log.Debugf("corpus input %q violates an assertion", input)
+
+		s.generateRandom(r)
+	}
+}
+
+// generateRandom permutates the token graph at random
+func (s *mutation) generateRandom(r rand.Rand) {
+	for i := 0; i <= tavor.MaxAssertRetries; i++ {
+		s.fuzz(s.root, r, token.NewVariableScope())
+		s.fuzzYADDA(s.root, r)
+
+		if conditions.CheckConstraints(s.root) {
+			return
+		}
+
+		log.Debug("retry fuzzing step since an assertion is violated")
+	}
+
+	log.Errorf("assertions are still violated after %d retries", tavor.MaxAssertRetries)
+}
+
+// mutateChecked applies a random mutation to the token tree. Mutations which violate an assertion, a unique repeat or a symbol use are rolled back and retried at most tavor.MaxAssertRetries times.
+// False is returned if the token tree cannot be restored after a rolled back mutation.
+func (s *mutation) mutateChecked(r rand.Rand) bool {
+	current := s.root.String()
+
+	for i := 0; i <= tavor.MaxAssertRetries; i++ {
+		s.mutate(r)
+
+		if conditions.CheckConstraints(s.root) {
+			return true
+		}
+
+		log.Debug("roll back mutation since an assertion is violated")
+
+		if !s.restore(current) {
+			return false
+		}
+	}
+
+	log.Debugf("skip mutation since assertions are still violated after %d retries", tavor.MaxAssertRetries)
+
+	return true
+}
+
+// mutate applies a random mutation to the token tree
+func (s *mutation) mutate(r rand.Rand) {
+	switch r.Intn(4) {
+	case 0:
+		s.permutate(r)
+	case 1:
+		s.repeat(r)
+	case 2:
+		s.alternate(r)
+	default:
+		s.splice(r)
+	}
+}
+
+// nodes returns all tokens of the current token tree
+func (s *mutation) nodes() []mutationNode {
+	var nodes []mutationNode
+
+	var walk func(tok token.Token, key string, start int)
+	walk = func(tok token.Token, key string, start int) {
+		str := tok.String()
+
+		end := -1
+		if start != -1 {
+			end = start + len(str)
+		}
+
+		nodes = append(nodes, mutationNode{
+			token: tok,
+			key:   key,
+			start: start,
+			end:   end,
+		})
+
+		if t, ok := tok.(token.Follow); ok && !t.Follow() {
+			return
+		}
+
+		switch t := tok.(type) {
+		case token.ForwardToken:
+			if c := t.Get(); c != nil {
+				if start != -1 && c.String() != str {
+					start = -1
+				}
+
+				walk(c, key+"/0", start)
+			}
+		case token.ListToken:
+			children := make([]token.Token, t.Len())
+			var childStr []string
+
+			for i := range children {
+				children[i], _ = t.Get(i)
+				childStr = append(childStr, children[i].String())
+			}
+
+			if start != -1 && strings.Join(childStr, "") != str {
+				start = -1
+			}
+
+			for i, c := range children {
+				walk(c, key+"/"+childKey(t, c), start)
+
+				if start != -1 {
+					start += len(childStr[i])
+				}
+			}
+		}
+	}
+
+	walk(s.root, "", 0)
+
+	return nodes
+}
+
+// permutate permutates a random subtree anew
+func (s *mutation) permutate(r rand.Rand) {
+	nodes := s.nodes()
+	n := nodes[r.Intn(len(nodes))]
+
+	log.Debugf("permutate %#v", n.token)
+
+	s.fuzz(n.token, r, token.NewVariableScope())
+	s.fuzzYADDA(s.root, r)
+}
+
+// repeat duplicates or removes an item of a random repeat
+func (s *mutation) repeat(r rand.Rand) {
+	var repeats []*lists.Repeat
+
+	for _, n := range s.nodes() {
+		if t, ok := n.token.(*lists.Repeat); ok && t.Len() != 0 {
+			repeats = append(repeats, t)
+		}
+	}
+
+	if len(repeats) == 0 {
+		return
+	}
+
+	t := repeats[r.Intn(len(repeats))]
+	i := r.Intn(t.Len())
+
+	if r.Intn(2) == 0 {
+		if t.Duplicate(i) == nil {
+			log.Debugf("duplicate item %d of %#v", i, t)
+
+			return
+		}
+	}
+
+	if t.Remove(i) == nil {
+		log.Debugf("remove item %d of %#v", i, t)
+	} else if t.Duplicate(i) == nil {
+		log.Debugf("duplicate item %d of %#v", i, t)
+	}
+}
+
+// alternate chooses another alternative of a random alternation
+func (s *mutation) alternate(r rand.Rand) {
+	var ones []*lists.One
+
+	for _, n := range s.nodes() {
+		if t, ok := n.token.(*lists.One); ok && t.Permutations() > 1 {
+			ones = append(ones, t)
+		}
+	}
+
+	if len(ones) == 0 {
+		return
+	}
+
+	t := ones[r.Intn(len(ones))]
+
+	c, _ := t.Get(0)
+	current := 0
+	for j := 0; j < t.InternalLen(); j++ {
+		if ic, _ := t.InternalGet(j); ic == c {
+			current = j
+		}
+	}
+
+	// choose any alternative but the current one
+	next := uint(r.Intn(int(t.Permutations()) - 1))
+	if next >= uint(current) {
+		next++
+	}
+
+	log.Debugf("alternate %#v from %d to %d", t, current, next)
+
+	if err := t.Permutation(next); err != nil {
+		log.Panic(err)
+	}
+
+	if c, _ := t.Get(0); c != nil {
+		s.fuzz(c, r, token.NewVariableScope())
+	}
+	s.fuzzYADDA(s.root, r)
+}
+
+// splice replaces a random subtree with a compatible subtree of another corpus input
+func (s *mutation) splice(r rand.Rand) {
+	s.spliceWith(r, s.corpus[r.Intn(len(s.corpus))])
+}
+
+// spliceWith replaces a random subtree with a compatible subtree of the given input
+func (s *mutation) spliceWith(r rand.Rand, other string) {
+	current := s.root.String()
+
+	if errs := parseInternal(s.root, other); len(errs) != 0 {
+		s.restore(current)
+
+		return
+	}
+
+	subtrees := make(map[string][]string)
+	for _, n := range s.nodes() {
+		if n.start != -1 {
+			subtrees[n.key] = append(subtrees[n.key], n.token.String())
+		}
+	}
+
+	if !s.restore(current) {
+		return
+	}
+
+	var candidates []mutationNode
+	for _, n := range s.nodes() {
+		if n.start != -1 && n.key != "" && len(subtrees[n.key]) != 0 {
+			candidates = append(candidates, n)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return
+	}
+
+	n := candidates[r.Intn(len(candidates))]
+	subtree := subtrees[n.key][r.Intn(len(subtrees[n.key]))]
+
+	spliced := current[:n.start] + subtree + current[n.end:]
+
+	log.Debugf("splice %q into %q giving %q", subtree, current, spliced)
+
+	if errs := parseInternal(s.root, spliced); len(errs) != 0 {
+		log.Debugf("spliced input cannot be parsed: %v", errs[0])
+
+		s.restore(current)
+	}
+}
+
+// parseInternal parses the given input with the token graph like parser.ParseInternal which cannot be used since its tests depend on this package.
+// Tokens which do not implement parsing yet panic, the panic is returned as error.
+func parseInternal(root token.Token, input string) (errs []error) {
+	defer func() {
+		if r := recover(); r != nil {
+			errs = []error{&Error{
+				Message: fmt.Sprintf("cannot parse %q: %v", input, r),
+				Type:    ErrInvalidConfiguration,
+			}}
+		}
+	}()
+
+	p := &token.InternalParser{
+		Data:    input,
+		DataLen: len(input),
+	}
+
+	nex, errs := root.Parse(p, 0)
+	if len(errs) != 0 {
+		return errs
+	} else if nex != p.DataLen {
+		return []error{&token.ParserError{
+			Message: fmt.Sprintf("expected EOF but still %q left", input[nex:]),
+			Type:    token.ParseErrorExpectedEOF,
+
+			Position: p.GetPosition(nex),
+		}}
+	}
+
+	return nil
+}
+
+// unparseableToken returns a token of the token graph which cannot be parsed since its type does not implement parsing yet, or nil if every token can be parsed.
+// Every token type is probed once by parsing an empty input with a clone. Children are probed before their parents so that the returned token is the one which does not implement parsing.
+func unparseableToken(root token.Token) token.Token {
+	probed := make(map[reflect.Type]struct{})
+	visited := make(map[token.Token]struct{})
+
+	var probe func(tok token.Token) token.Token
+	probe = func(tok token.Token) token.Token {
+		if _, ok := visited[tok]; ok {
+			return nil
+		}
+		visited[tok] = struct{}{}
+
+		if t, ok := tok.(token.Follow); !ok || t.Follow() {
+			switch t := tok.(type) {
+			case token.ForwardToken:
+				if c := t.InternalGet(); c != nil {
+					if u := probe(c); u != nil {
+						return u
+					}
+				}
+			case token.ListToken:
+				for i := 0; i < t.InternalLen(); i++ {
+					c, _ := t.InternalGet(i)
+
+					if u := probe(c); u != nil {
+						return u
+					}
+				}
+			}
+		}
+
+		if _, ok := probed[reflect.TypeOf(tok)]; ok {
+			return nil
+		}
+		probed[reflect.TypeOf(tok)] = struct{}{}
+
+		if errs := parseInternal(tok.Clone(), ""); len(errs) != 0 {
+			if err, ok := errs[0].(*Error); ok && err.Type == ErrInvalidConfiguration {
+				return tok
+			}
+		}
+
+		return nil
+	}
+
+	return probe(root)
+}
+
+// restore parses the given input so that the token tree holds it again. False is returned if the input cannot be parsed.
+func (s *mutation) restore(input string) bool {
+	if errs := parseInternal(s.root, input); len(errs) != 0 {
+		log.Debugf("cannot restore %q: %v", input, errs[0])
+
+		return false
+	}
+
+	return true
+}
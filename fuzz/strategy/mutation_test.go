@@ -0,0 +1,218 @@
+package strategy
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor"
+	"github.com/zimmski/tavor/parser"
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/lists"
+	"github.com/zimmski/tavor/token/primitives"
+)
+
+const mutationTestFormat = `
+Item = "a" | "b" | "c"
+List = +1,4(Item)
+START = "[" List "]" ?("!")
+`
+
+func TestMutationStrategyNilRandomGenerator(t *testing.T) {
+	ch, feedback, err := NewMutationWithCorpus(nil)(nil, nil)
+	Nil(t, ch)
+	Nil(t, feedback)
+	Equal(t, ErrNilRandomGenerator, err.(*Error).Type)
+}
+
+func TestMutationStrategyInvalidCorpus(t *testing.T) {
+	root, err := parser.ParseTavor(strings.NewReader(mutationTestFormat))
+	Nil(t, err)
+
+	r := rand.New(rand.NewSource(1))
+
+	_, _, err = NewMutationWithCorpus([]string{"[]", "[d]"})(root, r)
+	Equal(t, ErrInvalidCorpus, err.(*Error).Type)
+
+	defer func(folder string) {
+		tavor.CorpusFolder = folder
+	}(tavor.CorpusFolder)
+
+	tavor.CorpusFolder = ""
+	_, _, err = NewMutation(root, r)
+	Equal(t, ErrInvalidCorpus, err.(*Error).Type)
+
+	tavor.CorpusFolder = "/does/not/exist"
+	_, _, err = NewMutation(root, r)
+	Equal(t, ErrInvalidCorpus, err.(*Error).Type)
+}
+
+func TestMutationStrategyUnparseableTokens(t *testing.T) {
+	root, err := parser.ParseTavor(strings.NewReader(`
+		$Id Sequence = start: 1,
+			step: 1
+
+		START = $Id.Next " " $Id.Existing
+	`))
+	Nil(t, err)
+
+	ch, feedback, err := NewMutationWithCorpus([]string{"1 1"})(root, rand.New(rand.NewSource(1)))
+	Nil(t, ch)
+	Nil(t, feedback)
+	Equal(t, ErrInvalidConfiguration, err.(*Error).Type)
+
+	// panics of tokens which cannot be parsed are returned as errors
+	errs := parseInternal(root, "1 1")
+	Equal(t, 1, len(errs))
+	Equal(t, ErrInvalidConfiguration, errs[0].(*Error).Type)
+
+	root, err = parser.ParseTavor(strings.NewReader(mutationTestFormat))
+	Nil(t, err)
+	Nil(t, unparseableToken(root))
+}
+
+func TestMutationStrategy(t *testing.T) {
+	defer func(i int) {
+		tavor.MaxFeedbackIterations = i
+	}(tavor.MaxFeedbackIterations)
+	tavor.MaxFeedbackIterations = 300
+
+	root, err := parser.ParseTavor(strings.NewReader(mutationTestFormat))
+	Nil(t, err)
+
+	ch, feedback, err := NewMutationWithCorpus([]string{"[ab]", "[ccc]!", "invalid"})(root, rand.New(rand.NewSource(1)))
+	Nil(t, err)
+
+	generations := make(map[string]struct{})
+
+	for i := range ch {
+		s := root.String()
+		generations[s] = struct{}{}
+
+		// every mutation is a valid generation of the format
+		check, err := parser.ParseTavor(strings.NewReader(mutationTestFormat))
+		Nil(t, err)
+		Nil(t, parser.ParseInternal(check, strings.NewReader(s)), s)
+
+		feedback <- Feedback{}
+
+		ch <- i
+	}
+
+	True(t, len(generations) > 20)
+
+	// splicing combines the subtrees of the corpus inputs
+	_, ok := generations["[ab]!"]
+	True(t, ok)
+}
+
+func TestMutationStrategyConstraints(t *testing.T) {
+	defer func(i int) {
+		tavor.MaxFeedbackIterations = i
+	}(tavor.MaxFeedbackIterations)
+	tavor.MaxFeedbackIterations = 200
+
+	// mutations must not duplicate values of unique repeats
+	{
+		root, err := parser.ParseTavor(strings.NewReader(`
+			START = +1,4unique(Item)
+
+			Item = "a" | "b" | "c" | "d"
+		`))
+		Nil(t, err)
+
+		ch, feedback, err := NewMutationWithCorpus([]string{"ab", "cd"})(root, rand.New(rand.NewSource(1)))
+		Nil(t, err)
+
+		for i := range ch {
+			s := root.String()
+
+			found := make(map[rune]struct{})
+			for _, c := range s {
+				_, ok := found[c]
+				False(t, ok, s)
+
+				found[c] = struct{}{}
+			}
+
+			feedback <- Feedback{}
+
+			ch <- i
+		}
+	}
+	// mutations must not use undeclared symbols
+	for i, corpus := range [][]string{
+		{"var a\nvar b\nuse b\nuse a\n", "var c\nuse c\n"},
+		{"var a\nuse a\nvar b\n", "var c\nvar b\nuse c\n"},
+	} {
+		root, err := parser.ParseTavor(strings.NewReader(symbolsFormats[i]))
+		Nil(t, err)
+
+		ch, feedback, err := NewMutationWithCorpus(corpus)(root, rand.New(rand.NewSource(1)))
+		Nil(t, err)
+
+		for i := range ch {
+			checkSymbolUses(t, root.String())
+
+			feedback <- Feedback{}
+
+			ch <- i
+		}
+	}
+}
+
+func TestMutationStrategyCorpusFolder(t *testing.T) {
+	defer func(i int, folder string) {
+		tavor.MaxFeedbackIterations = i
+		tavor.CorpusFolder = folder
+	}(tavor.MaxFeedbackIterations, tavor.CorpusFolder)
+	tavor.MaxFeedbackIterations = 5
+
+	dir, err := ioutil.TempDir("", "tavor-corpus")
+	Nil(t, err)
+	defer func() {
+		Nil(t, os.RemoveAll(dir))
+	}()
+
+	Nil(t, ioutil.WriteFile(filepath.Join(dir, "1"), []byte("[a]"), 0644))
+	tavor.CorpusFolder = dir
+
+	root, err := parser.ParseTavor(strings.NewReader(mutationTestFormat))
+	Nil(t, err)
+
+	strat, err := New("Mutation")
+	Nil(t, err)
+
+	ch, err := strat(root, rand.New(rand.NewSource(1)))
+	Nil(t, err)
+
+	generations := 0
+
+	for i := range ch {
+		generations++
+
+		ch <- i
+	}
+
+	Equal(t, 5, generations)
+}
+
+func TestMutationStrategyLoopDetection(t *testing.T) {
+	var tok *token.Token
+
+	p := primitives.NewEmptyPointer(tok)
+	o := lists.NewConcatenation(
+		p,
+		primitives.NewConstantInt(1),
+	)
+	Nil(t, p.Set(o))
+
+	ch, _, err := NewMutationWithCorpus([]string{"1"})(o, rand.New(rand.NewSource(1)))
+	Nil(t, ch)
+	Equal(t, ErrEndlessLoopDetected, err.(*Error).Type)
+}
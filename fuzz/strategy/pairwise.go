@@ -0,0 +1,415 @@
+package strategy
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/zimmski/tavor"
+	"github.com/zimmski/tavor/log"
+	"github.com/zimmski/tavor/rand"
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/conditions"
+	"github.com/zimmski/tavor/token/constraints"
+	"github.com/zimmski/tavor/token/lists"
+)
+
+func init() {
+	Register("PairWise", NewPairWise)
+}
+
+// pairWiseParameter holds a token of the token graph whose choice is a parameter of the covering array
+type pairWiseParameter struct {
+	token token.Token
+	// key identifies the token in the token tree. Clones of the token share the key.
+	key    string
+	values uint
+}
+
+// pairWiseTuple holds a combination of parameters with one value for each parameter
+type pairWiseTuple struct {
+	parameters []int
+	values     []uint
+}
+
+type pairWise struct {
+	random
+
+	parameters []pairWiseParameter
+	tuples     []pairWiseTuple
+	// pending holds the indizes of tuples which are neither covered nor were tried to be covered
+	pending map[int]struct{}
+	covered map[int]struct{}
+}
+
+// NewPairWise implements a combinatorial fuzzing strategy that covers every combination of tavor.PairWiseStrength choices of a token graph.
+// Every alternation, optional and repeat with more than one permutation is a parameter whose values are its permutations. Every iteration generates one row of a covering array which is built greedily: the row starts with the first combination which is not covered yet and chooses the value of every other parameter so that it covers the most combinations which are not covered yet. Tokens which are not parameters are permutated at random. Since a parameter can be only reachable with specific choices of other parameters, e.g. a parameter inside an alternative, the coverage of a row is determined after its generation and combinations which could not be covered are tried at most once. The strategy ends with a summary of the covered combinations which is printed to STDERR. Permutations which violate an assertion of the graph are retried at most tavor.MaxAssertRetries times. The determinism is dependent on the random generator.
+func NewPairWise(root token.Token, r rand.Rand) (chan struct{}, error) {
+	if r == nil {
+		return nil, &Error{
+			Message: "random generator is nil",
+			Type:    ErrNilRandomGenerator,
+		}
+	}
+
+	if tavor.PairWiseStrength < 1 {
+		return nil, &Error{
+			Message: "strength has to be at least 1",
+			Type:    ErrInvalidConfiguration,
+		}
+	}
+
+	if token.LoopExists(root) {
+		return nil, &Error{
+			Message: "found endless loop in graph. Cannot proceed.",
+			Type:    ErrEndlessLoopDetected,
+		}
+	}
+
+	s := &pairWise{
+		random: random{
+			root: root,
+		},
+
+		pending: make(map[int]struct{}),
+		covered: make(map[int]struct{}),
+	}
+
+	s.collectParameters(root, "")
+	s.collectTuples()
+
+	continueFuzzing := make(chan struct{})
+
+	go func() {
+		log.Debug("start pairwise fuzzing routine")
+
+		rows := 0
+
+		for i := 0; i == 0 || len(s.pending) != 0; i++ {
+			row := s.nextRow()
+
+			log.Debugf("generate row %v", row)
+
+			visited, ok := s.generate(r, row)
+			if !ok {
+				log.Errorf("assertions are still violated after %d retries", tavor.MaxAssertRetries)
+
+				continue
+			}
+
+			s.cover(visited)
+
+			rows++
+
+			log.Debugf("done with fuzzing step %d", rows)
+
+			continueFuzzing <- struct{}{}
+
+			if _, ok := <-continueFuzzing; !ok {
+				log.Debug("fuzzing channel closed from outside")
+
+				return
+			}
+		}
+
+		s.summary(rows)
+
+		close(continueFuzzing)
+	}()
+
+	return continueFuzzing, nil
+}
+
+// collectParameters collects all parameters of the token graph
+func (s *pairWise) collectParameters(tok token.Token, key string) {
+	switch t := tok.(type) {
+	case *lists.One, *constraints.Optional, *lists.Repeat:
+		if p := t.Permutations(); p > 1 {
+			s.parameters = append(s.parameters, pairWiseParameter{
+				token:  t,
+				key:    key,
+				values: p,
+			})
+		}
+	}
+
+	if t, ok := tok.(token.Follow); ok && !t.Follow() {
+		return
+	}
+
+	switch t := tok.(type) {
+	case token.ForwardToken:
+		if c := t.InternalGet(); c != nil {
+			s.collectParameters(c, key+"/0")
+		}
+	case token.ListToken:
+		for j := 0; j < t.InternalLen(); j++ {
+			c, _ := t.InternalGet(j)
+
+			if _, ok := t.(*lists.Repeat); ok {
+				s.collectParameters(c, key+"/*")
+			} else {
+				s.collectParameters(c, key+"/"+strconv.Itoa(j))
+			}
+		}
+	}
+}
+
+// collectTuples collects all tuples which have to be covered
+func (s *pairWise) collectTuples() {
+	strength := tavor.PairWiseStrength
+	if strength > len(s.parameters) {
+		strength = len(s.parameters)
+	}
+	if strength == 0 {
+		return
+	}
+
+	for _, parameters := range pairWiseCombinations(len(s.parameters), strength) {
+		values := make([]uint, strength)
+
+	VALUES:
+		for {
+			s.pending[len(s.tuples)] = struct{}{}
+			s.tuples = append(s.tuples, pairWiseTuple{
+				parameters: parameters,
+				values:     append([]uint(nil), values...),
+			})
+
+			// count the values up like the digits of a number
+			for i := len(values) - 1; i >= 0; i-- {
+				values[i]++
+
+				if values[i] < s.parameters[parameters[i]].values {
+					continue VALUES
+				}
+
+				values[i] = 0
+			}
+
+			break
+		}
+	}
+
+	log.Debugf("found %d parameters with %d tuples", len(s.parameters), len(s.tuples))
+}
+
+// pairWiseCombinations returns all combinations of k indizes out of n indizes in lexicographic order
+func pairWiseCombinations(n int, k int) [][]int {
+	var combinations [][]int
+
+	c := make([]int, k)
+	for i := range c {
+		c[i] = i
+	}
+
+	for {
+		combinations = append(combinations, append([]int(nil), c...))
+
+		i := k - 1
+		for i >= 0 && c[i] == n-k+i {
+			i--
+		}
+		if i < 0 {
+			return combinations
+		}
+
+		c[i]++
+		for j := i + 1; j < k; j++ {
+			c[j] = c[j-1] + 1
+		}
+	}
+}
+
+// nextRow returns the next row of the covering array. A value of -1 means that the parameter has no value yet.
+func (s *pairWise) nextRow() []int {
+	row := make([]int, len(s.parameters))
+	for i := range row {
+		row[i] = -1
+	}
+
+	if len(s.pending) == 0 {
+		return row
+	}
+
+	// the row starts with the first pending tuple which is therefore not pending anymore
+	first := -1
+	for i := range s.tuples {
+		if _, ok := s.pending[i]; ok {
+			first = i
+
+			break
+		}
+	}
+
+	delete(s.pending, first)
+
+	t := s.tuples[first]
+	for i, p := range t.parameters {
+		row[p] = int(t.values[i])
+	}
+
+	for p := range row {
+		if row[p] != -1 {
+			continue
+		}
+
+		best, bestCount := 0, -1
+
+		for v := 0; v < int(s.parameters[p].values); v++ {
+			row[p] = v
+
+			if c := s.pendingMatches(row, p); c > bestCount {
+				best, bestCount = v, c
+			}
+		}
+
+		row[p] = best
+	}
+
+	return row
+}
+
+// pendingMatches returns how many pending tuples with the given parameter are covered by the values of the row
+func (s *pairWise) pendingMatches(row []int, parameter int) int {
+	n := 0
+
+TUPLES:
+	for i := range s.pending {
+		t := s.tuples[i]
+
+		found := false
+		for j, p := range t.parameters {
+			if row[p] != int(t.values[j]) {
+				continue TUPLES
+			}
+
+			if p == parameter {
+				found = true
+			}
+		}
+
+		if found {
+			n++
+		}
+	}
+
+	return n
+}
+
+// generate permutates the token graph with the values of the row and returns the values which were actually used
+func (s *pairWise) generate(r rand.Rand, row []int) (map[string]uint, bool) {
+	values := make(map[string]int, len(row))
+	for i, v := range row {
+		values[s.parameters[i].key] = v
+	}
+
+	for i := 0; i <= tavor.MaxAssertRetries; i++ {
+		visited := make(map[string]uint)
+
+		s.fuzzRow(s.root, "", r, token.NewVariableScope(), values, visited)
+		s.fuzzYADDA(s.root, r)
+
+		if conditions.CheckConstraints(s.root) {
+			return visited, true
+		}
+
+		log.Debug("retry fuzzing step since an assertion is violated")
+	}
+
+	return nil, false
+}
+
+// fuzzRow permutates the given token like random.fuzz but uses the values of the row for parameters
+func (s *pairWise) fuzzRow(tok token.Token, key string, r rand.Rand, variableScope *token.VariableScope, values map[string]int, visited map[string]uint) {
+	if t, ok := tok.(token.Scoping); ok && t.Scoping() {
+		variableScope = variableScope.Push()
+	}
+
+	p := tok.Permutations()
+	var rp uint
+	if v, ok := values[key]; ok && v != -1 && p > 0 {
+		rp = uint(v) % p
+
+		visited[key] = rp
+	} else if p > 0 {
+		rp = uint(r.Int63n(int64(p)))
+	} else {
+		log.Errorf("No valid permutation available")
+	}
+
+	err := tok.Permutation(rp)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	if t, ok := tok.(token.Follow); !ok || t.Follow() {
+		switch t := tok.(type) {
+		case token.ForwardToken:
+			if c := t.Get(); c != nil {
+				s.fuzzRow(c, key+"/0", r, variableScope, values, visited)
+			}
+		case token.ListToken:
+			l := t.Len()
+
+			for i := 0; i < l; i++ {
+				c, _ := t.Get(i)
+				s.fuzzRow(c, key+"/"+childKey(t, c), r, variableScope, values, visited)
+			}
+
+			if rep, ok := tok.(*lists.Repeat); ok && rep.Unique() {
+				s.fuzzDistinct(rep, r, variableScope)
+			}
+		}
+	}
+
+	if t, ok := tok.(token.Scoping); ok && t.Scoping() {
+		_ = variableScope.Pop()
+	}
+}
+
+// cover marks every tuple as covered whose values were all used
+func (s *pairWise) cover(visited map[string]uint) {
+TUPLES:
+	for i, t := range s.tuples {
+		if _, ok := s.covered[i]; ok {
+			continue
+		}
+
+		for j, p := range t.parameters {
+			if v, ok := visited[s.parameters[p].key]; !ok || v != t.values[j] {
+				continue TUPLES
+			}
+		}
+
+		s.covered[i] = struct{}{}
+		delete(s.pending, i)
+	}
+}
+
+// summary prints the coverage of the tuples to STDERR
+func (s *pairWise) summary(rows int) {
+	if len(s.tuples) == 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "generated %d rows without any parameters\n", rows)
+
+		return
+	}
+
+	strength := len(s.tuples[0].parameters)
+
+	_, _ = fmt.Fprintf(os.Stderr, "covered %d of %d %d-wise combinations of %d parameters with %d rows (%.1f%%)\n", len(s.covered), len(s.tuples), strength, len(s.parameters), rows, 100*float64(len(s.covered))/float64(len(s.tuples)))
+
+	for i, t := range s.tuples {
+		if _, ok := s.covered[i]; ok {
+			continue
+		}
+
+		var values []string
+		for j, p := range t.parameters {
+			values = append(values, fmt.Sprintf("%#v%s=%d", s.parameters[p].token, token.SourceSuffix(s.parameters[p].token), t.values[j]))
+		}
+
+		log.Debugf("combination %s could not be covered", strings.Join(values, ", "))
+	}
+}
@@ -0,0 +1,121 @@
+package strategy
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor"
+	"github.com/zimmski/tavor/parser"
+)
+
+func pairWiseGenerations(t *testing.T, format string) []string {
+	root, err := parser.ParseTavor(strings.NewReader(format))
+	Nil(t, err)
+
+	ch, err := NewPairWise(root, rand.New(rand.NewSource(1)))
+	Nil(t, err)
+
+	var generations []string
+
+	for i := range ch {
+		generations = append(generations, root.String())
+
+		ch <- i
+	}
+
+	return generations
+}
+
+func TestPairWiseStrategyNilRandomGenerator(t *testing.T) {
+	ch, err := NewPairWise(nil, nil)
+	Nil(t, ch)
+	Equal(t, ErrNilRandomGenerator, err.(*Error).Type)
+}
+
+func TestPairWiseStrategyInvalidConfiguration(t *testing.T) {
+	defer func(strength int) {
+		tavor.PairWiseStrength = strength
+	}(tavor.PairWiseStrength)
+
+	root, err := parser.ParseTavor(strings.NewReader("START = \"a\"\n"))
+	Nil(t, err)
+
+	tavor.PairWiseStrength = 0
+
+	ch, err := NewPairWise(root, rand.New(rand.NewSource(1)))
+	Nil(t, ch)
+	Equal(t, ErrInvalidConfiguration, err.(*Error).Type)
+}
+
+func TestPairWiseStrategyCombinations(t *testing.T) {
+	Equal(t, [][]int{{0, 1}, {0, 2}, {1, 2}}, pairWiseCombinations(3, 2))
+	Equal(t, [][]int{{0, 1, 2}}, pairWiseCombinations(3, 3))
+}
+
+func TestPairWiseStrategy(t *testing.T) {
+	defer func(strength int) {
+		tavor.PairWiseStrength = strength
+	}(tavor.PairWiseStrength)
+
+	format := "START = (\"a\" | \"b\" | \"c\") (\"x\" | \"y\" | \"z\") ?(\"1\") +1,2(\"p\")\n"
+
+	{
+		generations := pairWiseGenerations(t, "START = \"a\"\n")
+
+		Equal(t, []string{"a"}, generations)
+	}
+	{
+		generations := pairWiseGenerations(t, format)
+
+		// all 36 permutations are not needed to cover every pair
+		True(t, len(generations) >= 9)
+		True(t, len(generations) < 36)
+
+		pairs := make(map[string]struct{})
+		for _, g := range generations {
+			first := g[0:1]
+			second := g[1:2]
+			optional := "-"
+			if strings.Contains(g, "1") {
+				optional = "1"
+			}
+			repeat := strings.Repeat("p", strings.Count(g, "p"))
+
+			values := []string{first, second, optional, repeat}
+			for i := range values {
+				for j := i + 1; j < len(values); j++ {
+					pairs[values[i]+" "+values[j]] = struct{}{}
+				}
+			}
+		}
+
+		// 3*3 + 3*2 + 3*2 + 3*2 + 3*2 + 2*2 pairs
+		Equal(t, 37, len(pairs))
+	}
+	{
+		tavor.PairWiseStrength = 1
+
+		generations := pairWiseGenerations(t, format)
+
+		Equal(t, 3, len(generations))
+	}
+	{
+		tavor.PairWiseStrength = 10
+
+		generations := pairWiseGenerations(t, format)
+
+		Equal(t, 36, len(generations))
+	}
+	{
+		// combinations with the inner alternation can be only covered if the second alternative is chosen
+		tavor.PairWiseStrength = 2
+
+		generations := pairWiseGenerations(t, "START = \"a\" | \"b\" (\"x\" | \"y\")\n")
+
+		Contains(t, generations, "bx")
+		Contains(t, generations, "by")
+	}
+}
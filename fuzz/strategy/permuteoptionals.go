@@ -8,6 +8,7 @@ import (
 	"github.com/zimmski/tavor/log"
 	"github.com/zimmski/tavor/rand"
 	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/conditions"
 )
 
 func init() {
@@ -19,7 +20,7 @@ type permuteOptionals struct {
 }
 
 // NewPermuteOptionals implements a fuzzing strategy that generates permutations of only optional tokens of a token graph.
-// Every iteration of the strategy generates a new permutation. The generation is deterministic. This strategy searches the graph for tokens who implement the OptionalToken interface and permutates over them by deactivating or activating them. The permutations always start from the deactivated states so that minimum data is generated first.
+// Every iteration of the strategy generates a new permutation. The generation is deterministic. This strategy searches the graph for tokens who implement the OptionalToken interface and permutates over them by deactivating or activating them. The permutations always start from the deactivated states so that minimum data is generated first. Permutations which violate an assertion or a unique repeat of the graph are skipped.
 func NewPermuteOptionals(root token.Token, r rand.Rand) (chan struct{}, error) {
 	if token.LoopExists(root) {
 		return nil, &Error{
@@ -49,6 +50,14 @@ func NewPermuteOptionals(root token.Token, r rand.Rand) (chan struct{}, error) {
 		_ = token.ResetResetTokens(s.root)
 		token.ResetScope(s.root)
 
+		if !conditions.CheckConstraints(s.root) {
+			log.Debug("skip last fuzzing step since an assertion is violated")
+
+			close(continueFuzzing)
+
+			return
+		}
+
 		log.Debug("done with fuzzing step")
 
 		// done with the last fuzzing step
@@ -110,6 +119,12 @@ func (s *permuteOptionals) fuzz(r rand.Rand, continueFuzzing chan struct{}, opti
 		_ = token.ResetResetTokens(s.root)
 		token.ResetScope(s.root)
 
+		if !conditions.CheckConstraints(s.root) {
+			log.Debug("skip fuzzing step since an assertion is violated")
+
+			continue
+		}
+
 		log.Debug("done with fuzzing step")
 
 		// done with this fuzzing step
@@ -1,10 +1,16 @@
 package strategy
 
 import (
+	"fmt"
+
+	"github.com/zimmski/tavor"
 	"github.com/zimmski/tavor/log"
 	"github.com/zimmski/tavor/rand"
 	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/conditions"
+	"github.com/zimmski/tavor/token/lists"
 	"github.com/zimmski/tavor/token/sequences"
+	"github.com/zimmski/tavor/token/symbols"
 )
 
 func init() {
@@ -16,7 +22,7 @@ type random struct {
 }
 
 // NewRandom implements a fuzzing strategy that generates a random permutation of a token graph.
-// The strategy does exactly one iteration which permutates at random all reachable tokens in the graph. The determinism is dependent on the random generator and is therefore for example deterministic if a seed for the random generator produces always the same outputs.
+// The strategy does exactly one iteration which permutates at random all reachable tokens in the graph. Duplicated values of unique repeats are fuzzed again and permutations which violate an assertion or a unique repeat of the graph are retried at most tavor.MaxAssertRetries times. The iteration is generated before the strategy is returned so that an error of type ErrAssertionsViolated can be returned if the retries are exhausted. The determinism is dependent on the random generator and is therefore for example deterministic if a seed for the random generator produces always the same outputs.
 func NewRandom(root token.Token, r rand.Rand) (chan struct{}, error) {
 	if r == nil {
 		return nil, &Error{
@@ -36,15 +42,28 @@ func NewRandom(root token.Token, r rand.Rand) (chan struct{}, error) {
 		root: root,
 	}
 
-	continueFuzzing := make(chan struct{})
-
-	go func() {
-		log.Debug("start random fuzzing routine")
+	log.Debug("start random fuzzing step")
 
+	for i := 0; ; i++ {
 		s.fuzz(s.root, r, token.NewVariableScope())
 
 		s.fuzzYADDA(s.root, r)
 
+		if conditions.CheckConstraints(s.root) {
+			break
+		} else if i == tavor.MaxAssertRetries {
+			return nil, &Error{
+				Message: fmt.Sprintf("assertions are still violated after %d retries", tavor.MaxAssertRetries),
+				Type:    ErrAssertionsViolated,
+			}
+		}
+
+		log.Debug("retry fuzzing step since an assertion is violated")
+	}
+
+	continueFuzzing := make(chan struct{})
+
+	go func() {
 		log.Debug("done with fuzzing step")
 
 		// done with the last fuzzing step
@@ -63,7 +82,7 @@ func NewRandom(root token.Token, r rand.Rand) (chan struct{}, error) {
 }
 
 func (s *random) fuzz(tok token.Token, r rand.Rand, variableScope *token.VariableScope) {
-	log.Debugf("Fuzz (%p)%#v with maxPermutations %d", tok, tok, tok.Permutations())
+	log.Debugf("Fuzz (%p)%#v%s with maxPermutations %d", tok, tok, token.SourceSuffix(tok), tok.Permutations())
 
 	if t, ok := tok.(token.Scoping); ok && t.Scoping() {
 		variableScope = variableScope.Push()
@@ -95,6 +114,10 @@ func (s *random) fuzz(tok token.Token, r rand.Rand, variableScope *token.Variabl
 				c, _ := t.Get(i)
 				s.fuzz(c, r, variableScope)
 			}
+
+			if rep, ok := tok.(*lists.Repeat); ok && rep.Unique() {
+				s.fuzzDistinct(rep, r, variableScope)
+			}
 		}
 	}
 
@@ -103,6 +126,25 @@ func (s *random) fuzz(tok token.Token, r rand.Rand, variableScope *token.Variabl
 	}
 }
 
+// fuzzDistinct fuzzes duplicated values of a unique repeat again until all values are distinct or tavor.MaxAssertRetries is reached
+func (s *random) fuzzDistinct(rep *lists.Repeat, r rand.Rand, variableScope *token.VariableScope) {
+	for i := 0; i < tavor.MaxAssertRetries && !rep.Distinct(); i++ {
+		known := make(map[string]struct{})
+
+		for j := 0; j < rep.Len(); j++ {
+			c, _ := rep.Get(j)
+
+			if _, ok := known[c.String()]; ok {
+				log.Debugf("Fuzz duplicate %p(%#v) again", c, c)
+
+				s.fuzz(c, r, variableScope)
+			}
+
+			known[c.String()] = struct{}{}
+		}
+	}
+}
+
 func (s *random) fuzzYADDA(root token.Token, r rand.Rand) {
 	// TODO FIXME AND FIXME FIXME FIXME this should be done automatically somehow
 	// since this doesn't work in other heuristics...
@@ -111,10 +153,12 @@ func (s *random) fuzzYADDA(root token.Token, r rand.Rand) {
 	token.ResetCombinedScope(root)
 	_ = token.ResetResetTokens(root)
 	token.ResetCombinedScope(root)
+	// symbols are only visible in the order of the generation which is not the order of the combined scope
+	symbols.ResetSymbols(root)
 
 	err := token.Walk(root, func(tok token.Token) error {
 		switch tok.(type) {
-		case *sequences.SequenceExistingItem:
+		case *sequences.SequenceExistingItem, *symbols.SymbolsUseItem:
 			log.Debugf("Fuzz again %p(%#v)", tok, tok)
 
 			p := int64(tok.Permutations())
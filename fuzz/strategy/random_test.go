@@ -1,13 +1,17 @@
 package strategy
 
 import (
+	"fmt"
+	"math/rand"
 	"strings"
 	"testing"
 
 	. "github.com/zimmski/tavor/test/assert"
 
+	"github.com/zimmski/tavor"
 	"github.com/zimmski/tavor/parser"
 	"github.com/zimmski/tavor/test"
+	"github.com/zimmski/tavor/token/conditions"
 	"github.com/zimmski/tavor/token/constraints"
 	"github.com/zimmski/tavor/token/lists"
 	"github.com/zimmski/tavor/token/primitives"
@@ -153,6 +157,168 @@ func TestRandomStrategyCases(t *testing.T) {
 	}
 }
 
+func TestRandomStrategyAssert(t *testing.T) {
+	defer func(retries int) {
+		tavor.MaxAssertRetries = retries
+	}(tavor.MaxAssertRetries)
+
+	format := `
+		$Number Int = from: 1,
+			to: 100
+
+		START = Number<a> " " Number<b> Check
+
+		Check = {assert a.Value > b.Value}
+	`
+
+	// generations are retried until the assertion holds
+	for seed := 0; seed < 10; seed++ {
+		root, err := parser.ParseTavor(strings.NewReader(format))
+		Nil(t, err)
+
+		ch, err := NewRandom(root, rand.New(rand.NewSource(int64(seed))))
+		Nil(t, err)
+
+		_, ok := <-ch
+		True(t, ok)
+
+		var a, b int
+		_, err = fmt.Sscanf(root.String(), "%d %d", &a, &b)
+		Nil(t, err)
+		True(t, a > b)
+
+		asserts := conditions.Asserts(root)
+		Equal(t, 1, len(asserts))
+		Equal(t, uint(1), asserts[0].Statistics().Checks-asserts[0].Statistics().Violations)
+
+		ch <- struct{}{}
+
+		_, ok = <-ch
+		False(t, ok)
+	}
+
+	// an error if the assertion never holds
+	tavor.MaxAssertRetries = 3
+
+	root, err := parser.ParseTavor(strings.NewReader(`
+		START = 1<a> Check
+
+		Check = {assert a.Value == 2}
+	`))
+	Nil(t, err)
+
+	ch, err := NewRandom(root, test.NewRandTest(1))
+	Nil(t, ch)
+	Equal(t, ErrAssertionsViolated, err.(*Error).Type)
+
+	Equal(t, conditions.AssertStatistics{Checks: 4, Violations: 4}, conditions.Asserts(root)[0].Statistics())
+}
+
+func TestRandomStrategyUnique(t *testing.T) {
+	for seed := 0; seed < 10; seed++ {
+		root, err := parser.ParseTavor(strings.NewReader(`
+			START = +5unique(Key)
+
+			Key = "a" | "b" | "c" | "d" | "e" | "f"
+		`))
+		Nil(t, err)
+
+		ch, err := NewRandom(root, rand.New(rand.NewSource(int64(seed))))
+		Nil(t, err)
+
+		_, ok := <-ch
+		True(t, ok)
+
+		known := make(map[rune]struct{})
+		for _, c := range root.String() {
+			_, ok := known[c]
+			False(t, ok, root.String())
+
+			known[c] = struct{}{}
+		}
+		Equal(t, 5, len(known))
+
+		ch <- struct{}{}
+
+		_, ok = <-ch
+		False(t, ok)
+	}
+}
+
+// symbolsFormats holds formats with declarations and uses of symbols which are either separated or interleaved in one repeat
+var symbolsFormats = []string{`
+	$Vars Symbols
+
+	START = +1,3(Decl) +1,3(Use)
+
+	Decl = "var " $Vars.Define(Name) "\n"
+
+	Use = "use " $Vars.Use "\n"
+
+	Name = "a" | "b" | "c"
+`, `
+	$Vars Symbols
+
+	START = +3(Stmt "\n")
+
+	Stmt = Decl | Use
+
+	Decl = "var " $Vars.Define(Name)
+
+	Use = "use " $Vars.Use
+
+	Name = "a" | "b" | "c"
+`}
+
+// checkSymbolUses checks that every use of a generation uses a symbol which was declared before and returns the number of uses
+func checkSymbolUses(t *testing.T, out string) int {
+	uses := 0
+	defined := make(map[string]struct{})
+
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var kind, name string
+		_, err := fmt.Sscanf(line, "%s %s", &kind, &name)
+		Nil(t, err, out)
+
+		if kind == "var" {
+			defined[name] = struct{}{}
+		} else {
+			uses++
+
+			_, ok := defined[name]
+			True(t, ok, out)
+		}
+	}
+
+	return uses
+}
+
+func TestRandomStrategySymbols(t *testing.T) {
+	for _, format := range symbolsFormats {
+		uses := 0
+
+		for seed := 0; seed < 10; seed++ {
+			root, err := parser.ParseTavor(strings.NewReader(format))
+			Nil(t, err)
+
+			ch, err := NewRandom(root, rand.New(rand.NewSource(int64(seed))))
+			Nil(t, err)
+
+			_, ok := <-ch
+			True(t, ok)
+
+			uses += checkSymbolUses(t, root.String())
+
+			ch <- struct{}{}
+
+			_, ok = <-ch
+			False(t, ok)
+		}
+
+		True(t, uses > 0, format)
+	}
+}
+
 func validateTavorRandom(t *testing.T, seed int, format string, expect []string) {
 	root, err := parser.ParseTavor(strings.NewReader(format))
 	Nil(t, err)
@@ -3,9 +3,11 @@ package strategy
 import (
 	"fmt"
 	"sort"
+	"strconv"
 
 	"github.com/zimmski/tavor/rand"
 	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/lists"
 )
 
 // ErrorType the fuzzing strategy error type
@@ -16,6 +18,12 @@ const (
 	ErrEndlessLoopDetected ErrorType = iota
 	// ErrNilRandomGenerator the random generator is nil
 	ErrNilRandomGenerator
+	// ErrInvalidCorpus the corpus cannot be used
+	ErrInvalidCorpus
+	// ErrInvalidConfiguration the configuration of the fuzzing strategy is invalid
+	ErrInvalidConfiguration
+	// ErrAssertionsViolated no generation could be found which does not violate an assertion of the token graph
+	ErrAssertionsViolated
 )
 
 // Error holds a fuzzing strategy error
@@ -32,26 +40,104 @@ func (err *Error) Error() string {
 // The function starts the first iteration of the fuzzing strategy returning a channel which controls the iteration flow. The channel returns a value if the iteration is complete and waits with calculating the next iteration until a value is put in. The channel is automatically closed when there are no more iterations. The error return argument is not nil if an error occurs during the setup of the fuzzing strategy.
 type Strategy func(root token.Token, r rand.Rand) (chan struct{}, error)
 
+// Feedback holds the feedback of the execution of a generation
+type Feedback struct {
+	// Coverage holds an identifier for every edge, block or line of the tested program which was covered by the execution
+	Coverage []uint64
+	// Fitness rates the generation, a higher fitness is better
+	Fitness float64
+}
+
+// FeedbackStrategy defines a fuzzing strategy which is guided by the feedback of its generations.
+// The function starts the first iteration of the fuzzing strategy returning a channel which controls the iteration flow and a channel for the feedback of the iteration. The channel returns a value if the iteration is complete and waits with calculating the next iteration until feedback is given and a value is put in. The channels are automatically closed when there are no more iterations. The error return argument is not nil if an error occurs during the setup of the fuzzing strategy.
+type FeedbackStrategy func(root token.Token, r rand.Rand) (chan struct{}, chan<- Feedback, error)
+
 var strategyLookup = make(map[string]Strategy)
+var feedbackStrategyLookup = make(map[string]FeedbackStrategy)
 
 // New returns a new fuzzing strategy instance given the registered name of the strategy.
 // The error return argument is not nil, if the name does not exist in the registered fuzzing strategy list.
 func New(name string) (Strategy, error) {
 	strat, ok := strategyLookup[name]
 	if !ok {
+		if fstrat, ok := feedbackStrategyLookup[name]; ok {
+			return withoutFeedback(fstrat), nil
+		}
+
 		return nil, fmt.Errorf("unknown fuzzing strategy %q", name)
 	}
 
 	return strat, nil
 }
 
+// NewFeedback returns a new feedback fuzzing strategy instance given the registered name of the strategy.
+// The error return argument is not nil, if the name does not exist in the registered feedback fuzzing strategy list.
+func NewFeedback(name string) (FeedbackStrategy, error) {
+	strat, ok := feedbackStrategyLookup[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown feedback fuzzing strategy %q", name)
+	}
+
+	return strat, nil
+}
+
+// withoutFeedback returns a fuzzing strategy which gives empty feedback for every iteration of the given feedback fuzzing strategy
+func withoutFeedback(strat FeedbackStrategy) Strategy {
+	return func(root token.Token, r rand.Rand) (chan struct{}, error) {
+		ch, feedback, err := strat(root, r)
+		if err != nil {
+			return nil, err
+		}
+
+		continueFuzzing := make(chan struct{})
+
+		go func() {
+			for i := range ch {
+				continueFuzzing <- i
+
+				if _, ok := <-continueFuzzing; !ok {
+					close(feedback)
+
+					return
+				}
+
+				feedback <- Feedback{}
+				ch <- i
+			}
+
+			close(continueFuzzing)
+		}()
+
+		return continueFuzzing, nil
+	}
+}
+
+// childKey returns the key of a child of a list token which is the same for every generation of the token graph
+func childKey(l token.ListToken, c token.Token) string {
+	// items of repeats are clones of the same token and therefore share their key
+	if _, ok := l.(*lists.Repeat); ok {
+		return "*"
+	}
+
+	for j := 0; j < l.InternalLen(); j++ {
+		if ic, _ := l.InternalGet(j); ic == c {
+			return strconv.Itoa(j)
+		}
+	}
+
+	return "*"
+}
+
 // List returns a list of all registered fuzzing strategy names.
 func List() []string {
-	keyStrategyLookup := make([]string, 0, len(strategyLookup))
+	keyStrategyLookup := make([]string, 0, len(strategyLookup)+len(feedbackStrategyLookup))
 
 	for key := range strategyLookup {
 		keyStrategyLookup = append(keyStrategyLookup, key)
 	}
+	for key := range feedbackStrategyLookup {
+		keyStrategyLookup = append(keyStrategyLookup, key)
+	}
 
 	sort.Strings(keyStrategyLookup)
 
@@ -67,6 +153,25 @@ func Register(name string, strat Strategy) {
 	if _, ok := strategyLookup[name]; ok {
 		panic("fuzzing strategy " + name + " already registered")
 	}
+	if _, ok := feedbackStrategyLookup[name]; ok {
+		panic("fuzzing strategy " + name + " already registered")
+	}
 
 	strategyLookup[name] = strat
 }
+
+// RegisterFeedback registers a feedback fuzzing strategy instance function with the given name.
+func RegisterFeedback(name string, strat FeedbackStrategy) {
+	if strat == nil {
+		panic("register feedback fuzzing strategy is nil")
+	}
+
+	if _, ok := strategyLookup[name]; ok {
+		panic("fuzzing strategy " + name + " already registered")
+	}
+	if _, ok := feedbackStrategyLookup[name]; ok {
+		panic("fuzzing strategy " + name + " already registered")
+	}
+
+	feedbackStrategyLookup[name] = strat
+}
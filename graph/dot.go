@@ -25,8 +25,17 @@ type dotEdge struct {
 }
 
 type dotVertice struct {
-	label string
-	typ   string
+	label  string
+	source string
+	typ    string
+}
+
+func sourceLabel(tok token.Token) string {
+	if src, ok := token.GetSource(tok); ok {
+		return src.String()
+	}
+
+	return ""
 }
 
 func nodeUID(tok token.Token) string {
@@ -198,7 +207,8 @@ func (g *dotGraph) addDot(tok token.Token) (start, next map[token.Token]bool) {
 		}
 
 		g.vertices[tok] = dotVertice{
-			label: label,
+			label:  label,
+			source: sourceLabel(tok),
 		}
 
 		start[tok] = false
@@ -207,7 +217,8 @@ func (g *dotGraph) addDot(tok token.Token) (start, next map[token.Token]bool) {
 		return g.addDot(t.InternalGet())
 	default:
 		g.vertices[tok] = dotVertice{
-			label: tok.String(),
+			label:  tok.String(),
+			source: sourceLabel(tok),
 		}
 
 		start[tok] = false
@@ -273,6 +284,10 @@ func WriteDot(root token.Token, dst io.Writer) {
 	for tok, vertice := range g.vertices {
 		// Double escape the labels so that graphviz display the special sequences (\n, \t, ...)
 		label := strings.Replace(fmt.Sprintf("%q", vertice.label), "\\", "\\\\", -1)
+		if vertice.source != "" {
+			// the origin is put on its own line
+			label = label[:len(label)-1] + "\\n" + strings.Replace(fmt.Sprintf("%q", vertice.source), "\\", "\\\\", -1)[1:]
+		}
 		_, _ = fmt.Fprintf(dst, "\t%s [label=%s]\n", nodeUID(tok), label)
 	}
 
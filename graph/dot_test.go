@@ -2,10 +2,13 @@ package graph
 
 import (
 	"bytes"
+	"strings"
 	"testing"
+	"text/scanner"
 
 	. "github.com/zimmski/tavor/test/assert"
 
+	"github.com/zimmski/tavor/token"
 	"github.com/zimmski/tavor/token/lists"
 	"github.com/zimmski/tavor/token/primitives"
 )
@@ -47,3 +50,14 @@ func TestGraphDot(t *testing.T) {
 
 	True(t, len(got.String()) > 0)
 }
+
+func TestGraphDotSources(t *testing.T) {
+	var got bytes.Buffer
+
+	tok := primitives.NewConstantInt(1)
+	token.SetSource(tok, token.Source{Name: "START", Position: scanner.Position{Line: 1, Column: 9}})
+
+	WriteDot(tok, &got)
+
+	True(t, strings.Contains(got.String(), `[label="1\nSTART L:1, C:9"]`))
+}
@@ -0,0 +1,274 @@
+package lsp
+
+import (
+	"strings"
+	"text/scanner"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// symbol holds the occurrence of a token name in a document
+type symbol struct {
+	name       string
+	line       int // starting at 0
+	column     int // starting at 0, counted in characters
+	definition bool
+}
+
+type lexToken struct {
+	tok    rune
+	text   string
+	line   int
+	column int
+}
+
+type document struct {
+	uri   string
+	text  string
+	lines []string
+
+	// valid holds the last text of the document which could be parsed
+	valid string
+
+	symbols     []symbol
+	definitions map[string]symbol
+	typed       map[string]string
+	variables   map[string]struct{}
+}
+
+func newDocument(uri string, text string) *document {
+	d := &document{
+		uri: uri,
+	}
+
+	d.update(text)
+
+	return d
+}
+
+func (d *document) update(text string) {
+	d.text = text
+	d.lines = strings.Split(text, "\n")
+
+	d.symbols = nil
+	d.definitions = make(map[string]symbol)
+	d.typed = make(map[string]string)
+	d.variables = make(map[string]struct{})
+
+	d.index(lexScan(text))
+}
+
+// lexScan scans the text the same way as the Tavor parser does
+func lexScan(text string) []lexToken {
+	var s scanner.Scanner
+
+	s.Init(strings.NewReader(text))
+	s.Error = func(s *scanner.Scanner, msg string) {}
+	s.Whitespace = 1<<'\t' | 1<<' ' | 1<<'\r'
+
+	var tokens []lexToken
+
+	for c := s.Scan(); c != scanner.EOF; c = s.Scan() {
+		if c == '[' {
+			// skip character classes since their content is no token name
+			s.Whitespace ^= 1 << ' '
+
+			for c != ']' && c != '\n' && c != scanner.EOF {
+				c = s.Scan()
+			}
+
+			s.Whitespace |= 1 << ' '
+
+			if c != '\n' {
+				continue
+			}
+		}
+
+		tokens = append(tokens, lexToken{
+			tok:    c,
+			text:   s.TokenText(),
+			line:   s.Position.Line - 1,
+			column: s.Position.Column - 1,
+		})
+	}
+
+	return tokens
+}
+
+// index searches the tokens for definitions and usages of token names
+func (d *document) index(tokens []lexToken) {
+	start := true
+	typed := false
+
+	var last rune
+
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+
+		if t.tok == '\n' {
+			// a comma before the new line continues the definition in the next line
+			if last != ',' {
+				start = true
+			}
+
+			last = t.tok
+
+			continue
+		}
+
+		last = t.tok
+
+		if start {
+			start = false
+			typed = false
+
+			if t.tok == scanner.Ident && i+1 < len(tokens) && tokens[i+1].tok == '=' {
+				d.addSymbol(t, true)
+			} else if t.tok == scanner.Ident && t.text == "Const" && i+2 < len(tokens) && tokens[i+1].tok == scanner.Ident && tokens[i+2].tok == '=' {
+				d.addSymbol(tokens[i+1], true)
+
+				i++
+			} else if t.tok == '$' && i+1 < len(tokens) && tokens[i+1].tok == scanner.Ident {
+				typed = true
+
+				d.addSymbol(tokens[i+1], true)
+
+				if i+2 < len(tokens) && tokens[i+2].tok == scanner.Ident {
+					d.typed[tokens[i+1].text] = tokens[i+2].text
+				}
+			}
+
+			continue
+		}
+
+		if typed || t.tok != scanner.Ident {
+			continue
+		}
+
+		switch prev := tokens[i-1]; {
+		case prev.tok == '.':
+			// token attribute
+		case prev.tok == '<', prev.tok == '=' && i > 1 && tokens[i-2].tok == '<':
+			d.variables[t.text] = struct{}{}
+		default:
+			d.addSymbol(t, false)
+		}
+	}
+}
+
+func (d *document) addSymbol(t lexToken, definition bool) {
+	s := symbol{
+		name:       t.text,
+		line:       t.line,
+		column:     t.column,
+		definition: definition,
+	}
+
+	d.symbols = append(d.symbols, s)
+
+	if definition {
+		if _, ok := d.definitions[s.name]; !ok {
+			d.definitions[s.name] = s
+		}
+	}
+}
+
+// symbolAt returns the symbol at the given position
+func (d *document) symbolAt(pos position) (symbol, bool) {
+	column := d.column(pos)
+
+	for _, s := range d.symbols {
+		if s.line == pos.Line && column >= s.column && column <= s.column+utf8.RuneCountInString(s.name) {
+			return s, true
+		}
+	}
+
+	return symbol{}, false
+}
+
+// usages returns all occurrences of the given token name
+func (d *document) usages(name string, includeDefinition bool) []symbol {
+	var symbols []symbol
+
+	if _, ok := d.definitions[name]; !ok {
+		return nil
+	}
+
+	for _, s := range d.symbols {
+		if s.name == name && (includeDefinition || !s.definition) {
+			symbols = append(symbols, s)
+		}
+	}
+
+	return symbols
+}
+
+// column converts the UTF-16 based character of a position to a column counted in characters
+func (d *document) column(pos position) int {
+	if pos.Line < 0 || pos.Line >= len(d.lines) {
+		return pos.Character
+	}
+
+	units := 0
+	column := 0
+
+	for _, r := range d.lines[pos.Line] {
+		if units >= pos.Character {
+			break
+		}
+
+		units += len(utf16.Encode([]rune{r}))
+		column++
+	}
+
+	return column
+}
+
+// position converts a line and a column counted in characters to a UTF-16 based position
+func (d *document) position(line int, column int) position {
+	if line < 0 || line >= len(d.lines) {
+		return position{
+			Line:      line,
+			Character: column,
+		}
+	}
+
+	units := 0
+	i := 0
+
+	for _, r := range d.lines[line] {
+		if i >= column {
+			break
+		}
+
+		units += len(utf16.Encode([]rune{r}))
+		i++
+	}
+
+	return position{
+		Line:      line,
+		Character: units,
+	}
+}
+
+func (d *document) symbolRange(s symbol) textRange {
+	return textRange{
+		Start: d.position(s.line, s.column),
+		End:   d.position(s.line, s.column+utf8.RuneCountInString(s.name)),
+	}
+}
+
+// linePrefix returns the text of the line in front of the given position
+func (d *document) linePrefix(pos position) string {
+	if pos.Line < 0 || pos.Line >= len(d.lines) {
+		return ""
+	}
+
+	line := []rune(d.lines[pos.Line])
+	column := d.column(pos)
+	if column > len(line) {
+		column = len(line)
+	}
+
+	return string(line[:column])
+}
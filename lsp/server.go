@@ -0,0 +1,635 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zimmski/tavor/parser"
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/variables"
+)
+
+var (
+	completionTypedExp     = regexp.MustCompile(`^\s*\$[a-zA-Z_]\w*\s+\w*$`)
+	completionAttributeExp = regexp.MustCompile(`([a-zA-Z_]\w*)\.\w*$`)
+	tokenNameExp           = regexp.MustCompile(`^[a-zA-Z_]\w*$`)
+)
+
+// Server holds the state of a Language Server protocol session
+type Server struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	documents map[string]*document
+	shutdown  bool
+}
+
+// NewServer returns a new Language Server which reads its requests from in and writes its responses to out
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		in:  bufio.NewReader(in),
+		out: out,
+
+		documents: make(map[string]*document),
+	}
+}
+
+// Serve speaks the Language Server protocol over the given reader and writer until the client exits or the input is closed.
+// The error return argument is not nil if the communication with the client failed.
+func Serve(in io.Reader, out io.Writer) error {
+	return NewServer(in, out).Serve()
+}
+
+// Serve handles requests until the client exits or the input is closed
+func (s *Server) Serve() error {
+	for {
+		data, err := s.read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		var msg message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			if err := s.respondError(nil, errParse, err.Error()); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if msg.Method == "exit" {
+			return nil
+		}
+
+		if err := s.handle(&msg); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) read() ([]byte, error) {
+	length := -1
+
+	for {
+		line, err := s.in.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && line != "" {
+				return nil, io.ErrUnexpectedEOF
+			}
+
+			return nil, err
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+
+		if i := strings.Index(line, ":"); i != -1 && strings.EqualFold(strings.TrimSpace(line[:i]), "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(line[i+1:]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid Content-Length header %q", line)
+			}
+		}
+	}
+
+	if length < 0 {
+		return nil, fmt.Errorf("missing Content-Length header")
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(s.in, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (s *Server) write(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n", len(data)); err != nil {
+		return err
+	}
+
+	_, err = s.out.Write(data)
+
+	return err
+}
+
+func (s *Server) respond(id *json.RawMessage, result interface{}) error {
+	return s.write(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"result":  result,
+	})
+}
+
+func (s *Server) respondError(id *json.RawMessage, code int, msg string) error {
+	return s.write(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"error": responseError{
+			Code:    code,
+			Message: msg,
+		},
+	})
+}
+
+func (s *Server) notify(method string, params interface{}) error {
+	return s.write(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	})
+}
+
+func (s *Server) handle(msg *message) error {
+	type handler func(s *Server, params json.RawMessage) (interface{}, *responseError)
+
+	requests := map[string]handler{
+		"initialize":              (*Server).initialize,
+		"shutdown":                (*Server).shutdownRequest,
+		"textDocument/definition": (*Server).definition,
+		"textDocument/references": (*Server).references,
+		"textDocument/hover":      (*Server).hover,
+		"textDocument/completion": (*Server).completion,
+		"textDocument/rename":     (*Server).rename,
+	}
+	notifications := map[string]func(s *Server, params json.RawMessage) error{
+		"textDocument/didOpen":   (*Server).didOpen,
+		"textDocument/didChange": (*Server).didChange,
+		"textDocument/didClose":  (*Server).didClose,
+	}
+
+	if msg.ID == nil {
+		if n, ok := notifications[msg.Method]; ok {
+			return n(s, msg.Params)
+		}
+
+		// unknown notifications are ignored
+		return nil
+	}
+
+	if s.shutdown {
+		return s.respondError(msg.ID, errInvalidRequest, "server is shut down")
+	}
+
+	h, ok := requests[msg.Method]
+	if !ok {
+		return s.respondError(msg.ID, errMethodNotFound, fmt.Sprintf("method %q not found", msg.Method))
+	}
+
+	result, rerr := h(s, msg.Params)
+	if rerr != nil {
+		return s.respondError(msg.ID, rerr.Code, rerr.Message)
+	}
+
+	return s.respond(msg.ID, result)
+}
+
+func unmarshalParams(params json.RawMessage, v interface{}) *responseError {
+	if err := json.Unmarshal(params, v); err != nil {
+		return &responseError{
+			Code:    errInvalidParams,
+			Message: err.Error(),
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) document(uri string) (*document, *responseError) {
+	d, ok := s.documents[uri]
+	if !ok {
+		return nil, &responseError{
+			Code:    errInvalidParams,
+			Message: fmt.Sprintf("document %q is not open", uri),
+		}
+	}
+
+	return d, nil
+}
+
+func (s *Server) initialize(params json.RawMessage) (interface{}, *responseError) {
+	return map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"textDocumentSync":   1, // full
+			"definitionProvider": true,
+			"referencesProvider": true,
+			"hoverProvider":      true,
+			"completionProvider": map[string]interface{}{
+				"triggerCharacters": []string{"$", "."},
+			},
+			"renameProvider": true,
+		},
+		"serverInfo": map[string]interface{}{
+			"name": "tavor",
+		},
+	}, nil
+}
+
+func (s *Server) shutdownRequest(params json.RawMessage) (interface{}, *responseError) {
+	s.shutdown = true
+
+	return nil, nil
+}
+
+func (s *Server) didOpen(params json.RawMessage) error {
+	var p didOpenTextDocumentParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil
+	}
+
+	d := newDocument(p.TextDocument.URI, p.TextDocument.Text)
+	s.documents[d.uri] = d
+
+	return s.publishDiagnostics(d)
+}
+
+func (s *Server) didChange(params json.RawMessage) error {
+	var p didChangeTextDocumentParams
+	if err := json.Unmarshal(params, &p); err != nil || len(p.ContentChanges) == 0 {
+		return nil
+	}
+
+	d, ok := s.documents[p.TextDocument.URI]
+	if !ok {
+		return nil
+	}
+
+	// only full synchronization is supported so the last change holds the whole text
+	d.update(p.ContentChanges[len(p.ContentChanges)-1].Text)
+
+	return s.publishDiagnostics(d)
+}
+
+func (s *Server) didClose(params json.RawMessage) error {
+	var p didCloseTextDocumentParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil
+	}
+
+	delete(s.documents, p.TextDocument.URI)
+
+	// clear the diagnostics of the closed document
+	return s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         p.TextDocument.URI,
+		Diagnostics: []diagnostic{},
+	})
+}
+
+// parseToken parses the text and returns the token graph beginning with the given token definition.
+// Panics of the parser are returned as errors since a half written format file must not stop the server.
+func parseToken(text string, name string) (tok token.Token, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	return parser.ParseTavorToken(strings.NewReader(text), name)
+}
+
+func exports(text string) (names []string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	return parser.Exports(strings.NewReader(text))
+}
+
+func tokenAttributes(text string, name string) (attributes []string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	return parser.ParseTavorTokenAttributes(strings.NewReader(text), name)
+}
+
+func lintText(text string) (findings []parser.LintFinding, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	return parser.LintTavor(strings.NewReader(text))
+}
+
+func (s *Server) publishDiagnostics(d *document) error {
+	diagnostics := []diagnostic{}
+
+	// formats without a START token are valid if they export other entry points
+	start := "START"
+	if names, err := exports(d.text); err == nil && len(names) != 0 {
+		start = names[0]
+	}
+
+	_, err := parseToken(d.text, start)
+	if err != nil {
+		var errs token.ParserErrors
+
+		switch e := err.(type) {
+		case *token.ParserError:
+			errs = token.ParserErrors{e}
+		case token.ParserErrors:
+			errs = e
+		default:
+			diagnostics = append(diagnostics, diagnostic{
+				Severity: severityError,
+				Source:   "tavor",
+				Message:  err.Error(),
+			})
+		}
+
+		for _, perr := range errs {
+			pos := d.scannerPosition(perr.Position.Line, perr.Position.Column)
+
+			diagnostics = append(diagnostics, diagnostic{
+				Range: textRange{
+					Start: pos,
+					End:   pos,
+				},
+				Severity: severityError,
+				Source:   "tavor",
+				Message:  perr.Message,
+			})
+		}
+	} else {
+		d.valid = d.text
+
+		findings, _ := lintText(d.text)
+
+		for _, f := range findings {
+			severity := severityError
+			switch f.Severity {
+			case parser.LintWarning:
+				severity = severityWarning
+			case parser.LintInfo:
+				severity = severityInformation
+			}
+
+			pos := d.scannerPosition(f.Position.Line, f.Position.Column)
+
+			diagnostics = append(diagnostics, diagnostic{
+				Range: textRange{
+					Start: pos,
+					End:   pos,
+				},
+				Severity: severity,
+				Source:   "tavor",
+				Message:  f.Message,
+			})
+		}
+	}
+
+	return s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         d.uri,
+		Diagnostics: diagnostics,
+	})
+}
+
+// scannerPosition converts a line and column of the scanner, both starting at 1, to a position
+func (d *document) scannerPosition(line int, column int) position {
+	if line < 1 {
+		return position{}
+	}
+	if column < 1 {
+		column = 1
+	}
+
+	return d.position(line-1, column-1)
+}
+
+func (s *Server) definition(params json.RawMessage) (interface{}, *responseError) {
+	var p textDocumentPositionParams
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	d, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	sym, ok := d.symbolAt(p.Position)
+	if !ok {
+		return nil, nil
+	}
+
+	def, ok := d.definitions[sym.name]
+	if !ok {
+		return nil, nil
+	}
+
+	return location{
+		URI:   d.uri,
+		Range: d.symbolRange(def),
+	}, nil
+}
+
+func (s *Server) references(params json.RawMessage) (interface{}, *responseError) {
+	var p referenceParams
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	d, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	sym, ok := d.symbolAt(p.Position)
+	if !ok {
+		return nil, nil
+	}
+
+	locations := []location{}
+	for _, u := range d.usages(sym.name, p.Context.IncludeDeclaration) {
+		locations = append(locations, location{
+			URI:   d.uri,
+			Range: d.symbolRange(u),
+		})
+	}
+
+	return locations, nil
+}
+
+func (s *Server) hover(params json.RawMessage) (interface{}, *responseError) {
+	var p textDocumentPositionParams
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	d, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	sym, ok := d.symbolAt(p.Position)
+	if !ok {
+		return nil, nil
+	}
+	if _, ok := d.definitions[sym.name]; !ok {
+		return nil, nil
+	}
+
+	tok, perr := parseToken(d.valid, sym.name)
+	if perr != nil {
+		return nil, nil
+	}
+
+	header := fmt.Sprintf("**%s**", sym.name)
+	if typ, ok := d.typed[sym.name]; ok {
+		header += fmt.Sprintf(" (%s)", typ)
+	}
+
+	return hover{
+		Contents: markupContent{
+			Kind:  "markdown",
+			Value: fmt.Sprintf("%s\n\nPermutations: %d", header, tok.PermutationsAll()),
+		},
+		Range: d.symbolRange(sym),
+	}, nil
+}
+
+func (s *Server) completion(params json.RawMessage) (interface{}, *responseError) {
+	var p textDocumentPositionParams
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	d, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []completionItem{}
+	prefix := d.linePrefix(p.Position)
+
+	if completionTypedExp.MatchString(prefix) {
+		typedNames := token.ListTyped()
+		sort.Strings(typedNames)
+
+		for _, name := range typedNames {
+			items = append(items, completionItem{
+				Label:  name,
+				Kind:   completionKindClass,
+				Detail: "typed token",
+			})
+		}
+
+		return items, nil
+	}
+
+	if m := completionAttributeExp.FindStringSubmatch(prefix); m != nil {
+		name := m[1]
+
+		var attributes []string
+		if _, ok := d.definitions[name]; ok {
+			attributes, _ = tokenAttributes(d.valid, name)
+		} else if _, ok := d.variables[name]; ok {
+			attributes = parser.TokenAttributes(variables.NewVariable(name, nil))
+		}
+
+		for _, attribute := range attributes {
+			items = append(items, completionItem{
+				Label:  attribute,
+				Kind:   completionKindProperty,
+				Detail: "attribute of " + name,
+			})
+		}
+
+		return items, nil
+	}
+
+	names := make([]string, 0, len(d.definitions))
+	for name := range d.definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		item := completionItem{
+			Label:  name,
+			Kind:   completionKindReference,
+			Detail: "token",
+		}
+		if typ, ok := d.typed[name]; ok {
+			item.Detail = "typed token " + typ
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (s *Server) rename(params json.RawMessage) (interface{}, *responseError) {
+	var p renameParams
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	d, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	sym, ok := d.symbolAt(p.Position)
+	if !ok {
+		return nil, &responseError{
+			Code:    errRequestFailed,
+			Message: "no token at the given position",
+		}
+	}
+	if _, ok := d.definitions[sym.name]; !ok {
+		return nil, &responseError{
+			Code:    errRequestFailed,
+			Message: fmt.Sprintf("token %q is not defined", sym.name),
+		}
+	}
+
+	if !tokenNameExp.MatchString(p.NewName) {
+		return nil, &responseError{
+			Code:    errInvalidParams,
+			Message: fmt.Sprintf("%q is not a valid token name", p.NewName),
+		}
+	}
+	if _, ok := d.definitions[p.NewName]; ok && p.NewName != sym.name {
+		return nil, &responseError{
+			Code:    errRequestFailed,
+			Message: fmt.Sprintf("token %q is already defined", p.NewName),
+		}
+	}
+
+	var edits []textEdit
+	for _, u := range d.usages(sym.name, true) {
+		edits = append(edits, textEdit{
+			Range:   d.symbolRange(u),
+			NewText: p.NewName,
+		})
+	}
+
+	return workspaceEdit{
+		Changes: map[string][]textEdit{
+			d.uri: edits,
+		},
+	}, nil
+}
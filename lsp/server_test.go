@@ -0,0 +1,203 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+)
+
+const testURI = "file:///test.tavor"
+
+type testClient struct {
+	t *testing.T
+
+	in     bytes.Buffer
+	nextID int
+}
+
+func (c *testClient) send(method string, id int, params interface{}) {
+	msg := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	}
+	if id != 0 {
+		msg["id"] = id
+	}
+
+	data, err := json.Marshal(msg)
+	Nil(c.t, err)
+
+	fmt.Fprintf(&c.in, "Content-Length: %d\r\n\r\n%s", len(data), data)
+}
+
+func (c *testClient) request(method string, params interface{}) int {
+	c.nextID++
+
+	c.send(method, c.nextID, params)
+
+	return c.nextID
+}
+
+func (c *testClient) notify(method string, params interface{}) {
+	c.send(method, 0, params)
+}
+
+// run serves all queued messages and returns the responses by their id and the notifications in their order
+func (c *testClient) run() (map[int]map[string]interface{}, []map[string]interface{}) {
+	var out bytes.Buffer
+
+	Nil(c.t, Serve(&c.in, &out))
+
+	responses := make(map[int]map[string]interface{})
+	var notifications []map[string]interface{}
+
+	s := &Server{in: bufio.NewReader(&out)}
+	for {
+		data, err := s.read()
+		if err == io.EOF {
+			break
+		}
+		Nil(c.t, err)
+
+		var msg map[string]interface{}
+		Nil(c.t, json.Unmarshal(data, &msg))
+
+		if id, ok := msg["id"]; ok {
+			responses[int(id.(float64))] = msg
+		} else {
+			notifications = append(notifications, msg)
+		}
+	}
+
+	return responses, notifications
+}
+
+func textPosition(line, character int) map[string]interface{} {
+	return map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": testURI},
+		"position":     map[string]interface{}{"line": line, "character": character},
+	}
+}
+
+func completionLabels(response map[string]interface{}) []string {
+	var labels []string
+
+	for _, item := range response["result"].([]interface{}) {
+		labels = append(labels, item.(map[string]interface{})["label"].(string))
+	}
+
+	return labels
+}
+
+func TestServer(t *testing.T) {
+	src := strings.Join([]string{
+		`$Id Sequence`,
+		`Number = +([0-9])`,
+		`List = Number *("," Number)`,
+		`START = "[" ?(List) "]" $Id.Next`,
+		``,
+	}, "\n")
+
+	c := &testClient{t: t}
+
+	initialize := c.request("initialize", map[string]interface{}{})
+	c.notify("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{
+			"uri":        testURI,
+			"languageId": "tavor",
+			"version":    1,
+			"text":       src,
+		},
+	})
+
+	definition := c.request("textDocument/definition", textPosition(2, 8))
+
+	references := c.request("textDocument/references", func() map[string]interface{} {
+		p := textPosition(1, 2)
+		p["context"] = map[string]interface{}{"includeDeclaration": true}
+
+		return p
+	}())
+
+	hover := c.request("textDocument/hover", textPosition(1, 2))
+
+	completionTokens := c.request("textDocument/completion", textPosition(3, 8))
+	completionAttributes := c.request("textDocument/completion", textPosition(3, 29))
+
+	rename := c.request("textDocument/rename", func() map[string]interface{} {
+		p := textPosition(2, 9)
+		p["newName"] = "Digits"
+
+		return p
+	}())
+	renameInvalid := c.request("textDocument/rename", func() map[string]interface{} {
+		p := textPosition(2, 9)
+		p["newName"] = "List"
+
+		return p
+	}())
+
+	c.notify("textDocument/didChange", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": testURI, "version": 2},
+		"contentChanges": []interface{}{
+			map[string]interface{}{"text": "$Id Seq\n"},
+		},
+	})
+
+	completionTyped := c.request("textDocument/completion", textPosition(0, 5))
+
+	unknown := c.request("textDocument/unknown", map[string]interface{}{})
+	shutdown := c.request("shutdown", nil)
+	c.notify("exit", nil)
+
+	responses, notifications := c.run()
+
+	// initialize
+	capabilities := responses[initialize]["result"].(map[string]interface{})["capabilities"].(map[string]interface{})
+	Equal(t, true, capabilities["definitionProvider"])
+	Equal(t, true, capabilities["renameProvider"])
+
+	// diagnostics
+	Equal(t, 2, len(notifications))
+	Equal(t, "textDocument/publishDiagnostics", notifications[0]["method"])
+	Equal(t, 0, len(notifications[0]["params"].(map[string]interface{})["diagnostics"].([]interface{})))
+	diagnostics := notifications[1]["params"].(map[string]interface{})["diagnostics"].([]interface{})
+	Equal(t, 1, len(diagnostics))
+	Equal(t, float64(severityError), diagnostics[0].(map[string]interface{})["severity"])
+
+	// definition
+	Equal(t, map[string]interface{}{
+		"uri": testURI,
+		"range": map[string]interface{}{
+			"start": map[string]interface{}{"line": float64(1), "character": float64(0)},
+			"end":   map[string]interface{}{"line": float64(1), "character": float64(6)},
+		},
+	}, responses[definition]["result"])
+
+	// references
+	Equal(t, 3, len(responses[references]["result"].([]interface{})))
+
+	// hover
+	Contains(t, responses[hover]["result"].(map[string]interface{})["contents"].(map[string]interface{})["value"], "Permutations: 110")
+
+	// completion
+	Equal(t, []string{"Id", "List", "Number", "START"}, completionLabels(responses[completionTokens]))
+	Equal(t, []string{"Existing", "Next", "Reset"}, completionLabels(responses[completionAttributes]))
+	Contains(t, completionLabels(responses[completionTyped]), "Sequence")
+	Contains(t, completionLabels(responses[completionTyped]), "Int")
+
+	// rename
+	Equal(t, 3, len(responses[rename]["result"].(map[string]interface{})["changes"].(map[string]interface{})[testURI].([]interface{})))
+	NotNil(t, responses[renameInvalid]["error"])
+
+	// errors and shutdown
+	Equal(t, float64(errMethodNotFound), responses[unknown]["error"].(map[string]interface{})["code"])
+	Nil(t, responses[shutdown]["result"])
+}
@@ -0,0 +1,670 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"text/scanner"
+	"unicode/utf8"
+
+	"github.com/zimmski/tavor/token/expressions"
+	"github.com/zimmski/tavor/token/lists"
+)
+
+// formatCharacterClass is the pseudo rune of a whole character class since white spaces are significant inside of them
+const formatCharacterClass = -100
+
+// formatHeredoc is the pseudo rune of a whole heredoc string since white spaces and new lines are significant inside of them
+const formatHeredoc = -101
+
+type formatToken struct {
+	tok  rune
+	text string
+
+	// call is true for bit fields, tlv frames and tlv types since they need their opening parenthesis right after the keyword
+	call bool
+	// peek is the character right after the token if the parser looks at it to decide how the token is parsed, e.g. "(" after the name of an expression function. It is 0 otherwise.
+	peek rune
+}
+
+// formatExpressionKeywords holds the keywords of expressions which are parsed independent of their following character
+var formatExpressionKeywords = map[string]struct{}{
+	"by":           {},
+	"connect":      {},
+	"defined":      {},
+	"difference":   {},
+	"from":         {},
+	"in":           {},
+	"include":      {},
+	"intersection": {},
+	"not":          {},
+	"over":         {},
+	"path":         {},
+	"union":        {},
+	"without":      {},
+}
+
+// formatPeek returns the character which follows the token directly if the parser looks at it to decide how the token is parsed
+func formatPeek(tok rune, text string, next rune, expression bool) rune {
+	if !expression {
+		return 0
+	}
+
+	switch {
+	case tok == scanner.Ident && (next == '(' || next == '.'):
+		// expression functions and token attributes
+		if _, ok := formatExpressionKeywords[text]; !ok {
+			return next
+		}
+	case (tok == '<' || tok == '>') && next == '=':
+		// comparison operators of conditions
+		return next
+	}
+
+	return 0
+}
+
+func formatCall(name string) bool {
+	if name == "bits" || name == "tlv" {
+		return true
+	}
+
+	_, ok := lists.IntEncodings[name]
+
+	return ok
+}
+
+func (t formatToken) isComment() bool {
+	return t.tok == scanner.Comment
+}
+
+type formatStatementKind int
+
+const (
+	formatStatementComment formatStatementKind = iota
+	formatStatementDefinition
+	formatStatementTypedDefinition
+	formatStatementOther
+)
+
+type formatStatement struct {
+	kind  formatStatementKind
+	lines [][]formatToken
+
+	blankBefore bool
+	newLine     bool
+}
+
+// formatContext holds the state of an opened parenthesis or curly brace
+type formatContext struct {
+	expression bool
+	keyword    string
+}
+
+type tavorFormatter struct {
+	contexts []formatContext
+	bound    bool
+
+	prev  *formatToken
+	prev2 *formatToken
+
+	closedKeyword string
+}
+
+// FormatTavor reads a Tavor formatted input and returns it in the canonical layout of the Tavor format.
+// Comments are kept and the accepted language of the format is not changed. The error return argument is not nil if the input cannot be read or if it cannot be formatted without changing its meaning.
+func FormatTavor(src io.Reader) ([]byte, error) {
+	data, err := ioutil.ReadAll(src)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := formatScan(data)
+
+	var out bytes.Buffer
+
+	statements := formatStatements(tokens)
+
+	for i := 0; i < len(statements); i++ {
+		if i != 0 && statements[i].blankBefore {
+			out.WriteString("\n")
+		}
+
+		// consecutive definitions are aligned at their equal sign
+		j := i + 1
+		if statements[i].kind == formatStatementDefinition {
+			for j < len(statements) && statements[j].kind == formatStatementDefinition && !statements[j].blankBefore {
+				j++
+			}
+		}
+
+		formatBlock(&out, statements[i:j])
+
+		i = j - 1
+	}
+
+	formatted := out.Bytes()
+
+	if !formatEqual(tokens, formatScan(formatted)) {
+		return nil, errors.New("formatting would change the meaning of the format")
+	}
+
+	return formatted, nil
+}
+
+// formatScan scans the given data the same way as the Tavor parser does but keeps comments
+func formatScan(data []byte) []formatToken {
+	var s scanner.Scanner
+
+	s.Init(bytes.NewReader(data))
+	s.Error = func(s *scanner.Scanner, msg string) {}
+	s.Mode = scanner.GoTokens &^ scanner.SkipComments
+	s.Whitespace = 1<<'\t' | 1<<' ' | 1<<'\r'
+
+	var tokens []formatToken
+
+	// expressions are always inside curly braces
+	expressions := 0
+
+	for c := s.Scan(); c != scanner.EOF; c = s.Scan() {
+		switch c {
+		case '{':
+			expressions++
+		case '}':
+			if expressions > 0 {
+				expressions--
+			}
+		}
+
+		if c == '<' && s.Peek() == '<' {
+			s.Next()
+
+			raw, _, _ := scanHeredoc(&s)
+
+			tokens = append(tokens, formatToken{
+				tok:  formatHeredoc,
+				text: "<<" + raw,
+			})
+
+			continue
+		} else if c != '[' {
+			tokens = append(tokens, formatToken{
+				tok:  c,
+				text: s.TokenText(),
+				call: c == scanner.Ident && formatCall(s.TokenText()) && s.Peek() == '(',
+				peek: formatPeek(c, s.TokenText(), s.Peek(), expressions > 0),
+			})
+
+			continue
+		}
+
+		pattern, end := scanCharacterClass(&s)
+
+		text := "[" + pattern
+		if end == ']' {
+			text += "]"
+		}
+
+		tokens = append(tokens, formatToken{
+			tok:  formatCharacterClass,
+			text: text,
+		})
+
+		if end == '\n' {
+			tokens = append(tokens, formatToken{
+				tok:  end,
+				text: "\n",
+			})
+		}
+	}
+
+	return tokens
+}
+
+// formatStatements groups the tokens into global comments and definitions which are split into their lines
+func formatStatements(tokens []formatToken) []*formatStatement {
+	var statements []*formatStatement
+	var statement *formatStatement
+	var line []formatToken
+	var last *formatToken
+
+	newLines := 0
+
+	for i := range tokens {
+		t := tokens[i]
+
+		if statement == nil {
+			switch {
+			case t.tok == '\n':
+				newLines++
+			case t.isComment():
+				statements = append(statements, &formatStatement{
+					kind:        formatStatementComment,
+					lines:       [][]formatToken{{t}},
+					blankBefore: newLines > 1,
+					newLine:     true,
+				})
+
+				newLines = 0
+			default:
+				statement = &formatStatement{
+					blankBefore: newLines > 1,
+				}
+
+				switch t.tok {
+				case scanner.Ident:
+					statement.kind = formatStatementDefinition
+				case '$':
+					statement.kind = formatStatementTypedDefinition
+				default:
+					statement.kind = formatStatementOther
+				}
+
+				statements = append(statements, statement)
+
+				line = []formatToken{t}
+				last = &tokens[i]
+				newLines = 0
+			}
+
+			continue
+		}
+
+		if t.tok != '\n' {
+			line = append(line, t)
+
+			if !t.isComment() {
+				last = &tokens[i]
+			}
+
+			continue
+		}
+
+		statement.lines = append(statement.lines, line)
+		line = nil
+
+		// a comma before the new line continues the definition in the next line
+		if last != nil && last.tok == ',' {
+			last = nil
+
+			continue
+		}
+
+		statement.newLine = true
+		statement = nil
+		newLines = 1
+	}
+
+	if statement != nil {
+		statement.lines = append(statement.lines, line)
+	}
+
+	return statements
+}
+
+func formatBlock(out *bytes.Buffer, statements []*formatStatement) {
+	heads := make([]string, len(statements))
+	width := 0
+
+	for i, statement := range statements {
+		if statement.kind != formatStatementDefinition {
+			continue
+		}
+
+		f := &tavorFormatter{}
+
+		var head []formatToken
+		for _, t := range statement.lines[0] {
+			if t.tok == '=' {
+				break
+			}
+
+			head = append(head, t)
+		}
+
+		heads[i] = f.join(head)
+
+		if w := utf8.RuneCountInString(heads[i]); w > width {
+			width = w
+		}
+	}
+
+	for i, statement := range statements {
+		f := &tavorFormatter{}
+
+		switch statement.kind {
+		case formatStatementComment:
+			out.WriteString(statement.lines[0][0].text)
+		case formatStatementDefinition, formatStatementTypedDefinition, formatStatementOther:
+			first := statement.lines[0]
+
+			var head []formatToken
+			var body []formatToken
+
+			for j, t := range first {
+				if t.tok == '=' {
+					head = first[:j]
+					body = first[j+1:]
+
+					break
+				}
+			}
+
+			if head == nil {
+				// there is no body e.g. typed tokens without arguments
+				out.WriteString(f.join(first))
+				for _, l := range statement.lines[1:] {
+					out.WriteString("\n")
+					out.WriteString(f.join(l))
+				}
+
+				break
+			}
+
+			h := f.join(head)
+			if statement.kind == formatStatementDefinition {
+				h = heads[i] + strings.Repeat(" ", width-utf8.RuneCountInString(heads[i]))
+			}
+
+			f.advance(&formatToken{tok: '=', text: "="}, nil)
+
+			prefix := h + " = "
+			indent := strings.Repeat(" ", utf8.RuneCountInString(prefix))
+
+			lines := append([][]formatToken{body}, statement.lines[1:]...)
+
+			if statement.kind == formatStatementTypedDefinition {
+				formatArguments(out, f, prefix, indent, lines)
+
+				break
+			}
+
+			for j, l := range lines {
+				if j == 0 {
+					out.WriteString(prefix)
+				} else {
+					out.WriteString("\n")
+					out.WriteString(indent)
+				}
+
+				out.WriteString(f.join(l))
+			}
+		}
+
+		if statement.newLine {
+			out.WriteString("\n")
+		}
+	}
+}
+
+// formatArguments writes the arguments of a typed token definition with aligned argument values
+func formatArguments(out *bytes.Buffer, f *tavorFormatter, prefix string, indent string, lines [][]formatToken) {
+	width := 0
+
+	for _, l := range lines {
+		if len(l) > 1 && l[0].tok == scanner.Ident && l[1].tok == ':' {
+			if w := utf8.RuneCountInString(l[0].text); w > width {
+				width = w
+			}
+		}
+	}
+
+	for j, l := range lines {
+		if j == 0 {
+			out.WriteString(prefix)
+		} else {
+			out.WriteString("\n")
+			out.WriteString(indent)
+		}
+
+		if len(l) > 2 && l[0].tok == scanner.Ident && l[1].tok == ':' {
+			out.WriteString(l[0].text)
+			out.WriteString(":")
+			out.WriteString(strings.Repeat(" ", width-utf8.RuneCountInString(l[0].text)+1))
+
+			f.advance(&l[0], nil)
+			f.advance(&l[1], nil)
+
+			out.WriteString(f.join(l[2:]))
+		} else {
+			out.WriteString(f.join(l))
+		}
+	}
+}
+
+// join returns the canonical representation of one line of tokens
+func (f *tavorFormatter) join(tokens []formatToken) string {
+	var buf bytes.Buffer
+
+	for i := range tokens {
+		t := &tokens[i]
+
+		var next *formatToken
+		if i+1 < len(tokens) {
+			next = &tokens[i+1]
+		}
+
+		if i != 0 && f.space(t, next) {
+			buf.WriteString(" ")
+		}
+
+		buf.WriteString(t.text)
+
+		f.advance(t, next)
+	}
+
+	return buf.String()
+}
+
+func (f *tavorFormatter) expression() bool {
+	return len(f.contexts) != 0 && f.contexts[len(f.contexts)-1].expression
+}
+
+func (f *tavorFormatter) prevIs(tok rune) bool {
+	return f.prev != nil && f.prev.tok == tok
+}
+
+func (f *tavorFormatter) prev2Is(tok rune) bool {
+	return f.prev2 != nil && f.prev2.tok == tok
+}
+
+// space returns true if there should be a white space between the previous and the current token
+func (f *tavorFormatter) space(cur *formatToken, next *formatToken) bool {
+	if f.prev == nil || f.prev.isComment() || cur.isComment() {
+		return true
+	}
+
+	if f.bound {
+		return false
+	}
+
+	// comparison operators of conditions are surrounded by spaces
+	if f.condition() {
+		switch {
+		case cur.tok == '=' && (f.prevIs('!') || f.prevIs('<') || f.prevIs('>')):
+			return false
+		case cur.tok == '!' || cur.tok == '<' || cur.tok == '>':
+			return true
+		case f.prevIs('!') || f.prevIs('<') || f.prevIs('>') || (f.prevIs('=') && (f.prev2Is('!') || f.prev2Is('<') || f.prev2Is('>'))):
+			return true
+		}
+	}
+
+	switch f.prev.tok {
+	case '(', '.', '$', '<', '{':
+		return false
+	case '=':
+		if f.prev2Is('<') {
+			return false
+		}
+	case '-', '+':
+		if f.prev2Is(':') || f.prev2Is('=') {
+			return false
+		}
+	}
+
+	switch cur.tok {
+	case ')', '}', ',', '.', ':', '>', '<':
+		return false
+	case '{':
+		return next != nil && (next.text == "if" || next.text == "switch" || next.text == "assert")
+	case '(':
+		if f.attributeCall() || f.functionCall() || (f.prev != nil && f.prev.call) {
+			return false
+		}
+	case '=':
+		if f.prevIs('=') {
+			return false
+		}
+	}
+
+	if f.prevIs('}') {
+		return f.closedKeyword == "" || f.closedKeyword == "endif" || f.closedKeyword == "endswitch" || f.closedKeyword == "assert"
+	}
+
+	if !f.expression() {
+		switch f.prev.tok {
+		case '?', '+', '*', '@':
+			return false
+		}
+	}
+
+	return true
+}
+
+// condition returns true if the current context is the condition of an if or assert statement
+func (f *tavorFormatter) condition() bool {
+	if len(f.contexts) == 0 {
+		return false
+	}
+
+	switch f.contexts[len(f.contexts)-1].keyword {
+	case "if", "else", "assert":
+		return true
+	}
+
+	return false
+}
+
+// attributeCall returns true if the previous tokens are a token attribute e.g. "List.Item"
+func (f *tavorFormatter) attributeCall() bool {
+	return f.prevIs(scanner.Ident) && f.prev2Is('.')
+}
+
+// functionCall returns true if the previous token is the name of an expression function e.g. "upper"
+func (f *tavorFormatter) functionCall() bool {
+	return f.expression() && f.prevIs(scanner.Ident) && !f.prev2Is('.') && expressions.FunctionExists(f.prev.text)
+}
+
+// advance updates the formatter state after the current token
+func (f *tavorFormatter) advance(cur *formatToken, next *formatToken) {
+	switch cur.tok {
+	case '+', '*', '@':
+		if !f.expression() {
+			f.bound = true
+		}
+	case '(':
+		f.bound = false
+
+		f.contexts = append(f.contexts, formatContext{
+			expression: f.expression() || f.attributeCall(),
+		})
+	case '{':
+		// only statements have a keyword, expressions do not
+		keyword := ""
+		if next != nil && !f.prevIs('$') {
+			keyword = next.text
+		}
+
+		f.contexts = append(f.contexts, formatContext{
+			expression: true,
+			keyword:    keyword,
+		})
+	case ')', '}':
+		f.closedKeyword = ""
+
+		if len(f.contexts) != 0 {
+			if cur.tok == '}' {
+				f.closedKeyword = f.contexts[len(f.contexts)-1].keyword
+			}
+
+			f.contexts = f.contexts[:len(f.contexts)-1]
+		}
+	}
+
+	if !cur.isComment() {
+		f.prev2, f.prev = f.prev, cur
+	}
+}
+
+// formatEqual returns true if both token lists are parsed the same way by the Tavor parser
+func formatEqual(a []formatToken, b []formatToken) bool {
+	na, ca := formatNormalize(a)
+	nb, cb := formatNormalize(b)
+
+	if len(na) != len(nb) || len(ca) != len(cb) {
+		return false
+	}
+
+	for i := range na {
+		if na[i] != nb[i] {
+			return false
+		}
+	}
+	for i := range ca {
+		if ca[i] != cb[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// formatNormalize removes everything from the token list which is not significant to the parser and returns the comments separately
+func formatNormalize(tokens []formatToken) ([]formatToken, []string) {
+	var normalized []formatToken
+	var comments []string
+
+	newLines := 0
+	var last *formatToken
+
+	flush := func() {
+		if newLines == 0 {
+			return
+		}
+
+		// leading new lines are ignored by the parser
+		if last != nil {
+			// more than one new line after a multi line comma ends the definition
+			if last.tok == ',' && newLines > 1 {
+				normalized = append(normalized, formatToken{tok: '\n', text: "\n"})
+			}
+
+			normalized = append(normalized, formatToken{tok: '\n', text: "\n"})
+		}
+
+		newLines = 0
+	}
+
+	for i := range tokens {
+		t := tokens[i]
+
+		switch {
+		case t.isComment():
+			comments = append(comments, t.text)
+		case t.tok == '\n':
+			newLines++
+		default:
+			flush()
+
+			normalized = append(normalized, t)
+			last = &tokens[i]
+		}
+	}
+
+	flush()
+
+	return normalized, comments
+}
@@ -0,0 +1,150 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+)
+
+func TestFormatTavor(t *testing.T) {
+	validateFormat := func(in string, expected string) {
+		out, err := FormatTavor(strings.NewReader(in))
+		Nil(t, err)
+		Equal(t, expected, string(out))
+
+		// formatting must be idempotent
+		again, err := FormatTavor(strings.NewReader(expected))
+		Nil(t, err)
+		Equal(t, expected, string(again))
+	}
+
+	// white spaces and alignment
+	validateFormat(
+		"\n\nSTART   =  A|B\n\n\n\nA = \"a\"   1\nBC =  ( A  B )\n",
+		"START = A | B\n\nA  = \"a\" 1\nBC = (A B)\n",
+	)
+
+	// groups and repeats
+	validateFormat(
+		"START = ?( 1 ) +( 2 ) + 2 , 4 ( 3 ) +,4( 4 ) *( 5 ) @( 6 | 7 )\n",
+		"START = ?(1) +(2) +2,4(3) +,4(4) *(5) @(6 | 7)\n",
+	)
+
+	// subset permutation groups
+	validateFormat(
+		"START = @ 1 , 2 ( 6 | 7 ) @2( 8 | 9 )\n",
+		"START = @1,2(6 | 7) @2(8 | 9)\n",
+	)
+
+	// character classes keep their white spaces
+	validateFormat(
+		"START =   [ a-z\\n]  +( [\\t ] )\n",
+		"START = [ a-z\\n] +([\\t ])\n",
+	)
+
+	// multi line definitions and comments
+	validateFormat(
+		"/* head\ncomment */\nSTART = \"a\",   // first\n  \"b\" /* inner */ \"c\",\n\t\t\"d\" // last\n// end\n",
+		"/* head\ncomment */\nSTART = \"a\", // first\n        \"b\" /* inner */ \"c\",\n        \"d\" // last\n// end\n",
+	)
+
+	// typed tokens
+	validateFormat(
+		"$Number   Int =  from: 1,\n to:   -10\n\n$Id Sequence\nSTART = Number $Id.Next\n",
+		"$Number Int = from: 1,\n              to:   -10\n\n$Id Sequence\nSTART = Number $Id.Next\n",
+	)
+
+	// attributes, variables, expressions and statements
+	validateFormat(
+		"START = $Id.Next< id > ${ Id.Existing not in ( id ) } ${1+2} $List.Item( 1 ) Number< = a >\n",
+		"START = $Id.Next<id> ${Id.Existing not in (id)} ${1 + 2} $List.Item(1) Number<=a>\n",
+	)
+	validateFormat(
+		"START = A<var> {if var.Value==1} \"A\" {else if var.Value == 2}\"B\" {else} \"C\" {endif}  \"D\"\n",
+		"START = A<var> {if var.Value == 1}\"A\"{else if var.Value == 2}\"B\"{else}\"C\"{endif} \"D\"\n",
+	)
+	validateFormat(
+		"START = A<var> {switch var.Value} {case 1,2} \"A\" {default}\"B\" {endswitch}  \"D\"\n",
+		"START = A<var> {switch var.Value}{case 1, 2}\"A\"{default}\"B\"{endswitch} \"D\"\n",
+	)
+	validateFormat(
+		"START = ${upper( A.Value)} ${concat( \"a\" ,lower(B))}\n",
+		"START = ${upper(A.Value)} ${concat(\"a\", lower(B))}\n",
+	)
+	validateFormat(
+		"START = bits(4: A,4 : B) bits (1)\n",
+		"START = bits(4: A, 4: B) bits (1)\n",
+	)
+	validateFormat(
+		"START = A<a> B<b> {assert a.Value+b.Value<=3}{if a.Value!=b.Value}\"x\"{endif} {assert a.Value >=1}\n",
+		"START = A<a> B<b> {assert a.Value + b.Value <= 3} {if a.Value != b.Value}\"x\"{endif} {assert a.Value >= 1}\n",
+	)
+	validateFormat(
+		"START = + 2 , 3 unique ( A )  *unique(B)\n",
+		"START = +2,3unique(A) *unique(B)\n",
+	)
+	validateFormat(
+		"START = [^\"//]  [a-z-[aeiou] ]\n",
+		"START = [^\"//] [a-z-[aeiou] ]\n",
+	)
+	validateFormat(
+		"Const Rate =  - 2.5\n\nSTART =  0x1F  `a\\n`   <<EOT\n  a   b \n   EOT  \"\\u{21}\"\n",
+		"Const Rate = -2.5\n\nSTART = 0x1F `a\\n` <<EOT\n  a   b \n   EOT \"\\u{21}\"\n",
+	)
+	validateFormat(
+		"START = tlv(type:UInt8(7) ,length: UInt16be,value: A) UInt8 (1)\n",
+		"START = tlv(type: UInt8(7), length: UInt16be, value: A) UInt8 (1)\n",
+	)
+	validateFormat(
+		"START = ${Pairs path from(2) over(e.Item( 0 )) connect by (e.Item(1)) without (0)}\n",
+		"START = ${Pairs path from (2) over (e.Item(0)) connect by (e.Item(1)) without (0)}\n",
+	)
+
+	// a missing new line at the end is not added since it is an error
+	validateFormat(
+		"START  = 1",
+		"START = 1",
+	)
+
+	// an empty line after a multi line comma is not removed since it is an error
+	validateFormat(
+		"START = 1,\n\n2\n",
+		"START = 1,\n        \n2\n",
+	)
+}
+
+func TestFormatTavorChangedMeaning(t *testing.T) {
+	// the parser looks at the character right after a function name and a comparison operator
+	for _, in := range []string{
+		"START = ${upper (A.Value)}\n",
+		"START = ${A .Value}\n",
+		"START = A<a> {assert a.Value > = 1}\n",
+	} {
+		out, err := FormatTavor(strings.NewReader(in))
+		NotNil(t, err, in)
+		Nil(t, out)
+	}
+}
+
+func TestFormatTavorKeepsLanguage(t *testing.T) {
+	in := `
+		Number  =  +( [0-9] )
+		List = Number *( "," Number )
+		$Id Sequence = start: 2,
+			step: 3
+
+		START = "[" ?( List ) "]" | "null",
+		  $Id.Next
+	`
+
+	out, err := FormatTavor(strings.NewReader(in))
+	Nil(t, err)
+
+	tokIn, err := ParseTavor(strings.NewReader(in))
+	Nil(t, err)
+	tokOut, err := ParseTavor(strings.NewReader(string(out)))
+	Nil(t, err)
+
+	Equal(t, tokIn, tokOut)
+}
@@ -0,0 +1,561 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/scanner"
+
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/conditions"
+	"github.com/zimmski/tavor/token/constraints"
+	"github.com/zimmski/tavor/token/lists"
+	"github.com/zimmski/tavor/token/primitives"
+	"github.com/zimmski/tavor/token/variables"
+)
+
+// LintSeverity the severity of a lint finding
+type LintSeverity int
+
+const (
+	// LintError the finding makes the format file unusable for at least one command
+	LintError LintSeverity = iota
+	// LintWarning the finding is most likely a mistake in the format file
+	LintWarning
+	// LintInfo the finding is a hint which does not need to be a mistake
+	LintInfo
+)
+
+// String returns the name of the severity
+func (s LintSeverity) String() string {
+	switch s {
+	case LintError:
+		return "error"
+	case LintWarning:
+		return "warning"
+	case LintInfo:
+		return "info"
+	}
+
+	return fmt.Sprintf("LintSeverity(%d)", s)
+}
+
+// LintFindingType the lint finding type
+type LintFindingType int
+
+const (
+	// LintParseError the format file could not be parsed
+	LintParseError LintFindingType = iota
+	// LintUnreachableDefinition the token definition cannot be reached from the START token or an exported token definition
+	LintUnreachableDefinition
+	// LintNeverChosenAlternative the alternative can never be chosen while parsing
+	LintNeverChosenAlternative
+	// LintAmbiguousAlternation the result of parsing an alternation depends on the order of its alternatives
+	LintAmbiguousAlternation
+	// LintLeftRecursion the token definition is left-recursive which cannot be handled by the internal parser
+	LintLeftRecursion
+	// LintZeroLengthRepeat the body of a repeat can match the empty string
+	LintZeroLengthRepeat
+)
+
+// String returns the name of the finding type
+func (t LintFindingType) String() string {
+	switch t {
+	case LintParseError:
+		return "parse-error"
+	case LintUnreachableDefinition:
+		return "unreachable-definition"
+	case LintNeverChosenAlternative:
+		return "never-chosen-alternative"
+	case LintAmbiguousAlternation:
+		return "ambiguous-alternation"
+	case LintLeftRecursion:
+		return "left-recursion"
+	case LintZeroLengthRepeat:
+		return "zero-length-repeat"
+	}
+
+	return fmt.Sprintf("LintFindingType(%d)", t)
+}
+
+// LintFinding holds a finding of the format file linter
+type LintFinding struct {
+	Message  string
+	Type     LintFindingType
+	Severity LintSeverity
+	Position scanner.Position
+}
+
+// String returns a human readable representation of the finding
+func (f *LintFinding) String() string {
+	return fmt.Sprintf("L:%d, C:%d - %s: %s", f.Position.Line, f.Position.Column, f.Severity, f.Message)
+}
+
+type tavorLinter struct {
+	p *tavorParser
+
+	findings []LintFinding
+
+	nullable map[string]bool
+	literals map[string]*literalPrefix
+}
+
+type literalPrefix struct {
+	prefix   string
+	complete bool
+}
+
+// LintTavor reads and parses a Tavor formatted input and returns all findings of the static analysis sorted by their position.
+// Every syntax or semantic error of the format file is returned as a finding with the LintError severity. The error return argument is not nil if the input cannot be read.
+func LintTavor(src io.Reader) ([]LintFinding, error) {
+	p := newTavorParser()
+
+	if err := p.parse(src); err != nil {
+		var errs token.ParserErrors
+
+		switch e := err.(type) {
+		case *token.ParserError:
+			errs = token.ParserErrors{e}
+		case token.ParserErrors:
+			errs = e
+		default:
+			return nil, err
+		}
+
+		findings := make([]LintFinding, len(errs))
+		for i, perr := range errs {
+			findings[i] = LintFinding{
+				Message:  perr.Message,
+				Type:     LintParseError,
+				Severity: LintError,
+				Position: perr.Position,
+			}
+		}
+
+		return findings, nil
+	}
+
+	l := &tavorLinter{
+		p: p,
+
+		nullable: make(map[string]bool),
+		literals: make(map[string]*literalPrefix),
+	}
+
+	l.computeNullable()
+
+	l.checkUnreachableDefinitions()
+	l.checkLeftRecursion()
+
+	for _, name := range l.definitionNames() {
+		def := p.definitions[name]
+
+		l.checkTokens(def.token, def.position)
+	}
+
+	sort.Stable(lintFindingsByPosition(l.findings))
+
+	return l.findings, nil
+}
+
+type lintFindingsByPosition []LintFinding
+
+func (f lintFindingsByPosition) Len() int      { return len(f) }
+func (f lintFindingsByPosition) Swap(i, j int) { f[i], f[j] = f[j], f[i] }
+func (f lintFindingsByPosition) Less(i, j int) bool {
+	if f[i].Position.Line != f[j].Position.Line {
+		return f[i].Position.Line < f[j].Position.Line
+	}
+
+	return f[i].Position.Column < f[j].Position.Column
+}
+
+func (l *tavorLinter) add(typ LintFindingType, severity LintSeverity, position scanner.Position, format string, args ...interface{}) {
+	l.findings = append(l.findings, LintFinding{
+		Message:  fmt.Sprintf(format, args...),
+		Type:     typ,
+		Severity: severity,
+		Position: position,
+	})
+}
+
+func (l *tavorLinter) definitionNames() []string {
+	names := make([]string, 0, len(l.p.definitions))
+
+	for name := range l.p.definitions {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func (l *tavorLinter) position(tok token.Token, fallback scanner.Position) scanner.Position {
+	if pos, ok := l.p.positions[tok]; ok {
+		return pos
+	}
+
+	return fallback
+}
+
+// children returns the internal children of a token without following references to other token definitions
+func (l *tavorLinter) children(tok token.Token) []token.Token {
+	if _, ok := l.p.references[tok]; ok {
+		return nil
+	}
+
+	switch t := tok.(type) {
+	case *conditions.If:
+		var toks []token.Token
+
+		for i := range t.Pairs {
+			toks = append(toks, t.Pairs[i].Body)
+		}
+
+		return toks
+	case *conditions.Switch:
+		var toks []token.Token
+
+		for i := range t.Cases {
+			toks = append(toks, t.Cases[i].Body)
+		}
+
+		return toks
+	case token.ForwardToken:
+		if c := t.InternalGet(); c != nil {
+			return []token.Token{c}
+		}
+	case token.ListToken:
+		var toks []token.Token
+
+		for i := 0; i < t.InternalLen(); i++ {
+			c, _ := t.InternalGet(i)
+			if c != nil {
+				toks = append(toks, c)
+			}
+		}
+
+		return toks
+	}
+
+	return nil
+}
+
+func (l *tavorLinter) computeNullable() {
+	// the nullable state of definitions has to be computed as a fixed point since definitions can be recursive
+	for changed := true; changed; {
+		changed = false
+
+		for name, def := range l.p.definitions {
+			if l.nullable[name] {
+				continue
+			}
+
+			if l.isNullable(def.token) {
+				l.nullable[name] = true
+				changed = true
+			}
+		}
+	}
+}
+
+// isNullable returns true if the token can match the empty string
+func (l *tavorLinter) isNullable(tok token.Token) bool {
+	if name, ok := l.p.references[tok]; ok {
+		return l.nullable[name]
+	}
+
+	switch t := tok.(type) {
+	case *primitives.ConstantString:
+		return t.String() == ""
+	case *constraints.Optional:
+		return true
+	case *lists.Repeat:
+		return t.From() == 0 || l.isNullable(l.children(t)[0])
+	case *lists.One:
+		for _, c := range l.children(t) {
+			if l.isNullable(c) {
+				return true
+			}
+		}
+
+		return false
+	case *lists.Once:
+		// enough tokens must be nullable to fill the minimum of chosen tokens
+		nullable := 0
+		for _, c := range l.children(t) {
+			if l.isNullable(c) {
+				nullable++
+			}
+		}
+
+		return nullable >= t.From()
+	case *lists.Concatenation:
+		for _, c := range l.children(t) {
+			if !l.isNullable(c) {
+				return false
+			}
+		}
+
+		return true
+	case *primitives.Scope, *primitives.Pointer, *variables.Variable, *variables.VariableSave:
+		children := l.children(t)
+
+		return len(children) == 1 && l.isNullable(children[0])
+	}
+
+	return false
+}
+
+// literal returns the constant prefix of the token and if the prefix is the only string the token can match
+func (l *tavorLinter) literal(tok token.Token) (string, bool) {
+	if name, ok := l.p.references[tok]; ok {
+		if lit, ok := l.literals[name]; ok {
+			if lit == nil {
+				// recursive definition
+				return "", false
+			}
+
+			return lit.prefix, lit.complete
+		}
+
+		def, ok := l.p.definitions[name]
+		if !ok {
+			return "", false
+		}
+
+		l.literals[name] = nil
+
+		prefix, complete := l.literal(def.token)
+
+		l.literals[name] = &literalPrefix{
+			prefix:   prefix,
+			complete: complete,
+		}
+
+		return prefix, complete
+	}
+
+	switch t := tok.(type) {
+	case *primitives.ConstantString, *primitives.ConstantInt:
+		return t.String(), true
+	case *lists.Concatenation:
+		var prefix string
+
+		for _, c := range l.children(t) {
+			s, complete := l.literal(c)
+
+			prefix += s
+
+			if !complete {
+				return prefix, false
+			}
+		}
+
+		return prefix, true
+	case *primitives.Scope, *primitives.Pointer, *variables.Variable:
+		children := l.children(t)
+		if len(children) == 1 {
+			return l.literal(children[0])
+		}
+	}
+
+	return "", false
+}
+
+// first returns the names of all token definitions which can be parsed first by the given token
+func (l *tavorLinter) first(tok token.Token, names map[string]struct{}) {
+	if name, ok := l.p.references[tok]; ok {
+		names[name] = struct{}{}
+
+		return
+	}
+
+	switch t := tok.(type) {
+	case *lists.Concatenation:
+		for _, c := range l.children(t) {
+			l.first(c, names)
+
+			if !l.isNullable(c) {
+				break
+			}
+		}
+	default:
+		for _, c := range l.children(t) {
+			l.first(c, names)
+		}
+	}
+}
+
+func (l *tavorLinter) checkUnreachableDefinitions() {
+	calledFrom := make(map[string][]string)
+
+	for name, calls := range l.p.called {
+		for _, c := range calls {
+			calledFrom[c.from] = append(calledFrom[c.from], name)
+		}
+	}
+	for name, uses := range l.p.used {
+		for _, use := range uses {
+			if use.definitionName != "" {
+				calledFrom[use.definitionName] = append(calledFrom[use.definitionName], name)
+			}
+		}
+	}
+
+	// every entry point of the format is a root
+	roots := l.p.entryPoints()
+
+	reachable := make(map[string]struct{}, len(roots))
+	for _, name := range roots {
+		reachable[name] = struct{}{}
+	}
+	queue := append([]string{}, roots...)
+
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+
+		for _, c := range calledFrom[name] {
+			if _, ok := reachable[c]; !ok {
+				reachable[c] = struct{}{}
+				queue = append(queue, c)
+			}
+		}
+	}
+
+	for _, name := range l.definitionNames() {
+		// constants can be used in repeats and typed token arguments which are not references
+		if _, ok := l.p.constants[name]; ok {
+			continue
+		}
+
+		if _, ok := reachable[name]; !ok {
+			l.add(LintUnreachableDefinition, LintWarning, l.p.definitions[name].position, "token %q cannot be reached from %s", name, strings.Join(roots, " or "))
+		}
+	}
+}
+
+func (l *tavorLinter) checkLeftRecursion() {
+	firsts := make(map[string][]string)
+
+	for name, def := range l.p.definitions {
+		names := make(map[string]struct{})
+
+		l.first(def.token, names)
+
+		for n := range names {
+			firsts[name] = append(firsts[name], n)
+		}
+
+		sort.Strings(firsts[name])
+	}
+
+	reported := make(map[string]struct{})
+
+	for _, name := range l.definitionNames() {
+		if _, ok := reported[name]; ok {
+			continue
+		}
+
+		path := l.leftRecursionPath(name, firsts)
+		if path == nil {
+			continue
+		}
+
+		for _, n := range path {
+			reported[n] = struct{}{}
+		}
+
+		l.add(LintLeftRecursion, LintError, l.p.definitions[name].position, "token %q is left-recursive (%s)", name, strings.Join(path, " -> "))
+	}
+}
+
+// leftRecursionPath returns the shortest path of definitions through which the given definition references itself on the leftmost position or nil if there is no such path
+func (l *tavorLinter) leftRecursionPath(name string, firsts map[string][]string) []string {
+	previous := make(map[string]string)
+	queue := []string{name}
+
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+
+		for _, c := range firsts[n] {
+			if c == name {
+				path := []string{name}
+
+				for i := n; i != name; i = previous[i] {
+					path = append([]string{i}, path...)
+				}
+
+				return append([]string{name}, path...)
+			}
+
+			if _, ok := previous[c]; !ok {
+				previous[c] = n
+				queue = append(queue, c)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (l *tavorLinter) checkTokens(tok token.Token, position scanner.Position) {
+	if _, ok := l.p.references[tok]; ok {
+		return
+	}
+
+	position = l.position(tok, position)
+
+	switch t := tok.(type) {
+	case *lists.One:
+		l.checkAlternation(t, position)
+	case *lists.Repeat:
+		if l.isNullable(l.children(t)[0]) {
+			l.add(LintZeroLengthRepeat, LintWarning, position, "repeat body can match the empty string")
+		}
+	}
+
+	for _, c := range l.children(tok) {
+		l.checkTokens(c, position)
+	}
+}
+
+func (l *tavorLinter) checkAlternation(or *lists.One, position scanner.Position) {
+	alternatives := l.children(or)
+
+ALTERNATIVES:
+	for j := 1; j < len(alternatives); j++ {
+		jPosition := l.position(alternatives[j], position)
+		jLiteral, jComplete := l.literal(alternatives[j])
+
+		for i := 0; i < j; i++ {
+			if l.isNullable(alternatives[i]) {
+				l.add(LintNeverChosenAlternative, LintWarning, jPosition, "alternative %d can never be chosen while parsing since alternative %d can match the empty string", j+1, i+1)
+
+				continue ALTERNATIVES
+			}
+
+			iLiteral, iComplete := l.literal(alternatives[i])
+			if !iComplete || iLiteral == "" {
+				continue
+			}
+
+			if jComplete && iLiteral == jLiteral {
+				l.add(LintNeverChosenAlternative, LintWarning, jPosition, "alternative %d can never be chosen while parsing since it is equal to alternative %d %s", j+1, i+1, strconv.Quote(iLiteral))
+
+				continue ALTERNATIVES
+			}
+
+			if strings.HasPrefix(jLiteral, iLiteral) {
+				l.add(LintAmbiguousAlternation, LintWarning, jPosition, "alternative %d is ambiguous since alternative %d %s is a prefix of it and is parsed first", j+1, i+1, strconv.Quote(iLiteral))
+
+				continue ALTERNATIVES
+			}
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+)
+
+func lintFindings(t *testing.T, format string) []LintFinding {
+	findings, err := LintTavor(strings.NewReader(format))
+	Nil(t, err)
+
+	return findings
+}
+
+func TestLintTavorClean(t *testing.T) {
+	findings := lintFindings(t, `
+		Number = +([0-9])
+		List = Number *("," Number)
+
+		START = "[" ?(List) "]" | "null"
+	`)
+	Equal(t, 0, len(findings))
+}
+
+func TestLintTavorParseError(t *testing.T) {
+	findings := lintFindings(t, "START = 123\nNumber = 123\n")
+	Equal(t, 1, len(findings))
+	Equal(t, LintParseError, findings[0].Type)
+	Equal(t, LintError, findings[0].Severity)
+	Equal(t, 2, findings[0].Position.Line)
+}
+
+func TestLintTavorUnreachableDefinitions(t *testing.T) {
+	findings := lintFindings(t, "START = 1\nA = 2 ?(B)\nB = 3 ?(A)\n")
+	Equal(t, 2, len(findings))
+
+	Equal(t, LintUnreachableDefinition, findings[0].Type)
+	Equal(t, LintWarning, findings[0].Severity)
+	Equal(t, 2, findings[0].Position.Line)
+	Equal(t, 1, findings[0].Position.Column)
+	Equal(t, `token "A" cannot be reached from START`, findings[0].Message)
+
+	Equal(t, LintUnreachableDefinition, findings[1].Type)
+	Equal(t, 3, findings[1].Position.Line)
+
+	// exported token definitions are roots
+	findings = lintFindings(t, "START = 1\nExport A = 2 ?(B)\nB = 3 ?(A)\nC = 4 ?(D)\nD = 5 ?(C)\n")
+	Equal(t, 2, len(findings))
+	Equal(t, `token "C" cannot be reached from START or A`, findings[0].Message)
+
+	// constants do not need to be referenced
+	findings = lintFindings(t, "Const N = 2\nConst Unused = 1\nSTART = +N(1)\n")
+	Equal(t, 0, len(findings))
+}
+
+func TestLintTavorAlternations(t *testing.T) {
+	// duplicated alternative
+	findings := lintFindings(t, "START = \"a\" | \"b\" | \"a\"\n")
+	Equal(t, 1, len(findings))
+	Equal(t, LintNeverChosenAlternative, findings[0].Type)
+	Equal(t, 1, findings[0].Position.Line)
+	Equal(t, 21, findings[0].Position.Column)
+
+	// empty alternative in front
+	findings = lintFindings(t, "A = ?(1)\nSTART = A | 2\n")
+	Equal(t, 1, len(findings))
+	Equal(t, LintNeverChosenAlternative, findings[0].Type)
+	Equal(t, 2, findings[0].Position.Line)
+	Equal(t, 13, findings[0].Position.Column)
+
+	// prefix in front
+	findings = lintFindings(t, "A = \"ab\" [0-9]\nSTART = \"a\" | A\n")
+	Equal(t, 1, len(findings))
+	Equal(t, LintAmbiguousAlternation, findings[0].Type)
+	Equal(t, LintWarning, findings[0].Severity)
+	Equal(t, 2, findings[0].Position.Line)
+	Equal(t, 15, findings[0].Position.Column)
+
+	// prefix at the end is fine
+	findings = lintFindings(t, "START = \"ab\" | \"a\"\n")
+	Equal(t, 0, len(findings))
+}
+
+func TestLintTavorLeftRecursion(t *testing.T) {
+	findings := lintFindings(t, "A = A 1 | 2\nSTART = A\n")
+	Equal(t, 1, len(findings))
+	Equal(t, LintLeftRecursion, findings[0].Type)
+	Equal(t, LintError, findings[0].Severity)
+	Equal(t, `token "A" is left-recursive (A -> A)`, findings[0].Message)
+
+	findings = lintFindings(t, "A = ?(\"x\") B 1 | 2\nB = A 3\nSTART = A\n")
+	Equal(t, 1, len(findings))
+	Equal(t, LintLeftRecursion, findings[0].Type)
+	Equal(t, `token "A" is left-recursive (A -> B -> A)`, findings[0].Message)
+
+	// recursion on the right is fine
+	findings = lintFindings(t, "A = 1 ?(A)\nSTART = A\n")
+	Equal(t, 0, len(findings))
+}
+
+func TestLintTavorZeroLengthRepeats(t *testing.T) {
+	findings := lintFindings(t, "A = ?(1)\nSTART = +(A) 2\n")
+	Equal(t, 1, len(findings))
+	Equal(t, LintZeroLengthRepeat, findings[0].Type)
+	Equal(t, 2, findings[0].Position.Line)
+	Equal(t, 9, findings[0].Position.Column)
+}
@@ -0,0 +1,196 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/scanner"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/zimmski/tavor/token"
+)
+
+// unquoteString returns the value of an interpreted or raw string literal.
+// In addition to the escapes of Go, interpreted string literals support Unicode escapes of the form \u{X} with one to six hexadecimal digits.
+func unquoteString(s string) (string, error) {
+	if len(s) == 0 || s[0] != '"' || !strings.Contains(s, `\u{`) {
+		return strconv.Unquote(s)
+	}
+
+	// rewrite all \u{X} escapes to \UXXXXXXXX escapes which are known by Go
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+
+			continue
+		}
+
+		if !strings.HasPrefix(s[i:], `\u{`) {
+			b.WriteString(s[i : i+2])
+			i++
+
+			continue
+		}
+
+		end := strings.IndexByte(s[i:], '}')
+		if end == -1 {
+			return "", fmt.Errorf("Unicode escape is not terminated")
+		}
+
+		hex := s[i+3 : i+end]
+
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) > 6 || !utf8.ValidRune(rune(v)) {
+			return "", fmt.Errorf("invalid Unicode code point %q", hex)
+		}
+
+		fmt.Fprintf(&b, `\U%08X`, v)
+
+		i += end
+	}
+
+	return strconv.Unquote(b.String())
+}
+
+// isHeredocRune returns true if the given rune can be part of the delimiter of a heredoc string
+func isHeredocRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// scanHeredoc reads a heredoc string after its introducing "<<" characters.
+// The delimiter must be followed by a new line and the string ends with the first line which holds only optional indentation and the delimiter. The indentation of the closing delimiter is removed from every line of the string and every line including the last one ends with a new line. The raw text of the heredoc without the introducing characters is returned as well as its value. The error return argument is not nil if the heredoc is not valid.
+func scanHeredoc(s *scanner.Scanner) (string, string, error) {
+	var raw []rune
+
+	next := func() rune {
+		r := s.Next()
+		if r != scanner.EOF {
+			raw = append(raw, r)
+		}
+
+		return r
+	}
+
+	var delimiter []rune
+	for isHeredocRune(s.Peek()) {
+		delimiter = append(delimiter, next())
+	}
+
+	if len(delimiter) == 0 {
+		return string(raw), "", fmt.Errorf("heredoc has no delimiter")
+	}
+
+	for s.Peek() == ' ' || s.Peek() == '\t' || s.Peek() == '\r' {
+		next()
+	}
+
+	if r := next(); r != '\n' {
+		return string(raw), "", fmt.Errorf("heredoc delimiter %q must be followed by a new line", string(delimiter))
+	}
+
+	var lines []string
+
+	for {
+		var indentation []rune
+		for s.Peek() == ' ' || s.Peek() == '\t' {
+			indentation = append(indentation, next())
+		}
+
+		var line []rune
+
+		for {
+			if string(line) == string(delimiter) && !isHeredocRune(s.Peek()) {
+				value := ""
+				for _, l := range lines {
+					value += strings.TrimPrefix(l, string(indentation)) + "\n"
+				}
+
+				return string(raw), value, nil
+			}
+
+			r := next()
+			if r == scanner.EOF {
+				return string(raw), "", fmt.Errorf("heredoc is not terminated by %q", string(delimiter))
+			} else if r == '\n' {
+				break
+			}
+
+			line = append(line, r)
+		}
+
+		lines = append(lines, strings.TrimSuffix(string(indentation)+string(line), "\r"))
+	}
+}
+
+// intLiteral returns the value of the current decimal, hexadecimal, octal or binary integer token
+func (p *tavorParser) intLiteral() (int, error) {
+	v, err := strconv.ParseInt(p.scan.TokenText(), 0, 0)
+	if err != nil {
+		return 0, &token.ParserError{
+			Message:  fmt.Sprintf("invalid integer %s", p.scan.TokenText()),
+			Type:     token.ParseErrorInvalidLiteral,
+			Position: p.scan.Pos(),
+		}
+	}
+
+	return int(v), nil
+}
+
+// floatLiteral returns the value of the current decimal or hexadecimal floating point token
+func (p *tavorParser) floatLiteral() (float64, error) {
+	v, err := strconv.ParseFloat(strings.Replace(p.scan.TokenText(), "_", "", -1), 64)
+	if err != nil {
+		return 0, &token.ParserError{
+			Message:  fmt.Sprintf("invalid floating point number %s", p.scan.TokenText()),
+			Type:     token.ParseErrorInvalidLiteral,
+			Position: p.scan.Pos(),
+		}
+	}
+
+	return v, nil
+}
+
+// stringLiteral returns the value of the current interpreted or raw string token
+func (p *tavorParser) stringLiteral() (string, error) {
+	s := p.scan.TokenText()
+
+	if len(s) < 2 || s[len(s)-1] != s[0] {
+		return "", &token.ParserError{
+			Message:  "string is not terminated",
+			Type:     token.ParseErrorNonTerminatedString,
+			Position: p.scan.Pos(),
+		}
+	}
+
+	v, err := unquoteString(s)
+	if err != nil {
+		return "", &token.ParserError{
+			Message:  fmt.Sprintf("invalid string %s: %v", s, err),
+			Type:     token.ParseErrorInvalidLiteral,
+			Position: p.scan.Pos(),
+		}
+	}
+
+	return v, nil
+}
+
+// heredocLiteral returns the value of the heredoc string which starts at the current "<" token
+func (p *tavorParser) heredocLiteral() (string, error) {
+	position := p.scan.Pos()
+
+	p.scan.Next() // second "<"
+
+	_, v, err := scanHeredoc(&p.scan)
+	if err != nil {
+		return "", &token.ParserError{
+			Message:  err.Error(),
+			Type:     token.ParseErrorInvalidLiteral,
+			Position: position,
+		}
+	}
+
+	return v, nil
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"reflect"
+	"sort"
 	"strconv"
 	"text/scanner"
 
@@ -18,9 +19,11 @@ import (
 	"github.com/zimmski/tavor/token/conditions"
 	"github.com/zimmski/tavor/token/constraints"
 	"github.com/zimmski/tavor/token/expressions"
+	"github.com/zimmski/tavor/token/graphs"
 	"github.com/zimmski/tavor/token/lists"
 	"github.com/zimmski/tavor/token/primitives"
 	"github.com/zimmski/tavor/token/sequences"
+	"github.com/zimmski/tavor/token/symbols"
 	"github.com/zimmski/tavor/token/variables"
 )
 
@@ -53,7 +56,8 @@ type call struct {
 type tavorParser struct {
 	scan scanner.Scanner
 
-	err string
+	err  string
+	errs []*token.ParserError
 
 	earlyUse       map[string][]tokenUsage
 	lookup         map[string]tokenUsage
@@ -64,6 +68,23 @@ type tavorParser struct {
 	called map[string][]call
 
 	forwardAttributeUsage []attributeForwardUsage
+
+	// graphItems holds all graphs which are used by their token attributes
+	graphItems map[*graphs.Graph]struct{}
+
+	definitions map[string]tokenUsage
+	positions   map[token.Token]scanner.Position
+	references  map[token.Token]string
+
+	constants map[string]token.Token
+	defines   map[string]string
+
+	// exports holds the names of all exported token definitions which are entry points of the format besides START
+	exports []string
+	// start is the name of the entry point which is requested by the caller
+	start string
+
+	tests []TavorTest
 }
 
 func (p *tavorParser) expectRune(expect rune, got rune) (rune, error) {
@@ -133,23 +154,32 @@ func (p *tavorParser) parseGlobalScope(variableScope *token.VariableScope) error
 		case scanner.Ident:
 			c, err = p.parseTokenDefinition(variableScope)
 			if err != nil {
-				return err
+				if c, err = p.resynchronize(err); err != nil {
+					return err
+				}
 			}
 
 			continue
 		case '$':
 			c, err = p.parseTypedTokenDefinition(variableScope)
 			if err != nil {
-				return err
+				if c, err = p.resynchronize(err); err != nil {
+					return err
+				}
 			}
 
 			continue
 		default:
-			return &token.ParserError{
+			c, err = p.resynchronize(&token.ParserError{
 				Message:  fmt.Sprintf("token names have to start with a letter and not with %s", scanner.TokenString(c)),
 				Type:     token.ParseErrorInvalidTokenName,
 				Position: p.scan.Pos(),
+			})
+			if err != nil {
+				return err
 			}
+
+			continue
 		}
 
 		c = p.scan.Scan()
@@ -159,6 +189,89 @@ func (p *tavorParser) parseGlobalScope(variableScope *token.VariableScope) error
 	return nil
 }
 
+// addError records a parser error so that parsing can continue. Every other error is returned since it cannot be recovered from.
+func (p *tavorParser) addError(err error) error {
+	perr, ok := err.(*token.ParserError)
+	if !ok {
+		return err
+	}
+
+	p.errs = append(p.errs, perr)
+
+	return nil
+}
+
+// resynchronize records the error of an erroneous definition and skips all its remaining tokens.
+// The first token of the next definition is returned.
+func (p *tavorParser) resynchronize(err error) (rune, error) {
+	if err := p.addError(err); err != nil {
+		return zeroRune, err
+	}
+
+	var c, last rune
+	if p.scan.TokenText() == "\n" {
+		c = '\n'
+	}
+
+	for {
+		for c != '\n' && c != scanner.EOF {
+			last = c
+			c = p.scan.Scan()
+		}
+
+		if c == scanner.EOF {
+			return c, nil
+		}
+
+		c = p.scan.Scan()
+
+		// a comma at the end of the line continues the erroneous definition
+		if last == ',' {
+			last = zeroRune
+
+			continue
+		}
+
+		for c == '\n' {
+			c = p.scan.Scan()
+		}
+
+		// lines which cannot start a definition still belong to the erroneous definition
+		if c == scanner.Ident || c == '$' || c == scanner.EOF {
+			log.Debugf("resynchronized at %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+
+			return c, nil
+		}
+	}
+}
+
+// error returns nil if no parser error was recorded, the error itself if there is only one, or all errors sorted by their positions.
+func (p *tavorParser) error() error {
+	switch len(p.errs) {
+	case 0:
+		return nil
+	case 1:
+		return p.errs[0]
+	}
+
+	errs := make(token.ParserErrors, len(p.errs))
+	copy(errs, p.errs)
+
+	sort.Stable(errs)
+
+	return errs
+}
+
+// setSource sets the origin of the token if it has none, since tokens of other definitions keep their origin.
+func (p *tavorParser) setSource(definitionName string, tok token.Token, position scanner.Position) {
+	if _, ok := token.GetSource(tok); !ok {
+		token.SetSource(tok, token.Source{
+			Name:     definitionName,
+			Position: position,
+		})
+	}
+}
+
 func (p *tavorParser) getToken(definitionName string, name string, variableScope *token.VariableScope) token.Token {
 	if tok := variableScope.Get(name); tok != nil {
 		if v, ok := tok.(token.VariableToken); ok {
@@ -251,6 +364,7 @@ func (p *tavorParser) getToken(definitionName string, name string, variableScope
 			tok = ntok
 		} else {
 			ntok := tok.Clone()
+			token.CopySources(tok, ntok)
 
 			log.Debugf("token %s (%p)%#v was already used once. Cloned as (%p)%#v", name, tok, tok, ntok, ntok)
 
@@ -275,6 +389,7 @@ func (p *tavorParser) getToken(definitionName string, name string, variableScope
 	}
 
 	p.lookupUsage[tok] = struct{}{}
+	p.references[tok] = name
 
 	p.addCall(definitionName, variableScope, name)
 
@@ -298,36 +413,69 @@ func (p *tavorParser) parseTerm(definitionName string, c rune, variableScope *to
 	var err error
 	var tokens []token.Token
 
+	var position scanner.Position
+
 	addToken := func(tok token.Token) {
+		p.setSource(definitionName, tok, position)
+
 		tokens = append(tokens, tok)
 	}
 
 OUT:
 	for {
+		position = p.scan.Position
+
 		switch c {
 		case scanner.Ident:
 			name := p.scan.TokenText()
 
+			if name == "bits" && p.scan.Peek() == '(' {
+				var tok token.Token
+
+				tok, err = p.parseBits(definitionName, variableScope)
+				if err != nil {
+					return zeroRune, nil, err
+				}
+
+				addToken(tok)
+
+				break
+			} else if name == "tlv" && p.scan.Peek() == '(' {
+				var tok token.Token
+
+				tok, err = p.parseTLV(definitionName, variableScope)
+				if err != nil {
+					return zeroRune, nil, err
+				}
+
+				addToken(tok)
+
+				break
+			}
+
 			variableScope = variableScope.Push()
 			tok := p.getToken(definitionName, name, variableScope)
 
 			addToken(tok)
 		case scanner.Int:
-			v, _ := strconv.Atoi(p.scan.TokenText())
+			v, err := p.intLiteral()
+			if err != nil {
+				return zeroRune, nil, err
+			}
 
 			addToken(primitives.NewConstantInt(v))
-		case scanner.String:
-			s := p.scan.TokenText()
-
-			if s[len(s)-1] != '"' {
-				return zeroRune, nil, &token.ParserError{
-					Message:  "string is not terminated",
-					Type:     token.ParseErrorNonTerminatedString,
-					Position: p.scan.Pos(),
-				}
+		case scanner.Float:
+			v, err := p.floatLiteral()
+			if err != nil {
+				return zeroRune, nil, err
 			}
 
-			s, _ = strconv.Unquote(s)
+			addToken(primitives.NewConstantFloat(v))
+		case scanner.String, scanner.RawString:
+			s, err := p.stringLiteral()
+			if err != nil {
+				return zeroRune, nil, err
+			}
 
 			if len(s) == 0 {
 				return zeroRune, nil, &token.ParserError{
@@ -401,23 +549,39 @@ OUT:
 			log.Debug("Repeat:")
 			log.IncreaseIndentation()
 
+			repeatPosition := p.scan.Position
 			sym := c
 
 			c = p.scan.Scan()
 			log.Debugf("parseTerm repeat before ( %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
 
 			var from, to token.Token
+			// an explicit upper bound must not be smaller than the lower bound, whereas the default upper bound tavor.MaxRepeat can be
+			explicitTo := false
 
 			if sym == '*' {
 				from, to = primitives.NewConstantInt(0), primitives.NewConstantInt(tavor.MaxRepeat)
 			} else {
 				if c == scanner.Int {
-					iFrom, _ := strconv.Atoi(p.scan.TokenText())
+					iFrom, err := p.intLiteral()
+					if err != nil {
+						return zeroRune, nil, err
+					}
 					from = primitives.NewConstantInt(iFrom)
 
 					c = p.scan.Scan()
 					log.Debugf("parseTerm repeat after from ( %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
 
+					// until there is an explicit "to" we can assume to==from
+					to = from // do not clone here! since really to==from
+				} else if c == scanner.Ident && p.scan.TokenText() != "unique" {
+					from, err = p.getConstantBound(p.scan.TokenText())
+					if err != nil {
+						return zeroRune, nil, err
+					}
+
+					c = p.scan.Scan()
+
 					// until there is an explicit "to" we can assume to==from
 					to = from // do not clone here! since really to==from
 				} else if c == '$' {
@@ -440,11 +604,23 @@ OUT:
 					log.Debugf("parseTerm repeat after , ( %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
 
 					if c == scanner.Int {
-						iTo, _ := strconv.Atoi(p.scan.TokenText())
+						iTo, err := p.intLiteral()
+						if err != nil {
+							return zeroRune, nil, err
+						}
 						to = primitives.NewConstantInt(iTo)
+						explicitTo = true
 
 						c = p.scan.Scan()
 						log.Debugf("parseTerm repeat after to ( %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+					} else if c == scanner.Ident && p.scan.TokenText() != "unique" {
+						to, err = p.getConstantBound(p.scan.TokenText())
+						if err != nil {
+							return zeroRune, nil, err
+						}
+						explicitTo = true
+
+						c = p.scan.Scan()
 					} else if c == '$' {
 						c = p.scan.Scan()
 
@@ -459,12 +635,31 @@ OUT:
 				}
 			}
 
+			if iFrom, ok := from.(*primitives.ConstantInt); ok && explicitTo {
+				if iTo, ok := to.(*primitives.ConstantInt); ok && iFrom.Value() > iTo.Value() {
+					return zeroRune, nil, &token.ParserError{
+						Message:  fmt.Sprintf("lower bound %d of repeat is greater than its upper bound %d", iFrom.Value(), iTo.Value()),
+						Type:     token.ParseErrorInvalidRepeatBounds,
+						Position: repeatPosition,
+					}
+				}
+			}
+
+			unique := false
+
+			if c == scanner.Ident && p.scan.TokenText() == "unique" {
+				unique = true
+
+				c = p.scan.Scan()
+				log.Debugf("parseTerm repeat after unique ( %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+			}
+
 			_, err = p.expectRune('(', c)
 			if err != nil {
 				return zeroRune, nil, err
 			}
 
-			log.Debugf("repeat from %v to %v", from, to)
+			log.Debugf("repeat from %v to %v unique %t", from, to, unique)
 
 			c = p.scan.Scan()
 			log.Debugf("parseTerm repeat after ( %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
@@ -504,9 +699,17 @@ OUT:
 					}
 				}
 
-				addToken(lists.NewRepeatWithTokens(toks[0], from, to))
+				repeat := lists.NewRepeatWithTokens(toks[0], from, to)
+				repeat.SetUnique(unique)
+				p.positions[repeat] = repeatPosition
+
+				addToken(repeat)
 			default:
-				addToken(lists.NewRepeatWithTokens(lists.NewConcatenation(toks...), from, to))
+				repeat := lists.NewRepeatWithTokens(lists.NewConcatenation(toks...), from, to)
+				repeat.SetUnique(unique)
+				p.positions[repeat] = repeatPosition
+
+				addToken(repeat)
 			}
 
 			log.DecreaseIndentation()
@@ -514,7 +717,40 @@ OUT:
 			log.Debug("Once")
 			log.IncreaseIndentation()
 
-			_, err = p.expectScanRune('(')
+			oncePosition := p.scan.Position
+
+			c = p.scan.Scan()
+			log.Debugf("parseTerm once before ( %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+
+			// a range of chosen tokens is optional, without it every token is chosen
+			from, to := -1, -1
+
+			if c == scanner.Int || c == scanner.Ident {
+				from, err = p.parseOnceBound(c)
+				if err != nil {
+					return zeroRune, nil, err
+				}
+
+				// until there is an explicit "to" we can assume to==from
+				to = from
+
+				c = p.scan.Scan()
+				log.Debugf("parseTerm once after from ( %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+
+				if c == ',' {
+					c = p.scan.Scan()
+
+					to, err = p.parseOnceBound(c)
+					if err != nil {
+						return zeroRune, nil, err
+					}
+
+					c = p.scan.Scan()
+					log.Debugf("parseTerm once after to ( %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+				}
+			}
+
+			_, err = p.expectRune('(', c)
 			if err != nil {
 				return zeroRune, nil, err
 			}
@@ -532,8 +768,7 @@ OUT:
 				return zeroRune, nil, err
 			}
 
-			switch len(toks) {
-			case 1:
+			if len(toks) == 1 {
 				if t, ok := toks[0].(*lists.One); ok {
 					le := t.InternalLen()
 					tl := make([]token.Token, le)
@@ -541,12 +776,22 @@ OUT:
 						tl[i], _ = t.InternalGet(i)
 					}
 
-					addToken(lists.NewOnce(tl...))
-				} else {
-					addToken(lists.NewOnce(toks[0]))
+					toks = tl
 				}
-			default:
+			}
+
+			if from == -1 {
 				addToken(lists.NewOnce(toks...))
+			} else {
+				if from > to || to > len(toks) {
+					return zeroRune, nil, &token.ParserError{
+						Message:  fmt.Sprintf("permutation group with %d tokens cannot choose between %d and %d tokens", len(toks), from, to),
+						Type:     token.ParseErrorInvalidPermutationGroup,
+						Position: oncePosition,
+					}
+				}
+
+				addToken(lists.NewOnceWithRange(from, to, toks...))
 			}
 
 			log.DecreaseIndentation()
@@ -583,32 +828,53 @@ OUT:
 			log.Debug("Character class:")
 			log.IncreaseIndentation()
 
-			var pattern bytes.Buffer
-
-			p.scan.Whitespace ^= 1 << ' '
-
-			c = p.scan.Scan()
+			classPosition := p.scan.Position
 
-			for c != ']' && c != '\n' && c != scanner.EOF {
-				if _, err := pattern.WriteString(p.scan.TokenText()); err != nil {
-					panic(err)
-				}
+			pattern, r := scanCharacterClass(&p.scan)
+			if r != ']' {
+				_, err := p.expectRune(']', r)
 
-				c = p.scan.Scan()
+				return zeroRune, nil, err
 			}
 
-			p.scan.Whitespace |= 1 << ' '
+			class, err := primitives.ParseCharacterClass(pattern)
+			if err != nil {
+				perr := err.(*token.ParserError)
 
-			if c != ']' {
-				_, err := p.expectRune(']', c)
+				// the position of the error is relative to the pattern which begins right after the opening bracket
+				perr.Position.Filename = classPosition.Filename
+				perr.Position.Offset += classPosition.Offset + 1
+				perr.Position.Line = classPosition.Line
+				perr.Position.Column += classPosition.Column
 
-				return zeroRune, nil, err
+				return zeroRune, nil, perr
 			}
 
-			addToken(primitives.NewCharacterClass(pattern.String()))
+			addToken(class)
+
+			c = ']'
 
 			log.DecreaseIndentation()
 		case '<':
+			if p.scan.Peek() == '<' {
+				s, err := p.heredocLiteral()
+				if err != nil {
+					return zeroRune, nil, err
+				}
+
+				if len(s) == 0 {
+					return zeroRune, nil, &token.ParserError{
+						Message:  "empty strings are not allowed",
+						Type:     token.ParseErrorEmptyString,
+						Position: p.scan.Pos(),
+					}
+				}
+
+				addToken(primitives.NewConstantString(s))
+
+				break
+			}
+
 			log.Debug("Variable:")
 			log.IncreaseIndentation()
 
@@ -740,7 +1006,12 @@ func (p *tavorParser) parseExpressionTerm(definitionName string, c rune, variabl
 				return zeroRune, nil, err
 			}
 		default:
-			if p.scan.Peek() == '.' {
+			if p.scan.Peek() == '(' {
+				c, tok, err = p.parseExpressionFunction(definitionName, variableScope)
+				if err != nil {
+					return zeroRune, nil, err
+				}
+			} else if p.scan.Peek() == '.' {
 				c, tok, err = p.parseTokenAttribute(definitionName, c, variableScope)
 				if err != nil {
 					return zeroRune, nil, err
@@ -755,10 +1026,31 @@ func (p *tavorParser) parseExpressionTerm(definitionName string, c rune, variabl
 			}
 		}
 	case scanner.Int:
-		v, _ := strconv.Atoi(p.scan.TokenText())
+		v, err := p.intLiteral()
+		if err != nil {
+			return zeroRune, nil, err
+		}
 
 		tok = primitives.NewConstantInt(v)
 
+		c = p.scan.Scan()
+	case scanner.Float:
+		v, err := p.floatLiteral()
+		if err != nil {
+			return zeroRune, nil, err
+		}
+
+		tok = primitives.NewConstantFloat(v)
+
+		c = p.scan.Scan()
+	case scanner.String, scanner.RawString:
+		s, err := p.stringLiteral()
+		if err != nil {
+			return zeroRune, nil, err
+		}
+
+		tok = primitives.NewConstantString(s)
+
 		c = p.scan.Scan()
 	}
 
@@ -805,6 +1097,11 @@ func (p *tavorParser) parseExpressionTerm(definitionName string, c rune, variabl
 			if err != nil {
 				return zeroRune, nil, err
 			}
+		case "in", "not", "union", "intersection", "difference":
+			c, tok, err = p.parseExpressionOperatorSet(tok, op, definitionName, variableScope)
+			if err != nil {
+				return zeroRune, nil, err
+			}
 		default:
 			return zeroRune, nil, &token.ParserError{
 				Message:  fmt.Sprintf("Operator %q is unknown", op),
@@ -823,8 +1120,14 @@ func (p *tavorParser) parseExpressionGroup(definitionName string, variableScope
 		return nil, err
 	}
 
+	return p.parseExpressionList(definitionName, variableScope, max)
+}
+
+// parseExpressionList parses the expressions of an expression group after its opening parenthesis
+func (p *tavorParser) parseExpressionList(definitionName string, variableScope *token.VariableScope, max int) ([]token.Token, error) {
 	c := p.scan.Scan()
 
+	var err error
 	var tok token.Token
 	var toks []token.Token
 
@@ -882,55 +1185,148 @@ func (p *tavorParser) parseExpressionOperatorNotIn(tok *sequences.Sequence, defi
 	return c, tok.ExistingItem(expectToks), nil
 }
 
-var includeCache = map[string]token.Token{}
-
-func (p *tavorParser) parseExpressionOperatorInclude() (c rune, tok token.Token, err error) {
-	log.Debug("Include operator:")
+func (p *tavorParser) parseExpressionOperatorSet(tok token.Token, op string, definitionName string, variableScope *token.VariableScope) (rune, token.Token, error) {
+	log.Debugf("Set operator %q:", op)
 	log.IncreaseIndentation()
 	defer log.DecreaseIndentation()
 
-	_, err = p.expectScanRune(scanner.String)
-	if err != nil {
-		return zeroRune, nil, err
+	if op == "not" {
+		_, err := p.expectScanText("in")
+		if err != nil {
+			return zeroRune, nil, err
+		}
 	}
 
-	filepath, err := strconv.Unquote(p.scan.TokenText())
-	if err != nil {
-		return zeroRune, nil, err
-	}
+	var err error
+	var toks []token.Token
 
-	if tok, ok := includeCache[filepath]; ok {
-		tok = tok.Clone()
+	c := p.scan.Scan()
 
-		c = p.scan.Scan()
+	if c == '(' {
+		toks, err = p.parseExpressionList(definitionName, variableScope, -1)
+		if err != nil {
+			return zeroRune, nil, err
+		}
 
-		return c, tok, nil
-	}
+		c = p.scan.Scan()
+	} else {
+		var t token.Token
 
-	f, err := os.Open(filepath)
-	if err != nil {
-		return zeroRune, nil, fmt.Errorf("cannot open tavor file %q: %v", filepath, err)
-	}
-	defer func() {
-		if e := f.Close(); err == nil {
-			err = e
+		c, t, err = p.parseExpressionTerm(definitionName, c, variableScope)
+		if err != nil {
+			return zeroRune, nil, err
+		} else if t == nil {
+			return zeroRune, nil, &token.ParserError{
+				Message:  "expected another expression term after operator",
+				Type:     token.ParseErrorExpectedExpressionTerm,
+				Position: p.scan.Pos(),
+			}
 		}
-	}()
 
-	tok, err = ParseTavor(f)
-	if err != nil {
-		return zeroRune, nil, fmt.Errorf("%s: %#v", filepath, err)
+		toks = []token.Token{t}
 	}
 
-	includeCache[filepath] = tok
-
-	c = p.scan.Scan()
+	switch op {
+	case "in":
+		tok = expressions.NewInSet(tok, toks)
+	case "not":
+		tok = expressions.NewNotInSet(tok, toks)
+	case "union":
+		tok = expressions.NewUnionSet(tok, toks)
+	case "intersection":
+		tok = expressions.NewIntersectionSet(tok, toks)
+	case "difference":
+		tok = expressions.NewDifferenceSet(tok, toks)
+	}
 
 	return c, tok, nil
 }
 
-func (p *tavorParser) parseExpressionOperatorPath(tok token.Token, definitionName string, variableScope *token.VariableScope) (rune, token.Token, error) {
-	log.Debug("Path operator:")
+func (p *tavorParser) parseExpressionFunction(definitionName string, variableScope *token.VariableScope) (rune, token.Token, error) {
+	name := p.scan.TokenText()
+
+	log.Debugf("Function %q:", name)
+	log.IncreaseIndentation()
+	defer log.DecreaseIndentation()
+
+	if !expressions.FunctionExists(name) {
+		return zeroRune, nil, &token.ParserError{
+			Message:  fmt.Sprintf("unknown function %q", name),
+			Type:     token.ParseErrorUnknownFunction,
+			Position: p.scan.Pos(),
+		}
+	}
+
+	pos := p.scan.Pos()
+
+	args, err := p.parseExpressionGroup(definitionName, variableScope, -1)
+	if err != nil {
+		return zeroRune, nil, err
+	}
+
+	tok, err := expressions.NewFunction(name, args...)
+	if err != nil {
+		return zeroRune, nil, &token.ParserError{
+			Message:  err.Error(),
+			Type:     token.ParseErrorInvalidFunctionArguments,
+			Position: pos,
+		}
+	}
+
+	return p.scan.Scan(), tok, nil
+}
+
+var includeCache = map[string]token.Token{}
+
+func (p *tavorParser) parseExpressionOperatorInclude() (c rune, tok token.Token, err error) {
+	log.Debug("Include operator:")
+	log.IncreaseIndentation()
+	defer log.DecreaseIndentation()
+
+	_, err = p.expectScanRune(scanner.String)
+	if err != nil {
+		return zeroRune, nil, err
+	}
+
+	filepath, err := unquoteString(p.scan.TokenText())
+	if err != nil {
+		return zeroRune, nil, err
+	}
+
+	if tok, ok := includeCache[filepath]; ok {
+		clone := tok.Clone()
+		token.CopySources(tok, clone)
+		tok = clone
+
+		c = p.scan.Scan()
+
+		return c, tok, nil
+	}
+
+	f, err := os.Open(filepath)
+	if err != nil {
+		return zeroRune, nil, fmt.Errorf("cannot open tavor file %q: %v", filepath, err)
+	}
+	defer func() {
+		if e := f.Close(); err == nil {
+			err = e
+		}
+	}()
+
+	tok, err = ParseTavor(f)
+	if err != nil {
+		return zeroRune, nil, fmt.Errorf("%s: %#v", filepath, err)
+	}
+
+	includeCache[filepath] = tok
+
+	c = p.scan.Scan()
+
+	return c, tok, nil
+}
+
+func (p *tavorParser) parseExpressionOperatorPath(tok token.Token, definitionName string, variableScope *token.VariableScope) (rune, token.Token, error) {
+	log.Debug("Path operator:")
 	log.IncreaseIndentation()
 	defer log.DecreaseIndentation()
 
@@ -1096,6 +1492,30 @@ func (p *tavorParser) parseTokenAttribute(definitionName string, c rune, variabl
 	return c, rtok, err
 }
 
+// TokenAttributes returns the names of all token attributes which can be used with the given token.
+func TokenAttributes(tok token.Token) []string {
+	if t, ok := tok.(*primitives.Scope); ok {
+		tok = t.Resolve()
+	}
+
+	switch tok.(type) {
+	case token.ListToken:
+		return []string{"Count", "Item", "Unique"}
+	case *sequences.Sequence:
+		return []string{"Existing", "Next", "Reset"}
+	case *graphs.Graph:
+		return []string{"Edges", "Nodes", "Path", "Root"}
+	case *symbols.Symbols:
+		return []string{"Define", "Use"}
+	case *primitives.RangeInt:
+		return []string{"Value"}
+	case token.VariableToken:
+		return []string{"Count", "Index", "Item", "Reference", "Value"}
+	}
+
+	return nil
+}
+
 func (p *tavorParser) selectTokenAttribute(definitionName string, tok token.Token, tokenName string, attribute string, attributePosition scanner.Position, operator string, operatorToken token.Token, c rune, variableScope *token.VariableScope) (rune, token.Token, error) {
 	if t, ok := tok.(*primitives.Scope); ok {
 		tok = t.Resolve()
@@ -1147,6 +1567,45 @@ func (p *tavorParser) selectTokenAttribute(definitionName string, tok token.Toke
 		case "Reset":
 			return c, i.ResetItem(), nil
 		}
+	case *symbols.Symbols:
+		switch attribute {
+		case "Define":
+			_, err := p.expectRune('(', c)
+			if err != nil {
+				return zeroRune, nil, err
+			}
+
+			c = p.scan.Scan()
+
+			c, value, err := p.parseExpressionTerm(definitionName, c, variableScope)
+			if err != nil {
+				return zeroRune, nil, err
+			}
+
+			_, err = p.expectRune(')', c)
+			if err != nil {
+				return zeroRune, nil, err
+			}
+
+			c = p.scan.Scan()
+
+			return c, i.DefineItem(value), nil
+		case "Use":
+			return c, i.UseItem(), nil
+		}
+	case *graphs.Graph:
+		p.graphItems[i] = struct{}{}
+
+		switch attribute {
+		case "Edges":
+			return c, i.EdgesItem(), nil
+		case "Nodes":
+			return c, i.NodesItem(), nil
+		case "Path":
+			return c, i.PathItem(), nil
+		case "Root":
+			return c, i.RootItem(), nil
+		}
 	case *primitives.RangeInt:
 		switch attribute {
 		case "Value":
@@ -1197,137 +1656,540 @@ func (p *tavorParser) selectTokenAttribute(definitionName string, tok token.Toke
 	}
 }
 
-func (p *tavorParser) parseScope(definitionName string, c rune, variableScope *token.VariableScope) (rune, []token.Token, error) {
-	var err error
-	var tokens []token.Token
+// scanCharacterClass reads the pattern of a character class character by character right after its opening bracket since white spaces, quotes and comment characters are significant inside of character classes.
+// The returned rune is either the closing bracket, a new line or EOF which terminated the pattern.
+func scanCharacterClass(s *scanner.Scanner) (string, rune) {
+	var pattern bytes.Buffer
+	depth := 0
 
-	var toks []token.Token
+	for {
+		r := s.Next()
 
-	c, toks, err = p.parseTerm(definitionName, c, variableScope)
-	if err != nil {
-		return zeroRune, nil, err
-	} else if toks != nil {
-		tokens = toks
-	}
+		switch r {
+		case scanner.EOF, '\n':
+			return pattern.String(), r
+		case '\\':
+			if _, err := pattern.WriteRune(r); err != nil {
+				panic(err)
+			}
 
-	var ifPairs []conditions.IfPair
+			if n := s.Peek(); n == scanner.EOF || n == '\n' {
+				continue
+			}
 
-SCOPE:
-	for {
-		switch c {
-		case '|':
-			log.Debug("Or:")
-			log.IncreaseIndentation()
+			r = s.Next()
+		case '[':
+			depth++
+		case ']':
+			if depth == 0 {
+				return pattern.String(), r
+			}
 
-			var orTerms []token.Token
-			optional := false
+			depth--
+		}
 
-			toks = tokens
+		if _, err := pattern.WriteRune(r); err != nil {
+			panic(err)
+		}
+	}
+}
 
-		OR:
-			for {
-				switch len(toks) {
-				case 0:
-					optional = true
-				case 1:
-					orTerms = append(orTerms, toks[0])
-				default:
-					orTerms = append(orTerms, lists.NewConcatenation(toks...))
-				}
+func (p *tavorParser) parseBits(definitionName string, variableScope *token.VariableScope) (token.Token, error) {
+	log.Debug("Bits:")
+	log.IncreaseIndentation()
+	defer log.DecreaseIndentation()
 
-				if c == '|' {
-					c = p.scan.Scan()
-					log.Debugf("parseScope Or %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
-				} else {
-					log.Debug("parseScope break out or")
+	pos := p.scan.Pos()
 
-					break OR
-				}
+	if _, err := p.expectScanRune('('); err != nil {
+		return nil, err
+	}
 
-				c, toks, err = p.parseTerm(definitionName, c, variableScope)
-				if err != nil {
-					return zeroRune, nil, err
-				}
-			}
+	order := lists.BitOrderMSB
+	var fields []lists.BitField
+	width := 0
 
-			or := lists.NewOne(orTerms...)
+	for {
+		c := p.scan.Scan()
 
-			if optional {
-				tokens = []token.Token{constraints.NewOptional(or)}
-			} else {
-				tokens = []token.Token{or}
+		// multi line bit fields
+		for c == '\n' {
+			c = p.scan.Scan()
+		}
+
+		switch c {
+		case scanner.Int:
+			w, err := p.intLiteral()
+			if err != nil {
+				return nil, err
 			}
 
-			log.DecreaseIndentation()
-		case '{': // TODO make conditions work with ORs...
-			_ = p.scan.Scan()
-			condition := p.scan.TokenText()
+			if w < 1 || w > 32 {
+				return nil, &token.ParserError{
+					Message:  fmt.Sprintf("bit field width %d is not between 1 and 32", w),
+					Type:     token.ParseErrorInvalidBits,
+					Position: p.scan.Pos(),
+				}
+			}
 
-			var conditionExpression conditions.BooleanExpression
+			if _, err := p.expectScanRune(':'); err != nil {
+				return nil, err
+			}
 
-			switch condition {
-			case "if":
-				log.Debug("If:")
-				log.IncreaseIndentation()
+			var tok token.Token
 
-				c, conditionExpression, err = p.parseConditionExpression(definitionName, variableScope)
+			switch c = p.scan.Scan(); c {
+			case scanner.Ident:
+				tok = p.getToken(definitionName, p.scan.TokenText(), variableScope.Push())
+			case scanner.Int:
+				v, err := p.intLiteral()
 				if err != nil {
-					return zeroRune, nil, err
-				}
-			case "else":
-				if len(ifPairs) == 0 {
-					panic("TODO else/elseif without if")
+					return nil, err
 				}
 
-				c = p.scan.Scan()
-
-				if p.scan.TokenText() == "if" {
-					log.Debug("Elseif")
-					log.IncreaseIndentation()
-
-					c, conditionExpression, err = p.parseConditionExpression(definitionName, variableScope)
-					if err != nil {
-						return zeroRune, nil, err
-					}
-				} else {
-					log.Debug("Else:")
-					log.IncreaseIndentation()
-
-					conditionExpression = conditions.NewBooleanTrue()
+				tok = primitives.NewConstantInt(v)
+			default:
+				return nil, &token.ParserError{
+					Message:  fmt.Sprintf("expected integer token for bit field but got %v", scanner.TokenString(c)),
+					Type:     token.ParseErrorInvalidBits,
+					Position: p.scan.Pos(),
 				}
-			case "endif":
-				log.Debug("Endif:")
-				log.IncreaseIndentation()
+			}
 
-				if len(ifPairs) == 0 {
-					panic("TODO endif without if")
+			fields = append(fields, lists.BitField{
+				Width: w,
+				Token: tok,
+			})
+			width += w
+		case scanner.Ident:
+			if p.scan.TokenText() != "order" {
+				return nil, &token.ParserError{
+					Message:  fmt.Sprintf("unknown bits argument %q", p.scan.TokenText()),
+					Type:     token.ParseErrorInvalidBits,
+					Position: p.scan.Pos(),
 				}
+			}
 
-				c = p.scan.Scan()
+			if _, err := p.expectScanRune(':'); err != nil {
+				return nil, err
+			}
+			if _, err := p.expectScanRune(scanner.Ident); err != nil {
+				return nil, err
+			}
+
+			switch o := p.scan.TokenText(); o {
+			case "msb":
+				order = lists.BitOrderMSB
+			case "lsb":
+				order = lists.BitOrderLSB
 			default:
-				return zeroRune, nil, &token.ParserError{
-					Message:  fmt.Sprintf("unknown condition %q", condition),
-					Type:     token.ParseErrorUnknownCondition,
+				return nil, &token.ParserError{
+					Message:  fmt.Sprintf("unknown bit order %q", o),
+					Type:     token.ParseErrorInvalidBits,
 					Position: p.scan.Pos(),
 				}
 			}
-
-			_, err = p.expectRune('}', c)
-			if err != nil {
-				return zeroRune, nil, err
+		default:
+			return nil, &token.ParserError{
+				Message:  fmt.Sprintf("expected bit field but got %v", scanner.TokenString(c)),
+				Type:     token.ParseErrorInvalidBits,
+				Position: p.scan.Pos(),
 			}
+		}
 
-			c = p.scan.Scan()
+		c = p.scan.Scan()
+		if c == ',' {
+			continue
+		}
 
-			if condition != "endif" {
-				c, toks, err = p.parseTerm(definitionName, c, variableScope) // TODO this should be a a global scope or so ... we can do nesting
-				if err != nil {
-					return zeroRune, nil, err
-				}
+		if _, err := p.expectRune(')', c); err != nil {
+			return nil, err
+		}
 
-				var tok token.Token
+		break
+	}
 
-				switch len(toks) {
+	if len(fields) == 0 || width%8 != 0 {
+		return nil, &token.ParserError{
+			Message:  fmt.Sprintf("bit fields must add up to whole bytes but have %d bits", width),
+			Type:     token.ParseErrorInvalidBits,
+			Position: pos,
+		}
+	}
+
+	return lists.NewBits(order, fields...), nil
+}
+
+func (p *tavorParser) parseTLVEncoding() (lists.IntEncoding, error) {
+	if _, err := p.expectScanRune(scanner.Ident); err != nil {
+		return lists.IntEncoding{}, err
+	}
+
+	e, ok := lists.IntEncodings[p.scan.TokenText()]
+	if !ok {
+		return lists.IntEncoding{}, &token.ParserError{
+			Message:  fmt.Sprintf("unknown integer encoding %q", p.scan.TokenText()),
+			Type:     token.ParseErrorInvalidTLV,
+			Position: p.scan.Pos(),
+		}
+	}
+
+	return e, nil
+}
+
+func (p *tavorParser) parseTLVToken(definitionName string, variableScope *token.VariableScope, allowStrings bool) (token.Token, error) {
+	c := p.scan.Scan()
+
+	switch c {
+	case scanner.Ident:
+		return p.getToken(definitionName, p.scan.TokenText(), variableScope.Push()), nil
+	case scanner.Int:
+		v, err := p.intLiteral()
+		if err != nil {
+			return nil, err
+		}
+
+		return primitives.NewConstantInt(v), nil
+	case scanner.String, scanner.RawString:
+		if !allowStrings {
+			break
+		}
+
+		s, err := unquoteString(p.scan.TokenText())
+		if err != nil || len(s) == 0 {
+			return nil, &token.ParserError{
+				Message:  fmt.Sprintf("invalid string %s", p.scan.TokenText()),
+				Type:     token.ParseErrorInvalidTLV,
+				Position: p.scan.Pos(),
+			}
+		}
+
+		return primitives.NewConstantString(s), nil
+	}
+
+	return nil, &token.ParserError{
+		Message:  fmt.Sprintf("expected token but got %v", scanner.TokenString(c)),
+		Type:     token.ParseErrorInvalidTLV,
+		Position: p.scan.Pos(),
+	}
+}
+
+func (p *tavorParser) parseTLV(definitionName string, variableScope *token.VariableScope) (token.Token, error) {
+	log.Debug("TLV:")
+	log.IncreaseIndentation()
+	defer log.DecreaseIndentation()
+
+	pos := p.scan.Pos()
+
+	if _, err := p.expectScanRune('('); err != nil {
+		return nil, err
+	}
+
+	var typeEncoding, lengthEncoding lists.IntEncoding
+	var typ, value token.Token
+	hasLength := false
+
+	for {
+		c := p.scan.Scan()
+
+		// multi line arguments
+		for c == '\n' {
+			c = p.scan.Scan()
+		}
+
+		if c != scanner.Ident {
+			return nil, &token.ParserError{
+				Message:  fmt.Sprintf("expected tlv argument but got %v", scanner.TokenString(c)),
+				Type:     token.ParseErrorInvalidTLV,
+				Position: p.scan.Pos(),
+			}
+		}
+
+		arg := p.scan.TokenText()
+
+		if (arg == "type" && typ != nil) || (arg == "length" && hasLength) || (arg == "value" && value != nil) {
+			return nil, &token.ParserError{
+				Message:  fmt.Sprintf("tlv argument %q already defined", arg),
+				Type:     token.ParseErrorInvalidTLV,
+				Position: p.scan.Pos(),
+			}
+		}
+
+		if _, err := p.expectScanRune(':'); err != nil {
+			return nil, err
+		}
+
+		switch arg {
+		case "type":
+			e, err := p.parseTLVEncoding()
+			if err != nil {
+				return nil, err
+			}
+
+			typeEncoding = e
+
+			if p.scan.Peek() == '(' {
+				p.scan.Scan()
+
+				if typ, err = p.parseTLVToken(definitionName, variableScope, false); err != nil {
+					return nil, err
+				}
+
+				if _, err := p.expectScanRune(')'); err != nil {
+					return nil, err
+				}
+			} else {
+				// every type of the encoding is allowed
+				typ = primitives.NewRangeInt(0, e.Max())
+			}
+		case "length":
+			e, err := p.parseTLVEncoding()
+			if err != nil {
+				return nil, err
+			}
+
+			lengthEncoding = e
+			hasLength = true
+		case "value":
+			var err error
+
+			if value, err = p.parseTLVToken(definitionName, variableScope, true); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, &token.ParserError{
+				Message:  fmt.Sprintf("unknown tlv argument %q", arg),
+				Type:     token.ParseErrorInvalidTLV,
+				Position: p.scan.Pos(),
+			}
+		}
+
+		c = p.scan.Scan()
+		if c == ',' {
+			continue
+		}
+
+		if _, err := p.expectRune(')', c); err != nil {
+			return nil, err
+		}
+
+		break
+	}
+
+	if !hasLength || value == nil {
+		return nil, &token.ParserError{
+			Message:  "tlv needs a length and a value argument",
+			Type:     token.ParseErrorInvalidTLV,
+			Position: pos,
+		}
+	}
+
+	return lists.NewTLV(typeEncoding, typ, lengthEncoding, value), nil
+}
+
+func (p *tavorParser) parseScope(definitionName string, c rune, variableScope *token.VariableScope) (rune, []token.Token, error) {
+	var err error
+	var tokens []token.Token
+
+	var toks []token.Token
+
+	termPosition := p.scan.Position
+
+	c, toks, err = p.parseTerm(definitionName, c, variableScope)
+	if err != nil {
+		return zeroRune, nil, err
+	} else if toks != nil {
+		tokens = toks
+	}
+
+	var ifPairs []conditions.IfPair
+
+SCOPE:
+	for {
+		switch c {
+		case '|':
+			log.Debug("Or:")
+			log.IncreaseIndentation()
+
+			var orTerms []token.Token
+			optional := false
+
+			orPosition := termPosition
+			toks = tokens
+
+		OR:
+			for {
+				switch len(toks) {
+				case 0:
+					optional = true
+				case 1:
+					orTerms = append(orTerms, toks[0])
+					p.positions[toks[0]] = termPosition
+				default:
+					orTerm := lists.NewConcatenation(toks...)
+					orTerms = append(orTerms, orTerm)
+					p.positions[orTerm] = termPosition
+					p.setSource(definitionName, orTerm, termPosition)
+				}
+
+				if c == '|' {
+					c = p.scan.Scan()
+					log.Debugf("parseScope Or %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+				} else {
+					log.Debug("parseScope break out or")
+
+					break OR
+				}
+
+				termPosition = p.scan.Position
+
+				c, toks, err = p.parseTerm(definitionName, c, variableScope)
+				if err != nil {
+					return zeroRune, nil, err
+				}
+			}
+
+			or := lists.NewOne(orTerms...)
+			p.positions[or] = orPosition
+			p.setSource(definitionName, or, orPosition)
+
+			if optional {
+				tokens = []token.Token{constraints.NewOptional(or)}
+			} else {
+				tokens = []token.Token{or}
+			}
+
+			log.DecreaseIndentation()
+		case '{': // TODO make conditions work with ORs...
+			_ = p.scan.Scan()
+			condition := p.scan.TokenText()
+
+			var conditionExpression conditions.BooleanExpression
+
+			switch condition {
+			case "switch":
+				if len(ifPairs) > 0 {
+					return zeroRune, nil, &token.ParserError{
+						Message:  "switch statement inside if statement without body",
+						Type:     token.ParseErrorInvalidSwitch,
+						Position: p.scan.Pos(),
+					}
+				}
+
+				var tok token.Token
+
+				c, tok, err = p.parseSwitch(definitionName, variableScope)
+				if err != nil {
+					return zeroRune, nil, err
+				}
+
+				tokens = append(tokens, tok)
+
+				c, toks, err = p.parseTerm(definitionName, c, variableScope)
+				if err != nil {
+					return zeroRune, nil, err
+				}
+
+				tokens = append(tokens, toks...)
+
+				continue SCOPE
+			case "assert":
+				if len(ifPairs) > 0 {
+					return zeroRune, nil, &token.ParserError{
+						Message:  "assert statement inside if statement",
+						Type:     token.ParseErrorInvalidAssert,
+						Position: p.scan.Pos(),
+					}
+				}
+
+				log.Debug("Assert:")
+
+				position := p.scan.Position
+
+				c, conditionExpression, err = p.parseConditionExpression(definitionName, variableScope)
+				if err != nil {
+					return zeroRune, nil, err
+				}
+
+				if _, err := p.expectRune('}', c); err != nil {
+					return zeroRune, nil, err
+				}
+
+				tok := conditions.NewAssert(conditionExpression)
+				p.setSource(definitionName, tok, position)
+
+				tokens = append(tokens, tok)
+
+				c, toks, err = p.parseTerm(definitionName, p.scan.Scan(), variableScope)
+				if err != nil {
+					return zeroRune, nil, err
+				}
+
+				tokens = append(tokens, toks...)
+
+				continue SCOPE
+			case "if":
+				log.Debug("If:")
+				log.IncreaseIndentation()
+
+				c, conditionExpression, err = p.parseConditionExpression(definitionName, variableScope)
+				if err != nil {
+					return zeroRune, nil, err
+				}
+			case "else":
+				if len(ifPairs) == 0 {
+					panic("TODO else/elseif without if")
+				}
+
+				c = p.scan.Scan()
+
+				if p.scan.TokenText() == "if" {
+					log.Debug("Elseif")
+					log.IncreaseIndentation()
+
+					c, conditionExpression, err = p.parseConditionExpression(definitionName, variableScope)
+					if err != nil {
+						return zeroRune, nil, err
+					}
+				} else {
+					log.Debug("Else:")
+					log.IncreaseIndentation()
+
+					conditionExpression = conditions.NewBooleanTrue()
+				}
+			case "endif":
+				log.Debug("Endif:")
+				log.IncreaseIndentation()
+
+				if len(ifPairs) == 0 {
+					panic("TODO endif without if")
+				}
+
+				c = p.scan.Scan()
+			default:
+				return zeroRune, nil, &token.ParserError{
+					Message:  fmt.Sprintf("unknown condition %q", condition),
+					Type:     token.ParseErrorUnknownCondition,
+					Position: p.scan.Pos(),
+				}
+			}
+
+			_, err = p.expectRune('}', c)
+			if err != nil {
+				return zeroRune, nil, err
+			}
+
+			c = p.scan.Scan()
+
+			if condition != "endif" {
+				c, toks, err = p.parseTerm(definitionName, c, variableScope) // TODO this should be a a global scope or so ... we can do nesting
+				if err != nil {
+					return zeroRune, nil, err
+				}
+
+				var tok token.Token
+
+				switch len(toks) {
 				case 0:
 					panic("empty body is not allowed") // TODO catch this
 				case 1:
@@ -1372,6 +2234,130 @@ SCOPE:
 	return c, tokens, nil
 }
 
+func (p *tavorParser) parseSwitch(definitionName string, variableScope *token.VariableScope) (rune, token.Token, error) {
+	log.Debug("Switch:")
+	log.IncreaseIndentation()
+	defer log.DecreaseIndentation()
+
+	switchPosition := p.scan.Position
+
+	c, value, err := p.parseExpression(definitionName, variableScope)
+	if err != nil {
+		return zeroRune, nil, err
+	}
+
+	if _, err = p.expectRune('}', c); err != nil {
+		return zeroRune, nil, err
+	}
+
+	var cases []conditions.SwitchCase
+	hasDefault := false
+
+	c = p.scan.Scan()
+
+	for {
+		if _, err = p.expectRune('{', c); err != nil {
+			return zeroRune, nil, err
+		}
+
+		_ = p.scan.Scan()
+		statement := p.scan.TokenText()
+
+		var values []token.Token
+
+		switch statement {
+		case "case":
+			log.Debug("Case:")
+
+			for {
+				var v token.Token
+
+				c, v, err = p.parseExpression(definitionName, variableScope)
+				if err != nil {
+					return zeroRune, nil, err
+				}
+
+				values = append(values, v)
+
+				if c != ',' {
+					break
+				}
+			}
+		case "default":
+			log.Debug("Default:")
+
+			if hasDefault {
+				return zeroRune, nil, &token.ParserError{
+					Message:  "switch statement has more than one default case",
+					Type:     token.ParseErrorInvalidSwitch,
+					Position: p.scan.Pos(),
+				}
+			}
+
+			hasDefault = true
+
+			c = p.scan.Scan()
+		case "endswitch":
+			log.Debug("Endswitch:")
+
+			if len(cases) == 0 {
+				return zeroRune, nil, &token.ParserError{
+					Message:  "switch statement without cases",
+					Type:     token.ParseErrorInvalidSwitch,
+					Position: p.scan.Pos(),
+				}
+			}
+
+			if _, err = p.expectScanRune('}'); err != nil {
+				return zeroRune, nil, err
+			}
+
+			tok := conditions.NewSwitch(value, cases...)
+			p.positions[tok] = switchPosition
+			p.setSource(definitionName, tok, switchPosition)
+
+			return p.scan.Scan(), tok, nil
+		default:
+			return zeroRune, nil, &token.ParserError{
+				Message:  fmt.Sprintf("expected case, default or endswitch but got %q", statement),
+				Type:     token.ParseErrorInvalidSwitch,
+				Position: p.scan.Pos(),
+			}
+		}
+
+		if _, err = p.expectRune('}', c); err != nil {
+			return zeroRune, nil, err
+		}
+
+		c = p.scan.Scan()
+
+		var toks []token.Token
+
+		// every case has its own scope
+		c, toks, err = p.parseTerm(definitionName, c, variableScope.Push())
+		if err != nil {
+			return zeroRune, nil, err
+		}
+
+		var body token.Token
+
+		switch len(toks) {
+		case 0:
+			// a case without a body generates nothing
+			body = primitives.NewConstantString("")
+		case 1:
+			body = toks[0]
+		default:
+			body = lists.NewConcatenation(toks...)
+		}
+
+		cases = append(cases, conditions.SwitchCase{
+			Values: values,
+			Body:   body,
+		})
+	}
+}
+
 func (p *tavorParser) parseConditionExpression(definitionName string, variableScope *token.VariableScope) (rune, conditions.BooleanExpression, error) {
 	c, a, err := p.parseExpression(definitionName, variableScope)
 	if err != nil {
@@ -1382,12 +2368,40 @@ func (p *tavorParser) parseConditionExpression(definitionName string, variableSc
 		return c, ex, nil
 	}
 
+	equal := false
+	var op conditions.CompareOperator
+
 	switch c {
 	case '=':
 		_, err = p.expectScanRune('=')
 		if err != nil {
 			return zeroRune, nil, err
 		}
+
+		equal = true
+	case '!':
+		_, err = p.expectScanRune('=')
+		if err != nil {
+			return zeroRune, nil, err
+		}
+
+		op = conditions.CompareNotEqual
+	case '<':
+		op = conditions.CompareLess
+
+		if p.scan.Peek() == '=' {
+			p.scan.Scan()
+
+			op = conditions.CompareLessEqual
+		}
+	case '>':
+		op = conditions.CompareGreater
+
+		if p.scan.Peek() == '=' {
+			p.scan.Scan()
+
+			op = conditions.CompareGreaterEqual
+		}
 	default:
 		return zeroRune, nil, &token.ParserError{
 			Message:  fmt.Sprintf("unknown boolean operator %q", c),
@@ -1401,11 +2415,45 @@ func (p *tavorParser) parseConditionExpression(definitionName string, variableSc
 		return zeroRune, nil, err
 	}
 
-	return c, conditions.NewBooleanEqual(a, b), nil
+	if equal {
+		return c, conditions.NewBooleanEqual(a, b), nil
+	}
+
+	return c, conditions.NewBooleanCompare(op, a, b), nil
 }
 
 func (p *tavorParser) parseTokenDefinition(variableScope *token.VariableScope) (c rune, err error) {
 	name := p.scan.TokenText()
+	namePosition := p.scan.Pos()
+	tokenPosition := p.scan.Position
+
+	c = p.scan.Scan()
+	log.Debugf("parseTokenDefinition after name %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+
+	// a constant definition starts with the keyword "Const" followed by the name of the constant
+	if name == "Const" && c == scanner.Ident {
+		return p.parseConstantDefinition(variableScope)
+	}
+
+	// an embedded test starts with the keyword "test" followed by "valid" or "invalid"
+	if name == "test" && c == scanner.Ident {
+		return p.parseTestDefinition(tokenPosition)
+	}
+
+	// an exported token definition starts with the keyword "Export" followed by a regular token definition
+	if name == "Export" && c == scanner.Ident {
+		if p.scan.TokenText() == "Const" || p.scan.TokenText() == "Export" {
+			return zeroRune, &token.ParserError{
+				Message:  fmt.Sprintf("only token definitions can be exported but got %q", p.scan.TokenText()),
+				Type:     token.ParseErrorInvalidTokenName,
+				Position: p.scan.Pos(),
+			}
+		}
+
+		p.exports = append(p.exports, p.scan.TokenText())
+
+		return p.parseTokenDefinition(variableScope)
+	}
 
 	if use, ok := p.lookup[name]; ok {
 		// if there is a pointer in the lookup hash we can say that it was just used before
@@ -1413,14 +2461,12 @@ func (p *tavorParser) parseTokenDefinition(variableScope *token.VariableScope) (
 			return zeroRune, &token.ParserError{
 				Message:  "token already defined",
 				Type:     token.ParseErrorTokenAlreadyDefined,
-				Position: p.scan.Pos(),
+				Position: namePosition,
 			}
 		}
 	}
 
-	tokenPosition := p.scan.Position
-
-	if c, err = p.expectScanRune('='); err != nil {
+	if c, err = p.expectRune('=', c); err != nil {
 		// unexpected new line?
 		if c == '\n' {
 			return zeroRune, &token.ParserError{
@@ -1447,7 +2493,193 @@ func (p *tavorParser) parseTokenDefinition(variableScope *token.VariableScope) (
 		return zeroRune, err
 	}
 
-	log.Debugf("back to token definition with c=%q", c)
+	log.Debugf("back to token definition with c=%q", c)
+
+	// we always want a new line at the end of the file
+	if c == scanner.EOF {
+		return zeroRune, &token.ParserError{
+			Message:  "new line at end of token definition needed",
+			Type:     token.ParseErrorNewLineNeeded,
+			Position: p.scan.Pos(),
+		}
+	}
+
+	if c, err = p.expectRune('\n', c); err != nil {
+		return zeroRune, err
+	}
+
+	var tok token.Token
+
+	switch len(tokens) {
+	case 0:
+		return zeroRune, &token.ParserError{
+			Message:  "empty token definition",
+			Type:     token.ParseErrorEmptyTokenDefinition,
+			Position: p.scan.Pos(),
+		}
+	case 1:
+		tok = tokens[0]
+	default:
+		tok = lists.NewConcatenation(tokens...)
+	}
+
+	err = p.registerNamedToken(name, tok, tokenPosition, variableScope)
+	if err != nil {
+		return zeroRune, err
+	}
+
+	c = p.scan.Scan()
+	log.Debugf("parseTokenDefinition after newline %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
+
+	return c, nil
+}
+
+func (p *tavorParser) setEarlyUsage(name string, tok token.Token) error {
+	// self loop?
+	if uses, ok := p.earlyUse[name]; ok {
+		log.Debugf("setEarlyUsage fill empty pointer for %s", name)
+
+		for _, use := range uses {
+			log.Debugf("use (%p)%#v for pointer (%p)%#v", tok, tok, use.token, use.token)
+
+			err := use.token.(*primitives.Pointer).Set(tok)
+			if err != nil {
+				return &token.ParserError{
+					Message:  fmt.Sprintf("wrong token type for %s because of earlier usage: %s", name, err),
+					Type:     token.ParseErrorInvalidTokenType,
+					Position: p.scan.Pos(),
+				}
+			}
+		}
+
+		delete(p.earlyUse, name)
+	}
+
+	return nil
+}
+
+func (p *tavorParser) registerNamedToken(name string, tok token.Token, tokenPosition scanner.Position, variableScope *token.VariableScope) error {
+	sTok := primitives.NewScope(tok)
+
+	p.setSource(name, tok, tokenPosition)
+	p.setSource(name, sTok, tokenPosition)
+
+	err := p.setEarlyUsage(name, sTok)
+	if err != nil {
+		return err
+	}
+
+	p.lookup[name] = tokenUsage{
+		token:         sTok,
+		position:      tokenPosition,
+		variableScope: variableScope,
+	}
+	p.definitions[name] = tokenUsage{
+		token:         tok,
+		position:      tokenPosition,
+		variableScope: variableScope,
+	}
+
+	log.Debugf("added (%p)%#v as token %s", tok, tok, name)
+
+	return nil
+}
+
+func (p *tavorParser) parseConstantDefinition(variableScope *token.VariableScope) (rune, error) {
+	log.Debug("Constant")
+
+	name := p.scan.TokenText()
+	if use, ok := p.lookup[name]; ok {
+		// if there is a pointer in the lookup hash we can say that it was just used before
+		if _, ok := use.token.(*primitives.Pointer); !ok {
+			return zeroRune, &token.ParserError{
+				Message:  "token already defined",
+				Type:     token.ParseErrorTokenAlreadyDefined,
+				Position: p.scan.Pos(),
+			}
+		}
+	}
+
+	tokenPosition := p.scan.Position
+
+	if _, err := p.expectScanRune('='); err != nil {
+		return zeroRune, err
+	}
+
+	c := p.scan.Scan()
+
+	// optional sign (+/-)
+	prefix := ""
+	if c == '-' {
+		prefix = "-"
+		c = p.scan.Scan()
+	} else if c == '+' {
+		c = p.scan.Scan()
+	}
+
+	var tok token.Token
+
+	switch c {
+	case scanner.Int:
+		v, err := p.intLiteral()
+		if err != nil {
+			return zeroRune, err
+		}
+
+		if prefix == "-" {
+			v = -v
+		}
+
+		tok = primitives.NewConstantInt(v)
+	case scanner.Float:
+		v, err := p.floatLiteral()
+		if err != nil {
+			return zeroRune, err
+		}
+
+		if prefix == "-" {
+			v = -v
+		}
+
+		tok = primitives.NewConstantFloat(v)
+	case scanner.String, scanner.RawString:
+		s, err := p.stringLiteral()
+		if err != nil {
+			return zeroRune, err
+		}
+
+		tok = primitives.NewConstantString(s)
+	case '<':
+		if p.scan.Peek() != '<' {
+			return zeroRune, &token.ParserError{
+				Message:  fmt.Sprintf("invalid constant value %v", scanner.TokenString(c)),
+				Type:     token.ParseErrorInvalidConstantValue,
+				Position: p.scan.Pos(),
+			}
+		}
+
+		s, err := p.heredocLiteral()
+		if err != nil {
+			return zeroRune, err
+		}
+
+		tok = primitives.NewConstantString(s)
+	case scanner.Ident:
+		v, err := p.getConstant(p.scan.TokenText())
+		if err != nil {
+			return zeroRune, err
+		}
+
+		tok = v.Clone()
+	default:
+		return zeroRune, &token.ParserError{
+			Message:  fmt.Sprintf("invalid constant value %v", scanner.TokenString(c)),
+			Type:     token.ParseErrorInvalidConstantValue,
+			Position: p.scan.Pos(),
+		}
+	}
+
+	c = p.scan.Scan()
 
 	// we always want a new line at the end of the file
 	if c == scanner.EOF {
@@ -1458,77 +2690,135 @@ func (p *tavorParser) parseTokenDefinition(variableScope *token.VariableScope) (
 		}
 	}
 
-	if c, err = p.expectRune('\n', c); err != nil {
+	if _, err := p.expectRune('\n', c); err != nil {
 		return zeroRune, err
 	}
 
-	var tok token.Token
+	if v, ok := p.constantOverride(name); ok {
+		switch tok.(type) {
+		case *primitives.ConstantInt:
+			i, err := strconv.Atoi(v)
+			if err != nil {
+				return zeroRune, &token.ParserError{
+					Message:  fmt.Sprintf("constant %q needs an integer value but got %q", name, v),
+					Type:     token.ParseErrorInvalidConstantValue,
+					Position: tokenPosition,
+				}
+			}
 
-	switch len(tokens) {
-	case 0:
-		return zeroRune, &token.ParserError{
-			Message:  "empty token definition",
-			Type:     token.ParseErrorEmptyTokenDefinition,
-			Position: p.scan.Pos(),
+			tok = primitives.NewConstantInt(i)
+		case *primitives.ConstantFloat:
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return zeroRune, &token.ParserError{
+					Message:  fmt.Sprintf("constant %q needs a floating point value but got %q", name, v),
+					Type:     token.ParseErrorInvalidConstantValue,
+					Position: tokenPosition,
+				}
+			}
+
+			tok = primitives.NewConstantFloat(f)
+		default:
+			tok = primitives.NewConstantString(v)
 		}
-	case 1:
-		tok = tokens[0]
-	default:
-		tok = lists.NewConcatenation(tokens...)
+
+		log.Debugf("override constant %s with %q", name, v)
 	}
 
-	err = p.registerNamedToken(name, tok, tokenPosition, variableScope)
-	if err != nil {
+	p.constants[name] = tok
+
+	if err := p.registerNamedToken(name, tok, tokenPosition, variableScope); err != nil {
 		return zeroRune, err
 	}
 
 	c = p.scan.Scan()
-	log.Debugf("parseTokenDefinition after newline %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
 
 	return c, nil
 }
 
-func (p *tavorParser) setEarlyUsage(name string, tok token.Token) error {
-	// self loop?
-	if uses, ok := p.earlyUse[name]; ok {
-		log.Debugf("setEarlyUsage fill empty pointer for %s", name)
+// constantOverride returns the value which overrides the definition of the given constant. Defines take precedence over environment variables.
+func (p *tavorParser) constantOverride(name string) (string, bool) {
+	if v, ok := p.defines[name]; ok {
+		return v, true
+	}
 
-		for _, use := range uses {
-			log.Debugf("use (%p)%#v for pointer (%p)%#v", tok, tok, use.token, use.token)
+	return os.LookupEnv(ConstantEnvironmentPrefix + name)
+}
 
-			err := use.token.(*primitives.Pointer).Set(tok)
-			if err != nil {
-				return &token.ParserError{
-					Message:  fmt.Sprintf("wrong token type for %s because of earlier usage: %s", name, err),
-					Type:     token.ParseErrorInvalidTokenType,
-					Position: p.scan.Pos(),
-				}
-			}
+// getConstant returns the value of an already defined constant
+func (p *tavorParser) getConstant(name string) (token.Token, error) {
+	tok, ok := p.constants[name]
+	if !ok {
+		return nil, &token.ParserError{
+			Message:  fmt.Sprintf("constant %q is not defined", name),
+			Type:     token.ParseErrorUnknownConstant,
+			Position: p.scan.Pos(),
 		}
-
-		delete(p.earlyUse, name)
 	}
 
-	return nil
+	return tok, nil
 }
 
-func (p *tavorParser) registerNamedToken(name string, tok token.Token, tokenPosition scanner.Position, variableScope *token.VariableScope) error {
-	sTok := primitives.NewScope(tok)
+// getConstantInt returns the value of an already defined integer constant
+func (p *tavorParser) getConstantInt(name string) (token.Token, error) {
+	tok, err := p.getConstant(name)
+	if err != nil {
+		return nil, err
+	}
 
-	err := p.setEarlyUsage(name, sTok)
+	if _, ok := tok.(*primitives.ConstantInt); !ok {
+		return nil, &token.ParserError{
+			Message:  fmt.Sprintf("constant %q needs an integer value", name),
+			Type:     token.ParseErrorInvalidConstantValue,
+			Position: p.scan.Pos(),
+		}
+	}
+
+	return tok.Clone(), nil
+}
+
+// getConstantBound returns the value of an already defined integer constant which is used as a bound of a range and must therefore not be negative
+func (p *tavorParser) getConstantBound(name string) (token.Token, error) {
+	tok, err := p.getConstantInt(name)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	p.lookup[name] = tokenUsage{
-		token:         sTok,
-		position:      tokenPosition,
-		variableScope: variableScope,
+	if v := tok.(*primitives.ConstantInt).Value(); v < 0 {
+		return nil, &token.ParserError{
+			Message:  fmt.Sprintf("constant %q is used as a bound and needs a non-negative value but has %d", name, v),
+			Type:     token.ParseErrorInvalidConstantValue,
+			Position: p.scan.Pos(),
+		}
 	}
 
-	log.Debugf("added (%p)%#v as token %s", tok, tok, name)
+	return tok, nil
+}
 
-	return nil
+// parseOnceBound returns the value of the integer or integer constant of the current token which is a bound of a permutation group. Constant bounds must not be negative.
+func (p *tavorParser) parseOnceBound(c rune) (int, error) {
+	switch c {
+	case scanner.Int:
+		v, err := p.intLiteral()
+		if err != nil {
+			return 0, err
+		}
+
+		return v, nil
+	case scanner.Ident:
+		tok, err := p.getConstantBound(p.scan.TokenText())
+		if err != nil {
+			return 0, err
+		}
+
+		return tok.(*primitives.ConstantInt).Value(), nil
+	}
+
+	return 0, &token.ParserError{
+		Message:  fmt.Sprintf("expected integer bound of permutation group but got %v", scanner.TokenString(c)),
+		Type:     token.ParseErrorInvalidPermutationGroup,
+		Position: p.scan.Pos(),
+	}
 }
 
 func (p *tavorParser) parseTypedTokenDefinition(variableScope *token.VariableScope) (rune, error) {
@@ -1603,8 +2893,52 @@ func (p *tavorParser) parseTypedTokenDefinition(variableScope *token.VariableSco
 			log.Debugf("parseTypedTokenDefinition argument value %d:%v -> %v", p.scan.Line, scanner.TokenString(c), p.scan.TokenText())
 
 			switch c {
-			case scanner.Ident, scanner.String, scanner.Int:
+			case scanner.Ident:
+				if tok, ok := p.constants[p.scan.TokenText()]; ok {
+					switch t := tok.(type) {
+					case *primitives.ConstantInt:
+						v := t.Value()
+						if prefix == "-" {
+							v = -v
+						}
+
+						arguments[arg] = strconv.Itoa(v)
+					case *primitives.ConstantFloat:
+						v := t.Value()
+						if prefix == "-" {
+							v = -v
+						}
+
+						arguments[arg] = primitives.FormatFloat(v)
+					default:
+						arguments[arg] = strconv.Quote(t.String())
+					}
+
+					break
+				}
+
 				arguments[arg] = prefix + p.scan.TokenText()
+			case scanner.Int:
+				v, err := p.intLiteral()
+				if err != nil {
+					return zeroRune, err
+				}
+
+				arguments[arg] = prefix + strconv.Itoa(v)
+			case scanner.Float:
+				v, err := p.floatLiteral()
+				if err != nil {
+					return zeroRune, err
+				}
+
+				arguments[arg] = prefix + primitives.FormatFloat(v)
+			case scanner.String, scanner.RawString:
+				s, err := p.stringLiteral()
+				if err != nil {
+					return zeroRune, err
+				}
+
+				arguments[arg] = prefix + strconv.Quote(s)
 			default:
 				return zeroRune, &token.ParserError{
 					Message:  fmt.Sprintf("invalid argument value %v", c),
@@ -1730,17 +3064,172 @@ func (p *tavorParser) getVariable(fromDefinition string, name string, pos scanne
 }
 
 // ParseTavor reads and parses a Tavor formatted input and returns its token graph representation beginning with the START token.
-// The error return argument is not nil if an error is encountered during reading or parsing the file e.g. a syntax or semantic error.
+// The error return argument is not nil if an error is encountered during reading or parsing the file e.g. a syntax or semantic error. The parser does not stop at the first error. A single parser error is returned as *token.ParserError, multiple parser errors are returned as token.ParserErrors sorted by their positions.
 func ParseTavor(src io.Reader) (token.Token, error) {
-	p := &tavorParser{
+	return ParseTavorToken(src, "START")
+}
+
+// ConstantEnvironmentPrefix is the prefix of environment variables which override constant definitions, e.g. the environment variable "TAVOR_DEFINE_Host" overrides the constant "Host"
+const ConstantEnvironmentPrefix = "TAVOR_DEFINE_"
+
+// ParseTavorWithConstants reads and parses a Tavor formatted input like ParseTavor but the given values override the constant definitions of the input.
+// Constants which are not overridden by the given values can be overridden by environment variables. The resolved values of all constant definitions are returned as strings formatted like in the format, meaning that strings are quoted.
+// The error return argument is not nil if a given value is not declared as constant or if it is not suitable for its constant.
+func ParseTavorWithConstants(src io.Reader, defines map[string]string) (token.Token, map[string]string, error) {
+	return ParseTavorTokenWithConstants(src, "START", defines)
+}
+
+// ParseTavorTokenWithConstants reads and parses a Tavor formatted input like ParseTavorWithConstants but returns the token graph representation beginning with the given token definition instead of the START token.
+// The error return argument is not nil if an error is encountered during reading or parsing the file, if the token is not defined or if a given value is not suitable for its constant.
+func ParseTavorTokenWithConstants(src io.Reader, name string, defines map[string]string) (token.Token, map[string]string, error) {
+	p := newTavorParser()
+	p.defines = defines
+	p.start = name
+
+	if err := p.parse(src); err != nil {
+		return nil, nil, err
+	}
+
+	constants := make(map[string]string, len(p.constants))
+	for name, tok := range p.constants {
+		switch tok.(type) {
+		case *primitives.ConstantInt, *primitives.ConstantFloat:
+			constants[name] = tok.String()
+		default:
+			constants[name] = strconv.Quote(tok.String())
+		}
+	}
+
+	if err := p.checkStart(name); err != nil {
+		return nil, nil, err
+	}
+
+	tok, err := p.finish(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tok, constants, nil
+}
+
+// ParseTavorToken reads and parses a Tavor formatted input like ParseTavor but returns the token graph representation beginning with the given token definition instead of the START token.
+// The error return argument is not nil if an error is encountered during reading or parsing the file or if the token is not defined.
+func ParseTavorToken(src io.Reader, name string) (token.Token, error) {
+	p := newTavorParser()
+	p.start = name
+
+	if err := p.parse(src); err != nil {
+		return nil, err
+	}
+
+	if err := p.checkStart(name); err != nil {
+		return nil, err
+	}
+
+	return p.finish(name)
+}
+
+// Exports reads and parses a Tavor formatted input and returns the names of all entry points of the format, which are the START token, if it is defined, and all exported token definitions.
+// The error return argument is not nil if an error is encountered during reading or parsing the file.
+func Exports(src io.Reader) ([]string, error) {
+	p := newTavorParser()
+
+	if err := p.parse(src); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, name := range p.entryPoints() {
+		if _, ok := p.definitions[name]; ok {
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
+
+// entryPoints returns the names of the START token, all exported token definitions and the requested entry point. Every entry point is a root of the token graph.
+func (p *tavorParser) entryPoints() []string {
+	names := []string{"START"}
+	seen := map[string]struct{}{
+		"START": {},
+	}
+
+	for _, name := range append(append([]string{}, p.exports...), p.start) {
+		if _, ok := seen[name]; ok || name == "" {
+			continue
+		}
+
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// checkStart returns an error if the given token definition cannot be used as entry point of the token graph
+func (p *tavorParser) checkStart(name string) error {
+	if _, ok := p.definitions[name]; ok {
+		return nil
+	}
+
+	if name == "START" {
+		return &token.ParserError{
+			Message:  "no START token defined",
+			Type:     token.ParseErrorNoStart,
+			Position: p.scan.Pos(),
+		}
+	}
+
+	return &token.ParserError{
+		Message:  fmt.Sprintf("token %q is not defined", name),
+		Type:     token.ParseErrorTokenNotDefined,
+		Position: p.scan.Pos(),
+	}
+}
+
+// ParseTavorTokenAttributes reads and parses a Tavor formatted input and returns the names of all token attributes which can be used with the given token definition.
+// The error return argument is not nil if an error is encountered during reading or parsing the file or if the token is not defined.
+func ParseTavorTokenAttributes(src io.Reader, name string) ([]string, error) {
+	p := newTavorParser()
+
+	if err := p.parse(src); err != nil {
+		return nil, err
+	}
+
+	usage, ok := p.definitions[name]
+	if !ok {
+		return nil, &token.ParserError{
+			Message:  fmt.Sprintf("token %q is not defined", name),
+			Type:     token.ParseErrorTokenNotDefined,
+			Position: p.scan.Pos(),
+		}
+	}
+
+	return TokenAttributes(usage.token), nil
+}
+
+func newTavorParser() *tavorParser {
+	return &tavorParser{
 		earlyUse:    make(map[string][]tokenUsage),
 		lookup:      make(map[string]tokenUsage),
 		lookupUsage: make(map[token.Token]struct{}),
 		used:        make(map[string][]tokenUsage),
 
 		called: make(map[string][]call),
+
+		definitions: make(map[string]tokenUsage),
+		positions:   make(map[token.Token]scanner.Position),
+		references:  make(map[token.Token]string),
+
+		constants: make(map[string]token.Token),
+
+		graphItems: make(map[*graphs.Graph]struct{}),
 	}
+}
 
+// parse reads the whole format file and resolves all usages but leaves the token graph of the definitions untouched.
+func (p *tavorParser) parse(src io.Reader) error {
 	log.Debug("start parsing tavor file")
 
 	p.scan.Init(src)
@@ -1753,22 +3242,48 @@ func ParseTavor(src io.Reader) (token.Token, error) {
 	variableScope := token.NewVariableScope()
 
 	if err := p.parseGlobalScope(variableScope); err != nil {
-		return nil, err
+		return err
 	}
 
-	if _, ok := p.lookup["START"]; !ok {
-		return nil, &token.ParserError{
+	var defines []string
+	for name := range p.defines {
+		defines = append(defines, name)
+	}
+	sort.Strings(defines)
+
+	for _, name := range defines {
+		if _, ok := p.constants[name]; !ok {
+			p.errs = append(p.errs, &token.ParserError{
+				Message:  fmt.Sprintf("constant %q is defined but not declared in the format", name),
+				Type:     token.ParseErrorUnknownConstant,
+				Position: p.scan.Pos(),
+			})
+		}
+	}
+
+	// errors in the definitions would lead to follow-up errors while resolving the usages
+	if err := p.error(); err != nil {
+		return err
+	}
+
+	p.checkTests()
+
+	// START is optional if the format has other entry points
+	if _, ok := p.lookup["START"]; !ok && len(p.exports) == 0 && (p.start == "" || p.start == "START") {
+		p.errs = append(p.errs, &token.ParserError{
 			Message:  "no START token defined",
 			Type:     token.ParseErrorNoStart,
 			Position: p.scan.Pos(), // TODO correct position
-		}
+		})
 	}
 
-	p.used["START"] = append(p.used["START"], tokenUsage{
-		token:         nil,
-		position:      p.scan.Position,
-		variableScope: variableScope,
-	})
+	for _, name := range p.entryPoints() {
+		p.used[name] = append(p.used[name], tokenUsage{
+			token:         nil,
+			position:      p.scan.Position,
+			variableScope: variableScope,
+		})
+	}
 
 	for name, uses := range p.earlyUse {
 	USE:
@@ -1778,36 +3293,48 @@ func ParseTavor(src io.Reader) (token.Token, error) {
 					if vv, ok := v.(token.VariableToken); ok {
 						err := p.setEarlyUsage(name, variables.NewVariableValue(vv))
 						if err != nil {
-							return nil, err
+							if err := p.addError(err); err != nil {
+								return err
+							}
 						}
 
 						break USE
 					} else {
-						return nil, &token.ParserError{
+						p.errs = append(p.errs, &token.ParserError{
 							Message:  fmt.Sprintf("variable token %q is not always used as a variable", name),
 							Type:     token.ParseErrorNotAlwaysUsedAsAVariable,
 							Position: use.position,
-						}
+						})
+
+						break USE
 					}
 				}
 
 				// last chance that this token is a variable but it must be ALWAYS a variable
 				if v, err := p.getVariable(use.definitionName, name, use.position); err != nil {
-					return nil, err
+					if err := p.addError(err); err != nil {
+						return err
+					}
+
+					break USE
 				} else if v != nil {
 					err = p.setEarlyUsage(name, variables.NewVariableValue(v))
 					if err != nil {
-						return nil, err
+						if err := p.addError(err); err != nil {
+							return err
+						}
 					}
 
 					break USE
 				}
 
-				return nil, &token.ParserError{
+				p.errs = append(p.errs, &token.ParserError{
 					Message:  fmt.Sprintf("token %q is not defined", name),
 					Type:     token.ParseErrorTokenNotDefined,
 					Position: use.position,
-				}
+				})
+
+				break USE
 			}
 		}
 	}
@@ -1833,7 +3360,11 @@ func ParseTavor(src io.Reader) (token.Token, error) {
 		// look for the token in the call scope
 		if tok == nil {
 			if v, err := p.getVariable(forwardUse.definitionName, forwardUse.tokenName, forwardUse.tokenPosition); err != nil {
-				return nil, err
+				if err := p.addError(err); err != nil {
+					return err
+				}
+
+				continue
 			} else if v != nil {
 				tok = v
 				if t, ok := tok.(*primitives.Pointer); ok {
@@ -1844,41 +3375,57 @@ func ParseTavor(src io.Reader) (token.Token, error) {
 
 		// give up, there is no token we can use
 		if tok == nil {
-			return nil, &token.ParserError{
+			p.errs = append(p.errs, &token.ParserError{
 				Message:  fmt.Sprintf("token or variable %q is not defined", forwardUse.tokenName),
 				Type:     token.ParseErrorTokenNotDefined,
 				Position: forwardUse.tokenPosition,
-			}
+			})
+
+			continue
 		}
 
+		p.used[forwardUse.tokenName] = append(p.used[forwardUse.tokenName], tokenUsage{
+			token:          nil,
+			position:       forwardUse.tokenPosition,
+			variableScope:  forwardUse.variableScope,
+			definitionName: forwardUse.definitionName,
+		})
+
 		// TODO zeroRune must be replaced with "c" we cannot scan in this selectTokenAttribute call
 		_, rtok, err := p.selectTokenAttribute(forwardUse.definitionName, tok, forwardUse.tokenName, forwardUse.attribute, forwardUse.attributePosition, forwardUse.operator, forwardUse.operatorToken, zeroRune, variableScope)
 		if err != nil {
-			return nil, err
+			if err := p.addError(err); err != nil {
+				return err
+			}
+
+			continue
 		}
 
 		err = forwardUse.pointer.Set(rtok)
 		if err != nil {
-			return nil, err
+			return err
 		}
-
-		p.used[forwardUse.tokenName] = append(p.used[forwardUse.tokenName], tokenUsage{
-			token:         nil,
-			position:      forwardUse.tokenPosition,
-			variableScope: forwardUse.variableScope,
-		})
 	}
 
 	for name, use := range p.lookup {
 		if _, ok := p.used[name]; !ok {
-			return nil, &token.ParserError{
+			// constants can be used in repeats and typed token arguments or just be defined to be overridden
+			if _, ok := p.constants[name]; ok {
+				continue
+			}
+
+			p.errs = append(p.errs, &token.ParserError{
 				Message:  fmt.Sprintf("token %q declared but not used", name),
 				Type:     token.ParseErrorUnusedToken,
 				Position: use.position,
-			}
+			})
 		}
 	}
 
+	if err := p.error(); err != nil {
+		return err
+	}
+
 	for _, variable := range p.variableUsages {
 		tok := variable.(token.ForwardToken).InternalGet()
 
@@ -1894,7 +3441,7 @@ func ParseTavor(src io.Reader) (token.Token, error) {
 
 					err := variable.(token.ForwardToken).InternalReplace(tok, c)
 					if err != nil {
-						return nil, err
+						return err
 					}
 
 					break
@@ -1903,18 +3450,36 @@ func ParseTavor(src io.Reader) (token.Token, error) {
 		}
 	}
 
-	start := p.lookup["START"].token
+	return nil
+}
+
+// finish transforms the parsed definitions into the final token graph beginning with the given token definition.
+func (p *tavorParser) finish(name string) (token.Token, error) {
+	start := p.lookup[name].token
+
+	// the definitions are sorted so that the resets are always in the same order
+	var names []string
+	for n := range p.lookup {
+		names = append(names, n)
+	}
+	sort.Strings(names)
 
-	// TODO this could be done much better especially we could add ALL resets here not just sequences
+	// TODO this could be done much better especially we could add ALL resets here not just sequences and graphs
 	var automaticResets []token.Token
-	for _, usage := range p.lookup {
-		tok := usage.token
+	for _, n := range names {
+		tok := p.lookup[n].token
 		if t, ok := tok.(token.Resolve); ok {
 			tok = t.Resolve()
 		}
 
-		if tok, ok := tok.(*sequences.Sequence); ok {
+		switch tok := tok.(type) {
+		case *sequences.Sequence:
 			automaticResets = append(automaticResets, tok.ResetItem())
+		case *graphs.Graph:
+			// graphs which are used by their token attributes are chosen once for every generation regardless of which attributes are used
+			if _, ok := p.graphItems[tok]; ok {
+				automaticResets = append(automaticResets, tok.ResetItem())
+			}
 		}
 	}
 	if len(automaticResets) != 0 {
@@ -18,9 +18,11 @@ import (
 	"github.com/zimmski/tavor/token/conditions"
 	"github.com/zimmski/tavor/token/constraints"
 	"github.com/zimmski/tavor/token/expressions"
+	"github.com/zimmski/tavor/token/graphs"
 	"github.com/zimmski/tavor/token/lists"
 	"github.com/zimmski/tavor/token/primitives"
 	"github.com/zimmski/tavor/token/sequences"
+	"github.com/zimmski/tavor/token/symbols"
 	"github.com/zimmski/tavor/token/variables"
 )
 
@@ -263,6 +265,51 @@ func TestTavorParseErrors(t *testing.T) {
 	Nil(t, tok)
 }
 
+func TestTavorParseErrorsRecovery(t *testing.T) {
+	validateErrors := func(format string, expected []token.ParserErrorType, lines []int) {
+		tok, err := ParseTavor(strings.NewReader(format))
+		Nil(t, tok)
+
+		errs, ok := err.(token.ParserErrors)
+		True(t, ok)
+
+		var types []token.ParserErrorType
+		var errLines []int
+		for _, e := range errs {
+			types = append(types, e.Type)
+			errLines = append(errLines, e.Position.Line)
+		}
+
+		Equal(t, expected, types)
+		Equal(t, lines, errLines)
+	}
+
+	// syntax errors are reported for every definition
+	validateErrors(
+		"A = 1 )\nB =\n  = 3\nSTART = A B C\n3 = 4\nC = (\"c\",\n  \"d\"\n",
+		[]token.ParserErrorType{token.ParseErrorExpectRune, token.ParseErrorEmptyTokenDefinition, token.ParseErrorInvalidTokenName, token.ParseErrorExpectRune},
+		[]int{1, 3, 5, 8},
+	)
+
+	// multi line definitions are skipped as a whole
+	validateErrors(
+		"START = A B,\n  ( C\n+2 = 3\nA = 1\nB = 2 2 )\n",
+		[]token.ParserErrorType{token.ParseErrorExpectRune, token.ParseErrorExpectRune},
+		[]int{3, 5},
+	)
+
+	// usage errors are reported for every token but only if there is no syntax error
+	validateErrors(
+		"START = A $B.Count C\nD = 1\n",
+		[]token.ParserErrorType{token.ParseErrorTokenNotDefined, token.ParseErrorTokenNotDefined, token.ParseErrorTokenNotDefined, token.ParseErrorUnusedToken},
+		[]int{1, 1, 1, 2},
+	)
+
+	// a single error is returned as is
+	_, err := ParseTavor(strings.NewReader("START = (1\nA = 1\n"))
+	Equal(t, token.ParseErrorExpectRune, err.(*token.ParserError).Type)
+}
+
 func TestTavorParserSimple(t *testing.T) {
 	var tok token.Token
 	var err error
@@ -547,6 +594,40 @@ func TestTavorParserAlternationsAndGroupings(t *testing.T) {
 		primitives.NewConstantInt(2),
 		primitives.NewConstantInt(3),
 	)))
+
+	// once list with a range of chosen tokens
+	tok, err = ParseTavor(strings.NewReader("START = @1,2(1 | 2 | 3)\n"))
+	Nil(t, err)
+	Equal(t, tok, primitives.NewScope(lists.NewOnceWithRange(
+		1, 2,
+		primitives.NewConstantInt(1),
+		primitives.NewConstantInt(2),
+		primitives.NewConstantInt(3),
+	)))
+
+	// once list with an exact number of chosen tokens given by a constant
+	tok, err = ParseTavor(strings.NewReader("Const N = 2\nSTART = @N(1 | 2 | 3)\n"))
+	Nil(t, err)
+	Equal(t, tok, primitives.NewScope(lists.NewOnceWithRange(
+		2, 2,
+		primitives.NewConstantInt(1),
+		primitives.NewConstantInt(2),
+		primitives.NewConstantInt(3),
+	)))
+
+	// once list cannot choose more tokens than it has
+	_, err = ParseTavor(strings.NewReader("START = @2,4(1 | 2 | 3)\n"))
+	if errs, ok := err.(token.ParserErrors); ok {
+		err = errs[0]
+	}
+	Equal(t, token.ParseErrorInvalidPermutationGroup, err.(*token.ParserError).Type)
+
+	// once list cannot choose a negative number of tokens
+	_, err = ParseTavor(strings.NewReader("Const N = -1\nSTART = @N(1 | 2 | 3)\n"))
+	Equal(t, token.ParseErrorInvalidConstantValue, err.(*token.ParserError).Type)
+
+	_, err = ParseTavor(strings.NewReader("Const N = -1\nSTART = @N,2(1 | 2 | 3)\n"))
+	Equal(t, token.ParseErrorInvalidConstantValue, err.(*token.ParserError).Type)
 }
 
 func TestTavorParserTokenAttributes(t *testing.T) {
@@ -600,6 +681,40 @@ func TestTavorParserTokenAttributes(t *testing.T) {
 	}
 }
 
+func TestTavorParserSources(t *testing.T) {
+	tok, err := ParseTavor(strings.NewReader("Digit = 1 | 2\nSTART = \"a\" +(Digit)\n"))
+	Nil(t, err)
+
+	validateSource := func(tok token.Token, name string, line int, column int) {
+		src, ok := token.GetSource(tok)
+		True(t, ok)
+		Equal(t, name, src.Name)
+		Equal(t, line, src.Position.Line)
+		Equal(t, column, src.Position.Column)
+	}
+
+	validateSource(tok, "START", 2, 1)
+
+	concatenation := tok.(*primitives.Scope).InternalGet().(*lists.Concatenation)
+	validateSource(concatenation, "START", 2, 1)
+
+	a, _ := concatenation.InternalGet(0)
+	validateSource(a, "START", 2, 9)
+
+	repeat, _ := concatenation.InternalGet(1)
+	validateSource(repeat, "START", 2, 13)
+
+	// tokens of a referenced definition keep the origin of their definition
+	digit, _ := repeat.(*lists.Repeat).InternalGet(0)
+	validateSource(digit, "Digit", 1, 1)
+
+	one := digit.(*primitives.Scope).InternalGet().(*lists.One)
+	validateSource(one, "Digit", 1, 9)
+
+	two, _ := one.InternalGet(1)
+	validateSource(two, "Digit", 1, 13)
+}
+
 func TestTavorParserTypedTokens(t *testing.T) {
 	var tok token.Token
 	var err error
@@ -693,6 +808,77 @@ func TestTavorParserTypedTokens(t *testing.T) {
 			primitives.NewScope(s.ResetItem()),
 		))
 	}
+
+	// Graph
+	{
+		tok, err = ParseTavor(strings.NewReader(
+			"$Spec Graph\nSTART = Spec\n",
+		))
+		Nil(t, err)
+		Equal(t, tok, primitives.NewScope(graphs.NewGraph(5, 30, false, false, false, "%d\n", "%d %d\n")))
+
+		tok, err = ParseTavor(strings.NewReader(
+			"$Spec Graph = nodes: 3,\ndensity: 100,\nacyclic: true,\nnode: \"\",\nedge: \"%d->%d,\"\nSTART = Spec\n",
+		))
+		Nil(t, err)
+		Equal(t, tok, primitives.NewScope(graphs.NewGraph(3, 100, true, false, false, "", "%d->%d,")))
+
+		g := graphs.NewGraph(4, 30, false, false, true, "%d ", "%d %d\n")
+		tok, err = ParseTavor(strings.NewReader(
+			"$Spec Graph = nodes: 4,\ntree: true,\nnode: \"%d \"\nSTART = $Spec.Nodes $Spec.Edges $Spec.Path $Spec.Root\n",
+		))
+		Nil(t, err)
+		Equal(t, tok, lists.NewConcatenation(
+			g.ResetItem(),
+			primitives.NewScope(lists.NewConcatenation(
+				g.NodesItem(),
+				g.EdgesItem(),
+				g.PathItem(),
+				g.RootItem(),
+			)),
+		))
+
+		// the graph is chosen for every generation even if no attribute of the graph which is used chooses it
+		tok, err = ParseTavor(strings.NewReader(
+			"$Spec Graph = nodes: 4\nSTART = ?($Spec.Nodes) $Spec.Root\n",
+		))
+		Nil(t, err)
+		reset, err := tok.(token.ListToken).InternalGet(0)
+		Nil(t, err)
+		Equal(t, graphs.NewGraph(4, 30, false, false, false, "%d\n", "%d %d\n").Permutations(), reset.Permutations())
+		roots := make(map[string]struct{})
+		for i := uint(0); i < 4; i++ {
+			Nil(t, reset.Permutation(i))
+
+			roots[tok.String()] = struct{}{}
+		}
+		Equal(t, 4, len(roots))
+
+		// the path operator can walk the edges of a graph
+		tok, err = ParseTavor(strings.NewReader(`
+			$Spec Graph = nodes: 4,
+			              tree: true,
+			              node: "%d "
+
+			START = $Spec.Root ${Spec.Edges path from (Spec.Root) over (e.Item(0)) connect by (e.Item(1)) without (0)}
+		`))
+		Nil(t, err)
+
+		// the walk begins at the root and reaches every node of the tree
+		walk := tok.String()
+		Equal(t, 5, len(walk))
+		Equal(t, walk[0], walk[1])
+
+		_, err = ParseTavor(strings.NewReader(
+			"$Spec Graph = density: 101\nSTART = Spec\n",
+		))
+		Equal(t, token.ParseErrorInvalidArgumentValue, err.(*token.ParserError).Type)
+
+		_, err = ParseTavor(strings.NewReader(
+			"$Spec Graph = edge: \"%d\"\nSTART = Spec\n",
+		))
+		Equal(t, token.ParseErrorInvalidArgumentValue, err.(*token.ParserError).Type)
+	}
 }
 
 func TestTavorParserExpressions(t *testing.T) {
@@ -735,6 +921,47 @@ func TestTavorParserExpressions(t *testing.T) {
 		)))
 	}
 
+	// function use in expression
+	{
+		tok, err = ParseTavor(strings.NewReader(`
+			START = "Abc"<A> ${upper(A.Value)} ${pad_left(reverse(A.Value), 5, "-")} ${concat(substr(A.Value, 0, 2), repeat("x", 2))} ${lower("D")}
+		`))
+		Nil(t, err)
+		Equal(t, "AbcABC--cbAAbxxd", tok.String())
+
+		tok, err = ParseTavor(strings.NewReader(`
+			START = ${upper(A)}
+			A = "a" | "b"
+		`))
+		Nil(t, err)
+		Equal(t, "A", tok.String())
+		Equal(t, 2, tok.PermutationsAll())
+
+		_, err = ParseTavor(strings.NewReader(`
+			START = ${unknown("a")}
+		`))
+		Equal(t, token.ParseErrorUnknownFunction, err.(*token.ParserError).Type)
+
+		_, err = ParseTavor(strings.NewReader(`
+			START = ${substr("a", 1)}
+		`))
+		Equal(t, token.ParseErrorInvalidFunctionArguments, err.(*token.ParserError).Type)
+
+		tok, err = ParseTavor(strings.NewReader(`
+			$Size Int = from: 1,
+				to: 300
+			START = ${hex(Size)} ";" ${fmt("%04d", Size)}
+		`))
+		Nil(t, err)
+
+		errs := ParseInternal(tok, strings.NewReader("ff;0042"))
+		Nil(t, errs)
+		Equal(t, "ff;0042", tok.String())
+
+		errs = ParseInternal(tok, strings.NewReader("fff;0042"))
+		NotNil(t, errs)
+	}
+
 	// simple expression
 	{
 		s := sequences.NewSequence(1, 1)
@@ -845,6 +1072,99 @@ func TestTavorParserExpressions(t *testing.T) {
 		Nil(t, err)
 		Equal(t, "103123->231", tok.String())
 	}
+
+	// set operators
+	{
+		tok, err = ParseTavor(strings.NewReader(
+			"START = ${1 union (2, \"a\")}\n",
+		))
+		Nil(t, err)
+		Equal(t, tok, primitives.NewScope(expressions.NewUnionSet(
+			primitives.NewConstantInt(1),
+			[]token.Token{
+				primitives.NewConstantInt(2),
+				primitives.NewConstantString("a"),
+			},
+		)))
+		Equal(t, "12a", tok.String())
+
+		tok, err = ParseTavor(strings.NewReader(
+			"START = ${1 in (1, 2)} ${3 not in 4} ${1 intersection (2)} ${1 difference 2 union 3}\n",
+		))
+		Nil(t, err)
+		Equal(t, tok, primitives.NewScope(lists.NewConcatenation(
+			expressions.NewInSet(
+				primitives.NewConstantInt(1),
+				[]token.Token{
+					primitives.NewConstantInt(1),
+					primitives.NewConstantInt(2),
+				},
+			),
+			expressions.NewNotInSet(
+				primitives.NewConstantInt(3),
+				[]token.Token{
+					primitives.NewConstantInt(4),
+				},
+			),
+			expressions.NewIntersectionSet(
+				primitives.NewConstantInt(1),
+				[]token.Token{
+					primitives.NewConstantInt(2),
+				},
+			),
+			expressions.NewDifferenceSet(
+				primitives.NewConstantInt(1),
+				[]token.Token{
+					expressions.NewUnionSet(
+						primitives.NewConstantInt(2),
+						[]token.Token{
+							primitives.NewConstantInt(3),
+						},
+					),
+				},
+			),
+		)))
+		Equal(t, "131", tok.String())
+	}
+
+	// set operators with tokens and variables
+	{
+		tok, err = ParseTavor(strings.NewReader(`
+				START = Used<used> Print
+
+				Print = ${Color not in (used)} ${Color in (used)} {if used in ("red")}"R"{else}"G"{endif}
+
+				Color = "red" | "green" | "blue"
+
+				Used = "red" | "green"
+			`))
+		Nil(t, err)
+
+		variable, _ := tok.(*primitives.Scope).InternalGet().(*lists.Concatenation).InternalGet(0)
+		used := variable.(*variables.Variable).InternalGet().(*primitives.Scope).InternalGet()
+
+		print, _ := tok.(*primitives.Scope).InternalGet().(*lists.Concatenation).InternalGet(1)
+		l := print.(*primitives.Scope).InternalGet().(*lists.Concatenation)
+		notIn, _ := l.InternalGet(0)
+		in, _ := l.InternalGet(1)
+
+		Nil(t, notIn.Permutation(0))
+		Nil(t, in.Permutation(0))
+		Equal(t, "redgreenredR", tok.String())
+
+		Nil(t, used.Permutation(1))
+		Nil(t, notIn.Permutation(0))
+		Nil(t, in.Permutation(0))
+		Equal(t, "greenredgreenG", tok.String())
+
+		// the value of the first operand is kept as long as it fulfills the check
+		Nil(t, notIn.Permutation(2))
+		Equal(t, "greenredgreenG", tok.String())
+
+		// the first operand is chosen while the token is output, beginning with the permutation of the set token
+		Nil(t, used.Permutation(0))
+		Equal(t, "redblueredR", tok.String())
+	}
 }
 
 func TestTavorParserAndCuriousCaseOfFuzzing(t *testing.T) {
@@ -1448,6 +1768,27 @@ func TestTavorParserCharacterClasses(t *testing.T) {
 
 		Equal(t, " ", tok.String())
 	}
+	{
+		// quotes, comment characters and nested classes are part of the pattern
+		tok, err := ParseTavor(strings.NewReader(`
+			START = ["//] [^"\\] [a-z-[aeiou]] "]"
+		`))
+		Nil(t, err)
+
+		errs := ParseInternal(tok, strings.NewReader(`/xb]`))
+		Nil(t, errs)
+
+		errs = ParseInternal(tok, strings.NewReader(`/"b]`))
+		NotNil(t, errs)
+	}
+	{
+		// errors are positioned inside the pattern
+		tok, err := ParseTavor(strings.NewReader("START = \"a\" [a-\\p{Foo}]\n"))
+		Nil(t, tok)
+		Equal(t, token.ParseErrorInvalidCharacterClass, err.(*token.ParserError).Type)
+		Equal(t, 1, err.(*token.ParserError).Position.Line)
+		Equal(t, 16, err.(*token.ParserError).Position.Column)
+	}
 }
 
 func TestTavorParserVariables(t *testing.T) {
@@ -1673,6 +2014,106 @@ func TestTavorParserIfElseIfElsedd(t *testing.T) {
 	}
 }
 
+func TestTavorParserSwitch(t *testing.T) {
+	// basic switch with default
+	{
+		tok, err := ParseTavor(strings.NewReader(`
+			START = Choose<var> Print
+
+			Choose = 1 | 2 | 3 | 4
+
+			Print = {switch var.Value}{case 1} "one" {case 2, 3} "two" "or three" {default} "other" {endswitch}
+		`))
+		Nil(t, err)
+
+		variable, _ := tok.(*primitives.Scope).InternalGet().(*lists.Concatenation).InternalGet(0)
+		one := variable.(*variables.Variable).InternalGet().(*primitives.Scope).InternalGet()
+
+		Equal(t, "1one", tok.String())
+
+		Nil(t, one.Permutation(1))
+		Equal(t, "2twoor three", tok.String())
+
+		Nil(t, one.Permutation(2))
+		Equal(t, "3twoor three", tok.String())
+
+		Nil(t, one.Permutation(3))
+		Equal(t, "4other", tok.String())
+
+		// inputs are parsed through the chosen case
+		errs := ParseInternal(tok, strings.NewReader("3twoor three"))
+		Nil(t, errs)
+		Equal(t, "3twoor three", tok.String())
+
+		errs = ParseInternal(tok, strings.NewReader("1other"))
+		NotNil(t, errs)
+	}
+	// continued definition without default
+	{
+		tok, err := ParseTavor(strings.NewReader(`
+			START = 1<var> {switch var.Value}{case 2} 2 {endswitch} 3
+		`))
+		Nil(t, err)
+
+		nVariable := variables.NewVariable("var", primitives.NewConstantInt(1))
+
+		Equal(t, tok, primitives.NewScope(lists.NewConcatenation(
+			nVariable,
+			conditions.NewSwitch(
+				variables.NewVariableValue(nVariable),
+				conditions.SwitchCase{
+					Values: []token.Token{primitives.NewConstantInt(2)},
+					Body:   primitives.NewConstantInt(2),
+				},
+			),
+			primitives.NewConstantInt(3),
+		)))
+
+		Equal(t, "13", tok.String())
+		Equal(t, 1, tok.Permutations())
+	}
+	// cases without a body
+	{
+		tok, err := ParseTavor(strings.NewReader(`
+			START = 1<var> {switch var.Value}{case 1}{default} 2 {endswitch} 3
+		`))
+		Nil(t, err)
+		Equal(t, "13", tok.String())
+
+		errs := ParseInternal(tok, strings.NewReader("13"))
+		Nil(t, errs)
+	}
+	// every case has its own scope
+	{
+		_, err := ParseTavor(strings.NewReader(`
+			START = 1<var> {switch var.Value}{case 1} 2<inner> {endswitch} $inner.Value
+		`))
+		NotNil(t, err)
+	}
+	// errors
+	{
+		_, err := ParseTavor(strings.NewReader(`
+			START = 1<var> {switch var.Value}{endswitch}
+		`))
+		Equal(t, token.ParseErrorInvalidSwitch, err.(*token.ParserError).Type)
+
+		_, err = ParseTavor(strings.NewReader(`
+			START = 1<var> {switch var.Value}{default} 1 {default} 2 {endswitch}
+		`))
+		Equal(t, token.ParseErrorInvalidSwitch, err.(*token.ParserError).Type)
+
+		_, err = ParseTavor(strings.NewReader(`
+			START = 1<var> {switch var.Value}{if var.Value == 1} 1 {endswitch}
+		`))
+		Equal(t, token.ParseErrorInvalidSwitch, err.(*token.ParserError).Type)
+
+		_, err = ParseTavor(strings.NewReader(`
+			START = 1<var> {switch var.Value}{case 1} 1
+		`))
+		NotNil(t, err)
+	}
+}
+
 func TestParseTavorExpressionOperatorInclude(t *testing.T) {
 	tmpfile, err := ioutil.TempFile("", "")
 	Nil(t, err)
@@ -1689,3 +2130,605 @@ func TestParseTavorExpressionOperatorInclude(t *testing.T) {
 	Nil(t, err)
 	Equal(t, tok, primitives.NewScope(primitives.NewConstantInt(123)))
 }
+
+func TestTavorParserConstants(t *testing.T) {
+	var tok token.Token
+	var err error
+
+	// terminals
+	tok, err = ParseTavor(strings.NewReader(
+		"Const Host = \"localhost\"\nConst Port = 80\nConst Alias = Host\nSTART = Host \":\" Port \" \" Alias\n",
+	))
+	Nil(t, err)
+	Equal(t, "localhost:80 localhost", tok.String())
+
+	// constants can be used before they are defined
+	tok, err = ParseTavor(strings.NewReader(
+		"START = Port\nConst Port = -80\n",
+	))
+	Nil(t, err)
+	Equal(t, "-80", tok.String())
+
+	// repeat bounds
+	tok, err = ParseTavor(strings.NewReader(
+		"Const N = 3\nSTART = +N(\"a\")\n",
+	))
+	Nil(t, err)
+	Equal(t, "aaa", tok.String())
+
+	tok, err = ParseTavor(strings.NewReader(
+		"Const From = 1\nConst To = 3\nSTART = +From,To(\"a\")\n",
+	))
+	Nil(t, err)
+	expected, err := ParseTavor(strings.NewReader(
+		"START = +1,3(\"a\")\n",
+	))
+	Nil(t, err)
+	Equal(t, expected.PermutationsAll(), tok.PermutationsAll())
+
+	// typed token arguments
+	tok, err = ParseTavor(strings.NewReader(
+		"Const To = 10\n$Spec Int = from: -To,\nto: To\nSTART = Spec\n",
+	))
+	Nil(t, err)
+	Equal(t, tok, primitives.NewScope(primitives.NewRangeInt(-10, 10)))
+
+	// overrides
+	var constants map[string]string
+	tok, constants, err = ParseTavorWithConstants(strings.NewReader(
+		"Const N = 1\nConst Host = \"localhost\"\nSTART = Host +N(\"a\")\n",
+	), map[string]string{
+		"N":    "2",
+		"Host": "example",
+	})
+	Nil(t, err)
+	Equal(t, "exampleaa", tok.String())
+	Equal(t, map[string]string{
+		"N":    "2",
+		"Host": `"example"`,
+	}, constants)
+
+	os.Setenv(ConstantEnvironmentPrefix+"N", "4")
+	tok, _, err = ParseTavorWithConstants(strings.NewReader(
+		"Const N = 1\nSTART = +N(\"a\")\n",
+	), nil)
+	Nil(t, err)
+	Equal(t, "aaaa", tok.String())
+
+	tok, _, err = ParseTavorWithConstants(strings.NewReader(
+		"Const N = 1\nSTART = +N(\"a\")\n",
+	), map[string]string{
+		"N": "2",
+	})
+	Nil(t, err)
+	Equal(t, "aa", tok.String())
+	os.Unsetenv(ConstantEnvironmentPrefix + "N")
+
+	// errors
+	_, _, err = ParseTavorWithConstants(strings.NewReader(
+		"Const N = 1\nSTART = +N(\"a\")\n",
+	), map[string]string{
+		"N": "a",
+	})
+	Equal(t, token.ParseErrorInvalidConstantValue, err.(token.ParserErrors)[0].Type)
+
+	_, _, err = ParseTavorWithConstants(strings.NewReader(
+		"Const N = 1\nSTART = +N(\"a\")\n",
+	), map[string]string{
+		"M": "1",
+	})
+	Equal(t, token.ParseErrorUnknownConstant, err.(*token.ParserError).Type)
+
+	_, err = ParseTavor(strings.NewReader(
+		"START = +N(\"a\")\nConst N = 1\n",
+	))
+	Equal(t, token.ParseErrorUnknownConstant, err.(*token.ParserError).Type)
+
+	_, err = ParseTavor(strings.NewReader(
+		"Const N = \"a\"\nSTART = +N(\"a\")\n",
+	))
+	Equal(t, token.ParseErrorInvalidConstantValue, err.(*token.ParserError).Type)
+
+	_, err = ParseTavor(strings.NewReader(
+		"Const N = (1)\nSTART = N\n",
+	))
+	Equal(t, token.ParseErrorInvalidConstantValue, err.(*token.ParserError).Type)
+
+	// negative repeat bounds
+	_, err = ParseTavor(strings.NewReader(
+		"Const N = -2\nSTART = +N(\"a\")\n",
+	))
+	Equal(t, token.ParseErrorInvalidConstantValue, err.(*token.ParserError).Type)
+
+	_, err = ParseTavor(strings.NewReader(
+		"Const N = -2\nSTART = +0,N(\"a\")\n",
+	))
+	Equal(t, token.ParseErrorInvalidConstantValue, err.(*token.ParserError).Type)
+
+	_, _, err = ParseTavorWithConstants(strings.NewReader(
+		"Const N = 1\nSTART = +N(\"a\")\n",
+	), map[string]string{
+		"N": "-1",
+	})
+	Equal(t, token.ParseErrorInvalidConstantValue, err.(*token.ParserError).Type)
+
+	// lower repeat bounds greater than upper repeat bounds
+	_, err = ParseTavor(strings.NewReader(
+		"Const From = 3\nConst To = 2\nSTART = +From,To(\"a\")\n",
+	))
+	Equal(t, token.ParseErrorInvalidRepeatBounds, err.(*token.ParserError).Type)
+
+	_, err = ParseTavor(strings.NewReader(
+		"START = +3,2(\"a\")\n",
+	))
+	Equal(t, token.ParseErrorInvalidRepeatBounds, err.(*token.ParserError).Type)
+}
+
+func TestTavorParserLiterals(t *testing.T) {
+	var tok token.Token
+	var err error
+
+	// integers
+	tok, err = ParseTavor(strings.NewReader(
+		"START = 0x1F \" \" 0o17 \" \" 017 \" \" 0b101 \" \" 1_000\n",
+	))
+	Nil(t, err)
+	Equal(t, "31 15 15 5 1000", tok.String())
+
+	tok, err = ParseTavor(strings.NewReader(
+		"START = +0x2(\"a\")\n",
+	))
+	Nil(t, err)
+	Equal(t, "aa", tok.String())
+
+	// floats
+	tok, err = ParseTavor(strings.NewReader(
+		"START = 1.50 \" \" 2. \" \" .5 \" \" 1e3 \" \" 0x1p-2\n",
+	))
+	Nil(t, err)
+	Equal(t, "1.5 2.0 0.5 1000.0 0.25", tok.String())
+
+	tok, err = ParseTavor(strings.NewReader(
+		"$F Float = from: 0.5,\nto: 0x1p0,\nstep: 0.25\nSTART = F\n",
+	))
+	Nil(t, err)
+	Equal(t, tok, primitives.NewScope(primitives.NewRangeFloat(0.5, 1.0, 0.25)))
+
+	tok, err = ParseTavor(strings.NewReader(
+		"Const Rate = -2.5\n$F Float = from: Rate,\nto: 0.0\nSTART = Rate F\n",
+	))
+	Nil(t, err)
+	Equal(t, "-2.5-2.5", tok.String())
+
+	// strings
+	tok, err = ParseTavor(strings.NewReader(
+		"START = \"\\u{1F600}\\u{41}\\\\u{41}\" `a\\n\"b`\n",
+	))
+	Nil(t, err)
+	Equal(t, "\U0001F600A\\u{41}a\\n\"b", tok.String())
+
+	tok, err = ParseTavor(strings.NewReader(
+		"$S Sequence = start: 0x10\nSTART = $S.Next \"\\u{21}\"\n",
+	))
+	Nil(t, err)
+	Equal(t, "16!", tok.String())
+
+	// heredocs
+	tok, err = ParseTavor(strings.NewReader(
+		"START = \"<\" <<EOT\n  a \"b\"\n    c // d\n  EOT \">\"\n",
+	))
+	Nil(t, err)
+	Equal(t, "<a \"b\"\n  c // d\n>", tok.String())
+
+	tok, err = ParseTavor(strings.NewReader(
+		"Const Header = <<END\nEND2\nEND\nSTART = Header\n",
+	))
+	Nil(t, err)
+	Equal(t, "END2\n", tok.String())
+
+	// errors
+	for _, format := range []string{
+		"START = 09\n",
+		"START = 0x\n",
+		"START = 1e\n",
+		"START = \"\\u{}\"\n",
+		"START = \"\\u{D800}\"\n",
+		"START = \"\\u{110000}\"\n",
+		"START = \"\\u{41\"\n",
+		"START = <<\na\n\n",
+		"START = <<EOT a\nEOT\n",
+		"START = <<EOT\na\n",
+	} {
+		_, err = ParseTavor(strings.NewReader(format))
+		if errs, ok := err.(token.ParserErrors); ok {
+			err = errs[0]
+		}
+		Equal(t, token.ParseErrorInvalidLiteral, err.(*token.ParserError).Type, format)
+	}
+
+	_, err = ParseTavor(strings.NewReader(
+		"START = <<EOT\nEOT\n",
+	))
+	Equal(t, token.ParseErrorEmptyString, err.(*token.ParserError).Type)
+
+	_, err = ParseTavor(strings.NewReader(
+		"$F Float = from: \"a\"\nSTART = F\n",
+	))
+	Equal(t, token.ParseErrorInvalidArgumentValue, err.(*token.ParserError).Type)
+
+	_, err = ParseTavor(strings.NewReader(
+		"$I Int = from: 1.5\nSTART = I\n",
+	))
+	Equal(t, token.ParseErrorInvalidArgumentValue, err.(*token.ParserError).Type)
+}
+
+func TestTavorParserEntryPoints(t *testing.T) {
+	var tok token.Token
+	var err error
+
+	// exported token definitions are not unused
+	tok, err = ParseTavor(strings.NewReader(
+		"START = \"a\"\nExport Header = \"b\" Field\nExport Field = \"c\"\n",
+	))
+	Nil(t, err)
+	Equal(t, "a", tok.String())
+
+	tok, err = ParseTavorToken(strings.NewReader(
+		"START = \"a\"\nExport Header = \"b\" Field\nExport Field = \"c\"\n",
+	), "Header")
+	Nil(t, err)
+	Equal(t, "bc", tok.String())
+
+	names, err := Exports(strings.NewReader(
+		"START = \"a\"\nExport Header = \"b\" Field\nExport Field = \"c\"\n",
+	))
+	Nil(t, err)
+	Equal(t, []string{"START", "Header", "Field"}, names)
+
+	// START is optional if there are other entry points
+	tok, constants, err := ParseTavorTokenWithConstants(strings.NewReader(
+		"Const N = 1\nExport Header = +N(\"b\")\n",
+	), "Header", map[string]string{
+		"N": "2",
+	})
+	Nil(t, err)
+	Equal(t, "bb", tok.String())
+	Equal(t, map[string]string{"N": "2"}, constants)
+
+	names, err = Exports(strings.NewReader(
+		"Export Header = \"b\"\n",
+	))
+	Nil(t, err)
+	Equal(t, []string{"Header"}, names)
+
+	_, err = ParseTavor(strings.NewReader(
+		"Export Header = \"b\"\n",
+	))
+	Equal(t, token.ParseErrorNoStart, err.(*token.ParserError).Type)
+
+	// the requested entry point is not unused
+	tok, err = ParseTavorToken(strings.NewReader(
+		"START = \"a\"\nHeader = \"b\"\n",
+	), "Header")
+	Nil(t, err)
+	Equal(t, "b", tok.String())
+
+	_, err = ParseTavor(strings.NewReader(
+		"START = \"a\"\nHeader = \"b\"\n",
+	))
+	Equal(t, token.ParseErrorUnusedToken, err.(*token.ParserError).Type)
+
+	// errors
+	_, err = ParseTavorToken(strings.NewReader(
+		"START = \"a\"\n",
+	), "Header")
+	Equal(t, token.ParseErrorTokenNotDefined, err.(*token.ParserError).Type)
+
+	_, err = ParseTavor(strings.NewReader(
+		"START = N\nExport Const N = 1\n",
+	))
+	Equal(t, token.ParseErrorInvalidTokenName, err.(*token.ParserError).Type)
+}
+
+func TestTavorParserTests(t *testing.T) {
+	results, err := RunTavorTests(strings.NewReader(
+		"Const N = 1\nSTART = +N(Item)\nExport Item = \"<\" [a-z] \">\"\n\n"+
+			"test valid \"<a><b>\"\ntest valid Item `<c>`\ntest invalid \"<a>\"\ntest invalid Item <<EOT\n<d>\nEOT\ntest valid \"<1>\"\n",
+	), map[string]string{
+		"N": "2",
+	})
+	Nil(t, err)
+	Equal(t, 5, len(results))
+
+	Equal(t, TavorTest{
+		Valid:    true,
+		Start:    "START",
+		Input:    "<a><b>",
+		Position: results[0].Position,
+	}, results[0].TavorTest)
+	Equal(t, 5, results[0].Position.Line)
+	True(t, results[0].Passed())
+
+	Equal(t, "Item", results[1].Start)
+	True(t, results[1].Passed())
+
+	False(t, results[2].Valid)
+	True(t, results[2].Passed())
+
+	Equal(t, "<d>\n", results[3].Input)
+	True(t, results[3].Passed())
+
+	False(t, results[4].Passed())
+	NotNil(t, results[4].Errors)
+
+	// tests are ignored by the token graph
+	tok, err := ParseTavor(strings.NewReader(
+		"START = \"a\"\ntest invalid \"b\"\n",
+	))
+	Nil(t, err)
+	Equal(t, "a", tok.String())
+
+	// errors
+	_, err = RunTavorTests(strings.NewReader(
+		"START = \"a\"\ntest valid Nope \"a\"\n",
+	), nil)
+	Equal(t, token.ParseErrorTokenNotDefined, err.(*token.ParserError).Type)
+
+	for _, format := range []string{
+		"START = \"a\"\ntest maybe \"a\"\n",
+		"START = \"a\"\ntest valid 1\n",
+	} {
+		_, err = RunTavorTests(strings.NewReader(format), nil)
+		Equal(t, token.ParseErrorInvalidTest, err.(*token.ParserError).Type, format)
+	}
+}
+
+func TestTavorParserBits(t *testing.T) {
+	// fields packed with the most significant bit first
+	{
+		tok, err := ParseTavor(strings.NewReader(`
+			START = bits(4: Version, 4: IHL, 8: 255)
+
+			Version = 4
+
+			$IHL Int = from: 5,
+				to: 15
+		`))
+		Nil(t, err)
+
+		Equal(t, "\x45\xff", tok.String())
+
+		errs := ParseInternal(tok, strings.NewReader("\x4f\xff"))
+		Nil(t, errs)
+
+		errs = ParseInternal(tok, strings.NewReader("\x35\xff"))
+		NotNil(t, errs)
+
+		errs = ParseInternal(tok, strings.NewReader("\x45"))
+		NotNil(t, errs)
+	}
+	// fields packed with the least significant bit first
+	{
+		tok, err := ParseTavor(strings.NewReader(`
+			START = bits(order: lsb, 4: 4, 4: 5)
+		`))
+		Nil(t, err)
+
+		Equal(t, "\x54", tok.String())
+	}
+	// invalid bits
+	for _, src := range []string{
+		`START = bits(0: 1, 8: 1)`,
+		`START = bits(33: 1, 7: 1)`,
+		`START = bits(4: 1)`,
+		`START = bits(order: big, 8: 1)`,
+		`START = bits(size: 8, 8: 1)`,
+		`START = bits()`,
+	} {
+		tok, err := ParseTavor(strings.NewReader(src))
+		Nil(t, tok)
+		Equal(t, token.ParseErrorInvalidBits, err.(*token.ParserError).Type, src)
+	}
+}
+
+func TestTavorParserTLV(t *testing.T) {
+	// frames with and without type
+	{
+		tok, err := ParseTavor(strings.NewReader(`
+			Payload = "ab" | "hello"
+
+			START = tlv(type: UInt8(7), length: UInt16be, value: Payload) tlv(
+				length: UInt8,
+				value: "x")
+		`))
+		Nil(t, err)
+
+		Equal(t, "\x07\x00\x02ab\x01x", tok.String())
+
+		errs := ParseInternal(tok, strings.NewReader("\x07\x00\x05hello\x01x"))
+		Nil(t, errs)
+		Equal(t, "\x07\x00\x05hello\x01x", tok.String())
+
+		errs = ParseInternal(tok, strings.NewReader("\x07\x00\x04hello\x01x"))
+		NotNil(t, errs)
+
+		errs = ParseInternal(tok, strings.NewReader("\x08\x00\x02ab\x01x"))
+		NotNil(t, errs)
+	}
+	// types without a token can be every value of their encoding
+	{
+		tok, err := ParseTavor(strings.NewReader(`
+			START = tlv(type: UInt16le, length: UInt8, value: 1)
+		`))
+		Nil(t, err)
+
+		Equal(t, "\x00\x00\x011", tok.String())
+		Equal(t, 65536, tok.PermutationsAll())
+	}
+	// invalid frames
+	for _, src := range []string{
+		`START = tlv(length: UInt8)`,
+		`START = tlv(value: "x")`,
+		`START = tlv(length: UInt8, length: UInt8, value: "x")`,
+		`START = tlv(length: Int8, value: "x")`,
+		`START = tlv(type: UInt8("a"), length: UInt8, value: "x")`,
+		`START = tlv(size: UInt8, value: "x")`,
+		`START = tlv()`,
+	} {
+		tok, err := ParseTavor(strings.NewReader(src))
+		Nil(t, tok)
+		Equal(t, token.ParseErrorInvalidTLV, err.(*token.ParserError).Type, src)
+	}
+}
+
+func TestTavorParserUnique(t *testing.T) {
+	for _, src := range []string{
+		`START = +unique(Key)`,
+		`START = *unique(Key)`,
+		`START = +1,3unique(Key)`,
+		`START = +1,unique(Key)`,
+	} {
+		tok, err := ParseTavor(strings.NewReader(src + `
+
+			Key = "a" | "b"
+		`))
+		Nil(t, err, src)
+
+		True(t, tok.(*primitives.Scope).Get().(*lists.Repeat).Unique(), src)
+	}
+	{
+		tok, err := ParseTavor(strings.NewReader(`
+			START = +2("a" | "b")
+		`))
+		Nil(t, err)
+
+		False(t, tok.(*primitives.Scope).Get().(*lists.Repeat).Unique())
+		True(t, conditions.CheckConstraints(tok))
+	}
+	{
+		tok, err := ParseTavor(strings.NewReader(`
+			START = +2unique("a" | "b")
+		`))
+		Nil(t, err)
+
+		Equal(t, "aa", tok.String())
+		False(t, conditions.CheckConstraints(tok))
+
+		errs := ParseInternal(tok, strings.NewReader("ba"))
+		Nil(t, errs)
+		True(t, conditions.CheckConstraints(tok))
+
+		errs = ParseInternal(tok, strings.NewReader("bb"))
+		NotNil(t, errs)
+	}
+}
+
+func TestTavorParserSymbols(t *testing.T) {
+	{
+		tok, err := ParseTavor(strings.NewReader(`
+			$Vars Symbols
+
+			START = Decl Decl Func "use " $Vars.Use "\n"
+
+			Decl = "var " $Vars.Define(Name) "\n"
+
+			Func = "func " Decl "use " $Vars.Use "\n"
+
+			Name = "a" | "b"
+		`))
+		Nil(t, err)
+
+		symbols.ResetSymbols(tok)
+
+		Equal(t, "var a\nvar a\nfunc var a\nuse a\nuse a\n", tok.String())
+		True(t, conditions.CheckConstraints(tok))
+
+		errs := ParseInternal(tok, strings.NewReader("var a\nvar b\nfunc var a\nuse a\nuse b\n"))
+		Nil(t, errs)
+
+		symbols.ResetSymbols(tok)
+
+		Equal(t, "var a\nvar b\nfunc var a\nuse a\nuse b\n", tok.String())
+		True(t, conditions.CheckConstraints(tok))
+
+		// the symbol of the function is not visible outside of the function
+		errs = ParseInternal(tok, strings.NewReader("var a\nvar a\nfunc var b\nuse b\nuse b\n"))
+		Nil(t, errs)
+
+		symbols.ResetSymbols(tok)
+
+		False(t, conditions.CheckConstraints(tok))
+	}
+	{
+		tok, err := ParseTavor(strings.NewReader(`
+			$Vars Symbols
+
+			START = $Vars.Unknown
+		`))
+		Nil(t, tok)
+		Equal(t, token.ParseErrorUnknownTokenAttribute, err.(*token.ParserError).Type)
+	}
+}
+
+func TestTavorParserAssert(t *testing.T) {
+	// comparison operators
+	for _, c := range []struct {
+		condition string
+		expected  string
+	}{
+		{"a.Value != b.Value", "12x"},
+		{"a.Value == b.Value", "12"},
+		{"a.Value < b.Value", "12x"},
+		{"a.Value <= 1", "12x"},
+		{"a.Value > b.Value", "12"},
+		{"a.Value + b.Value >= 3", "12x"},
+	} {
+		tok, err := ParseTavor(strings.NewReader(`
+			START = 1<a> 2<b> Print
+
+			Print = {if ` + c.condition + `} "x" {endif}
+		`))
+		Nil(t, err)
+
+		Equal(t, c.expected, tok.String(), c.condition)
+	}
+	// assertions
+	{
+		tok, err := ParseTavor(strings.NewReader(`
+			START = (1 | 2)<a> Check "x"
+
+			Check = {assert a.Value < 2} {assert a.Value != 3}
+		`))
+		Nil(t, err)
+
+		Equal(t, "1x", tok.String())
+
+		asserts := conditions.Asserts(tok)
+		Equal(t, 2, len(asserts))
+		True(t, conditions.CheckConstraints(tok))
+
+		// assertions are not parsed
+		errs := ParseInternal(tok, strings.NewReader("2x"))
+		Nil(t, errs)
+		Equal(t, "2x", tok.String())
+		False(t, conditions.CheckConstraints(tok))
+	}
+	// invalid assertions
+	{
+		tok, err := ParseTavor(strings.NewReader(`
+			START = 1<a> Check
+
+			Check = {if a.Value == 1} 1 {assert a.Value == 1} {endif}
+		`))
+		Nil(t, tok)
+		Equal(t, token.ParseErrorInvalidAssert, err.(*token.ParserError).Type)
+
+		tok, err = ParseTavor(strings.NewReader(`
+			START = 1<a> Check
+
+			Check = {assert a.Value ~ 1}
+		`))
+		Nil(t, tok)
+		Equal(t, token.ParseErrorUnknownBooleanOperator, err.(*token.ParserError).Type)
+	}
+}
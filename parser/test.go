@@ -0,0 +1,159 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"text/scanner"
+
+	"github.com/zimmski/tavor/log"
+	"github.com/zimmski/tavor/token"
+)
+
+// TavorTest defines an example input which is embedded in a format file and which is either valid or invalid for an entry point of the format
+type TavorTest struct {
+	// Valid is true if the input must be parsed successfully
+	Valid bool
+	// Start is the name of the entry point which parses the input
+	Start string
+	// Input is the example input
+	Input string
+	// Position is the position of the test in the format file
+	Position scanner.Position
+}
+
+// TavorTestResult holds the outcome of running an embedded test
+type TavorTestResult struct {
+	TavorTest
+
+	// Errors holds the errors of parsing the input, which are expected for invalid inputs
+	Errors []error
+}
+
+// Passed returns true if the input is valid or invalid as the test expects
+func (r TavorTestResult) Passed() bool {
+	return r.Valid == (len(r.Errors) == 0)
+}
+
+func (p *tavorParser) parseTestDefinition(position scanner.Position) (rune, error) {
+	var valid bool
+
+	switch p.scan.TokenText() {
+	case "valid":
+		valid = true
+	case "invalid":
+		valid = false
+	default:
+		return zeroRune, &token.ParserError{
+			Message:  fmt.Sprintf("expected \"valid\" or \"invalid\" but got %q", p.scan.TokenText()),
+			Type:     token.ParseErrorInvalidTest,
+			Position: p.scan.Pos(),
+		}
+	}
+
+	start := "START"
+
+	c := p.scan.Scan()
+
+	// an optional entry point can be given before the input
+	if c == scanner.Ident {
+		start = p.scan.TokenText()
+
+		c = p.scan.Scan()
+	}
+
+	var input string
+	var err error
+
+	switch {
+	case c == scanner.String || c == scanner.RawString:
+		input, err = p.stringLiteral()
+	case c == '<' && p.scan.Peek() == '<':
+		input, err = p.heredocLiteral()
+	default:
+		return zeroRune, &token.ParserError{
+			Message:  fmt.Sprintf("expected input string of test but got %v", scanner.TokenString(c)),
+			Type:     token.ParseErrorInvalidTest,
+			Position: p.scan.Pos(),
+		}
+	}
+	if err != nil {
+		return zeroRune, err
+	}
+
+	c = p.scan.Scan()
+
+	// we always want a new line at the end of the file
+	if c == scanner.EOF {
+		return zeroRune, &token.ParserError{
+			Message:  "new line at end of test needed",
+			Type:     token.ParseErrorNewLineNeeded,
+			Position: p.scan.Pos(),
+		}
+	}
+
+	if _, err := p.expectRune('\n', c); err != nil {
+		return zeroRune, err
+	}
+
+	p.tests = append(p.tests, TavorTest{
+		Valid:    valid,
+		Start:    start,
+		Input:    input,
+		Position: position,
+	})
+
+	c = p.scan.Scan()
+
+	return c, nil
+}
+
+// checkTests records an error for every test which uses an entry point that is not defined
+func (p *tavorParser) checkTests() {
+	for _, t := range p.tests {
+		if _, ok := p.definitions[t.Start]; !ok {
+			p.errs = append(p.errs, &token.ParserError{
+				Message:  fmt.Sprintf("token %q of test is not defined", t.Start),
+				Type:     token.ParseErrorTokenNotDefined,
+				Position: t.Position,
+			})
+		}
+	}
+}
+
+// RunTavorTests reads and parses a Tavor formatted input and runs all its embedded tests. The given values override the constant definitions of the input like in ParseTavorWithConstants.
+// Every test parses its input with a new token graph beginning with its entry point. The results are returned in the order of the tests in the input.
+// The error return argument is not nil if an error is encountered during reading or parsing the file.
+func RunTavorTests(src io.Reader, defines map[string]string) ([]TavorTestResult, error) {
+	data, err := ioutil.ReadAll(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := newTavorParser()
+	p.defines = defines
+
+	if err := p.parse(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+
+	results := make([]TavorTestResult, len(p.tests))
+
+	for i, t := range p.tests {
+		log.Debugf("run test of line %d", t.Position.Line)
+
+		tok, _, err := ParseTavorTokenWithConstants(bytes.NewReader(data), t.Start, defines)
+		if err != nil {
+			return nil, err
+		}
+
+		results[i] = TavorTestResult{
+			TavorTest: t,
+			Errors:    ParseInternal(tok, strings.NewReader(t.Input)),
+		}
+	}
+
+	return results, nil
+}
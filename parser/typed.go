@@ -3,6 +3,7 @@ package parser
 import (
 	"fmt"
 	"strconv"
+	"strings"
 )
 
 type argumentsParser struct {
@@ -41,6 +42,77 @@ func (ap *argumentsParser) GetInt(name string, defaultValue int) int {
 	return val
 }
 
+// GetFloat tries to parse the argument name and returns its floating point value or defaultValue if the argument is not found.
+// The return value is valid only if Err returns nil.
+func (ap *argumentsParser) GetFloat(name string, defaultValue float64) float64 {
+	if ap.err != nil {
+		return -1
+	}
+
+	raw, found := ap.arguments[name]
+	if !found {
+		return defaultValue
+	}
+
+	val, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		ap.err = fmt.Errorf("%q needs a floating point value", name)
+		return -1
+	}
+
+	ap.usedArguments[name] = struct{}{}
+	return val
+}
+
+// GetBool tries to parse the argument name and returns its boolean value or defaultValue if the argument is not found.
+// The return value is valid only if Err returns nil.
+func (ap *argumentsParser) GetBool(name string, defaultValue bool) bool {
+	if ap.err != nil {
+		return false
+	}
+
+	raw, found := ap.arguments[name]
+	if !found {
+		return defaultValue
+	}
+
+	val, err := strconv.ParseBool(raw)
+	if err != nil {
+		ap.err = fmt.Errorf("%q needs a boolean value", name)
+		return false
+	}
+
+	ap.usedArguments[name] = struct{}{}
+	return val
+}
+
+// GetString tries to parse the argument name and returns its string value or defaultValue if the argument is not found.
+// The return value is valid only if Err returns nil.
+func (ap *argumentsParser) GetString(name string, defaultValue string) string {
+	if ap.err != nil {
+		return ""
+	}
+
+	raw, found := ap.arguments[name]
+	if !found {
+		return defaultValue
+	}
+
+	val := raw
+	if strings.HasPrefix(raw, "\"") {
+		var err error
+
+		val, err = strconv.Unquote(raw)
+		if err != nil {
+			ap.err = fmt.Errorf("%q needs a string value", name)
+			return ""
+		}
+	}
+
+	ap.usedArguments[name] = struct{}{}
+	return val
+}
+
 // Err returns the first error encountered by the ArgumentsParser
 func (ap *argumentsParser) Err() error {
 	return ap.err
@@ -5,6 +5,7 @@ import (
 
 	"github.com/zimmski/tavor/log"
 	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/conditions"
 )
 
 func init() {
@@ -24,7 +25,7 @@ type linearStrategy struct {
 }
 
 // NewLinear implements a reduce strategy that reduces the data through a linear search algorithm.
-// Every step of the strategy generates a new valid token graph state. The generation is deterministic. The algorithm starts by deactivating all optional tokens, this includes for example reducing lists to their minimum repetition. Each step uses the feedback to determine which tokens to reactivate next.
+// Every step of the strategy generates a new valid token graph state. States which violate an assertion or a unique repeat of the graph are skipped. The generation is deterministic. The algorithm starts by deactivating all optional tokens, this includes for example reducing lists to their minimum repetition. Each step uses the feedback to determine which tokens to reactivate next.
 func NewLinear(root token.Token) (chan struct{}, chan<- ReduceFeedbackType, error) {
 	if token.LoopExists(root) {
 		return nil, nil, &Error{
@@ -86,7 +87,7 @@ func (s *linearStrategy) reduce(continueReducing chan struct{}, feedbackReducing
 			}
 
 			if c.reduction == c.maxReductions-1 {
-				log.Debugf("use initial value for (%p)%#v, nothing to reduce", c.token, c.token)
+				log.Debugf("use initial value for (%p)%#v%s, nothing to reduce", c.token, c.token, token.SourceSuffix(c.token))
 
 				c.reduction = c.maxReductions
 				if err := c.token.Reduce(c.reduction); err != nil {
@@ -99,12 +100,12 @@ func (s *linearStrategy) reduce(continueReducing chan struct{}, feedbackReducing
 			c.reduction++
 		}
 
-		log.Debugf("reduced (%p)%#v to reduction %d/%d", c.token, c.token, c.reduction, c.maxReductions)
+		log.Debugf("reduced (%p)%#v%s to reduction %d/%d", c.token, c.token, token.SourceSuffix(c.token), c.reduction, c.maxReductions)
 
 		c.children = s.getTree(c.token, true)
 
 		if len(c.children) > 0 {
-			log.Debugf("reduce the children of (%p)%#v %d/%d", c.token, c.token, c.reduction, c.maxReductions)
+			log.Debugf("reduce the children of (%p)%#v%s %d/%d", c.token, c.token, token.SourceSuffix(c.token), c.reduction, c.maxReductions)
 
 			s.reduce(continueReducing, feedbackReducing, c.children)
 		}
@@ -118,6 +119,12 @@ func (s *linearStrategy) nextStep(continueReducing chan struct{}, feedbackReduci
 	_ = token.ResetResetTokens(s.root)
 	token.ResetScope(s.root)
 
+	if !conditions.CheckConstraints(s.root) {
+		log.Debug("skip reducing step since an assertion is violated")
+
+		return true, Bad
+	}
+
 	log.Debug("done with reducing step")
 
 	// done with this reduce step
@@ -199,7 +206,7 @@ func (s *linearStrategy) getTree(root token.Token, fromChildren bool) []linearSt
 }
 
 func (s *linearStrategy) setReduction(tok token.ReduceToken, reduction uint) {
-	log.Debugf("set (%p)%#v to reduction %d", tok, tok, reduction)
+	log.Debugf("set (%p)%#v%s to reduction %d", tok, tok, token.SourceSuffix(tok), reduction)
 
 	if err := tok.Reduce(reduction); err != nil {
 		panic(err)
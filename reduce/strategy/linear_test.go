@@ -156,6 +156,55 @@ func TestLinearStrategy(t *testing.T) {
 			"b",
 		)
 	}
+	{
+		// Reductions which violate a unique repeat are skipped
+		tok, err := parser.ParseTavor(bytes.NewBufferString(`
+			START = +1,3unique(Item)
+
+			Item = "a" ?("b")
+		`))
+		Nil(t, err)
+
+		validateTavorLinear(
+			t,
+			tok,
+			"aba",
+			func(out string) ReduceFeedbackType {
+				return Bad
+			},
+			[]string{
+				"ab",
+				"a",
+			},
+			"aba",
+		)
+	}
+	{
+		// Reductions which remove the definition of a used symbol are skipped
+		tok, err := parser.ParseTavor(bytes.NewBufferString(`
+			$Vars Symbols
+
+			START = +1,3(Decl) "use " $Vars.Use ";"
+
+			Decl = "var " $Vars.Define(Name) ";"
+
+			Name = "a" | "b"
+		`))
+		Nil(t, err)
+
+		validateTavorLinear(
+			t,
+			tok,
+			"var a;var b;use b;",
+			func(out string) ReduceFeedbackType {
+				return Bad
+			},
+			[]string{
+				"var b;use b;",
+			},
+			"var a;var b;use b;",
+		)
+	}
 
 	/*
 		TODO read this files in with the aag.tavor file.
@@ -13,5 +13,26 @@ const (
 // MaxRepeat determines the maximum copies in graph cycles.
 var MaxRepeat = 2
 
+// MaxAssertRetries determines how many times a generation is retried if it violates an assertion.
+var MaxAssertRetries = 100
+
+// MaxFeedbackIterations determines how many generations a feedback-driven fuzzing strategy produces.
+var MaxFeedbackIterations = 1000
+
+// CorpusFolder determines the folder of the inputs which are mutated by mutation-based fuzzing strategies.
+var CorpusFolder = ""
+
+// GeneticPopulation determines how many individuals a generation of the genetic fuzzing strategy holds.
+var GeneticPopulation = 20
+
+// GeneticGenerations determines how many generations the genetic fuzzing strategy evolves.
+var GeneticGenerations = 10
+
+// GeneticElitism determines how many of the fittest individuals of a generation are carried over unchanged to the next generation.
+var GeneticElitism = 2
+
+// PairWiseStrength determines how many parameters of every combination the pairwise fuzzing strategy covers with all their values.
+var PairWiseStrength = 2
+
 // ErrNoSequenceValue there is no item left to choose an existing item.
 var ErrNoSequenceValue = fmt.Errorf("There is no sequence value to choose from")
@@ -57,8 +57,136 @@ func ObjectsAreEqual(expected, actual interface{}) bool {
 		return true
 	}
 
-	return false
+	// Compare again but skip values which are irrelevant for comparisons
+	return deepEqualComparable(reflect.ValueOf(expected), reflect.ValueOf(actual), make(map[visit]struct{}))
+
+}
+
+// Incomparable is implemented by types whose values are skipped if objects are compared, e.g. metadata which does not change the behaviour of the value it is part of.
+type Incomparable interface {
+	Incomparable()
+}
+
+var incomparableType = reflect.TypeOf((*Incomparable)(nil)).Elem()
+
+// visit holds two compared references so that cyclic references are only compared once
+type visit struct {
+	a, b uintptr
+	typ  reflect.Type
+}
+
+// isIncomparable returns true if the type itself implements the Incomparable interface. Methods which are promoted from embedded fields do not count.
+func isIncomparable(t reflect.Type) bool {
+	if !t.Implements(incomparableType) {
+		return false
+	}
+
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() == reflect.Struct {
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.Anonymous && reflect.PtrTo(f.Type).Implements(incomparableType) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// deepEqualComparable works like reflect.DeepEqual but skips values which implement the Incomparable interface
+func deepEqualComparable(a, b reflect.Value, visited map[visit]struct{}) bool {
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+	if isIncomparable(a.Type()) {
+		return true
+	}
+
+	switch a.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Ptr:
+		if a.Pointer() == b.Pointer() && (a.Kind() != reflect.Slice || a.Len() == b.Len()) {
+			return true
+		}
+
+		v := visit{a.Pointer(), b.Pointer(), a.Type()}
+		if _, ok := visited[v]; ok {
+			return true
+		}
+		visited[v] = struct{}{}
+	}
+
+	switch a.Kind() {
+	case reflect.Array:
+		for i := 0; i < a.Len(); i++ {
+			if !deepEqualComparable(a.Index(i), b.Index(i), visited) {
+				return false
+			}
+		}
+
+		return true
+	case reflect.Slice:
+		if a.IsNil() != b.IsNil() || a.Len() != b.Len() {
+			return false
+		}
+
+		for i := 0; i < a.Len(); i++ {
+			if !deepEqualComparable(a.Index(i), b.Index(i), visited) {
+				return false
+			}
+		}
+
+		return true
+	case reflect.Interface, reflect.Ptr:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
 
+		return deepEqualComparable(a.Elem(), b.Elem(), visited)
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if !deepEqualComparable(a.Field(i), b.Field(i), visited) {
+				return false
+			}
+		}
+
+		return true
+	case reflect.Map:
+		if a.IsNil() != b.IsNil() || a.Len() != b.Len() {
+			return false
+		}
+
+		for _, k := range a.MapKeys() {
+			if !deepEqualComparable(a.MapIndex(k), b.MapIndex(k), visited) {
+				return false
+			}
+		}
+
+		return true
+	case reflect.Func:
+		return a.IsNil() && b.IsNil()
+	case reflect.Bool:
+		return a.Bool() == b.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() == b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() == b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() == b.Float()
+	case reflect.Complex64, reflect.Complex128:
+		return a.Complex() == b.Complex()
+	case reflect.String:
+		return a.String() == b.String()
+	case reflect.Chan, reflect.UnsafePointer:
+		return a.Pointer() == b.Pointer()
+	}
+
+	return false
 }
 
 /* CallerInfo is necessary because the assert functions use the testing object
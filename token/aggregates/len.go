@@ -8,6 +8,8 @@ import (
 
 // Len implements a aggregation token that returns the length of a LenToken token
 type Len struct {
+	token.Origin
+
 	token token.LenToken
 }
 
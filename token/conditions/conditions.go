@@ -2,13 +2,17 @@ package conditions
 
 import (
 	"fmt"
+	"sync/atomic"
 
 	"github.com/zimmski/tavor/token"
 	"github.com/zimmski/tavor/token/lists"
+	"github.com/zimmski/tavor/token/symbols"
 )
 
 // IfPair implements a condition token which holds an If condition with its head and body
 type IfPair struct {
+	token.Origin
+
 	Head BooleanExpression
 	Body token.Token
 }
@@ -95,6 +99,8 @@ func (c *IfPair) InternalReplace(oldToken, newToken token.Token) error {
 
 // If implements a condition token which holds a list of IfPairs which belong together (e.g. If Elsif ... Else)
 type If struct {
+	token.Origin
+
 	Pairs []IfPair
 }
 
@@ -126,7 +132,13 @@ func (c *If) Clone() token.Token {
 // Parse tries to parse the token beginning from the current position in the parser data.
 // If the parsing is successful the error argument is nil and the next current position after the token is returned.
 func (c *If) Parse(pars *token.InternalParser, cur int) (int, []error) {
-	panic("TODO implement")
+	for _, pair := range c.Pairs {
+		if pair.Head.Evaluate() {
+			return pair.Body.Parse(pars, cur)
+		}
+	}
+
+	return cur, nil
 }
 
 // Permutation sets a specific permutation for this token
@@ -215,3 +227,304 @@ func (c *If) SetScope(variableScope *token.VariableScope) {
 		token.SetScope(pair.Body, variableScope)
 	}
 }
+
+// SwitchCase holds the values and the body of a case of a Switch condition. A case without values is the default case.
+type SwitchCase struct {
+	Values []token.Token
+	Body   token.Token
+}
+
+// Default returns true if the case is the default case
+func (c *SwitchCase) Default() bool {
+	return len(c.Values) == 0
+}
+
+// Clone returns a copy of the case and all its children
+func (c *SwitchCase) Clone() SwitchCase {
+	nValues := make([]token.Token, len(c.Values))
+	for i := range c.Values {
+		nValues[i] = c.Values[i].Clone()
+	}
+
+	return SwitchCase{
+		Values: nValues,
+		Body:   c.Body.Clone(),
+	}
+}
+
+// Switch implements a condition token which chooses the body of the first case with a value equal to its value. If no case matches, the default case is chosen.
+type Switch struct {
+	token.Origin
+
+	Value token.Token
+	Cases []SwitchCase
+}
+
+// NewSwitch returns a new instance of a Switch token referencing a value and a list of cases
+func NewSwitch(value token.Token, cases ...SwitchCase) *Switch {
+	if len(cases) == 0 {
+		panic("Must at least given one case")
+	}
+
+	return &Switch{
+		Value: value,
+		Cases: cases,
+	}
+}
+
+// Case returns the case which is currently chosen or nil if no case matches
+func (c *Switch) Case() *SwitchCase {
+	value := c.Value.String()
+
+	for i := range c.Cases {
+		for _, v := range c.Cases[i].Values {
+			if v.String() == value {
+				return &c.Cases[i]
+			}
+		}
+	}
+
+	for i := range c.Cases {
+		if c.Cases[i].Default() {
+			return &c.Cases[i]
+		}
+	}
+
+	return nil
+}
+
+// Token interface methods
+
+// Clone returns a copy of the token and all its children
+func (c *Switch) Clone() token.Token {
+	nCases := make([]SwitchCase, len(c.Cases))
+	for i := range c.Cases {
+		nCases[i] = c.Cases[i].Clone()
+	}
+
+	return &Switch{
+		Value: c.Value.Clone(),
+		Cases: nCases,
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (c *Switch) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	ca := c.Case()
+	if ca == nil {
+		return cur, nil
+	}
+
+	return ca.Body.Parse(pars, cur)
+}
+
+// Permutation sets a specific permutation for this token
+func (c *Switch) Permutation(i uint) error {
+	permutations := c.Permutations()
+
+	if i >= permutations {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+
+	// do nothing
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (c *Switch) Permutations() uint {
+	return 1
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (c *Switch) PermutationsAll() uint {
+	return c.Permutations()
+}
+
+func (c *Switch) String() string {
+	if ca := c.Case(); ca != nil {
+		return ca.Body.String()
+	}
+
+	return ""
+}
+
+// ScopeToken interface methods
+
+// SetScope sets the scope of the token
+func (c *Switch) SetScope(variableScope *token.VariableScope) {
+	token.SetScope(c.Value, variableScope)
+
+	for _, ca := range c.Cases {
+		for _, v := range ca.Values {
+			token.SetScope(v, variableScope)
+		}
+
+		// every case has its own scope
+		token.SetScope(ca.Body, variableScope.Push())
+	}
+}
+
+// AssertStatistics holds how often an assertion was checked and how often it was violated
+type AssertStatistics struct {
+	Checks     uint
+	Violations uint
+}
+
+// assertCounters holds the statistics of an assertion. The counters are only accessed atomically since generations can be checked concurrently.
+type assertCounters struct {
+	checks     uint64
+	violations uint64
+}
+
+// Assert implements a condition token which holds an assertion over a complete generation. The token itself has no output.
+// The statistics of an assertion are shared between all copies of the token.
+type Assert struct {
+	token.Origin
+
+	Head BooleanExpression
+
+	statistics *assertCounters
+}
+
+// NewAssert returns a new instance of an Assert token referencing the given boolean expression
+func NewAssert(head BooleanExpression) *Assert {
+	return &Assert{
+		Head: head,
+
+		statistics: &assertCounters{},
+	}
+}
+
+// Check evaluates the assertion and records the result in the statistics of the assertion
+func (c *Assert) Check() bool {
+	atomic.AddUint64(&c.statistics.checks, 1)
+
+	if !c.Head.Evaluate() {
+		atomic.AddUint64(&c.statistics.violations, 1)
+
+		return false
+	}
+
+	return true
+}
+
+// Statistics returns the statistics of the assertion
+func (c *Assert) Statistics() AssertStatistics {
+	return AssertStatistics{
+		Checks:     uint(atomic.LoadUint64(&c.statistics.checks)),
+		Violations: uint(atomic.LoadUint64(&c.statistics.violations)),
+	}
+}
+
+// Token interface methods
+
+// Clone returns a copy of the token and all its children
+func (c *Assert) Clone() token.Token {
+	return &Assert{
+		Head: c.Head.Clone().(BooleanExpression),
+
+		statistics: c.statistics,
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (c *Assert) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	return cur, nil
+}
+
+// Permutation sets a specific permutation for this token
+func (c *Assert) Permutation(i uint) error {
+	permutations := c.Permutations()
+
+	if i >= permutations {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+
+	// do nothing
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (c *Assert) Permutations() uint {
+	return 1
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (c *Assert) PermutationsAll() uint {
+	return c.Permutations()
+}
+
+func (c *Assert) String() string {
+	return ""
+}
+
+// ScopeToken interface methods
+
+// SetScope sets the scope of the token
+func (c *Assert) SetScope(variableScope *token.VariableScope) {
+	token.SetScope(c.Head, variableScope)
+}
+
+// Asserts returns all assertions of the token graph. Copies of the same assertion are only returned once.
+func Asserts(root token.Token) []*Assert {
+	var asserts []*Assert
+	known := make(map[*assertCounters]struct{})
+
+	err := token.Walk(root, func(tok token.Token) error {
+		if a, ok := tok.(*Assert); ok {
+			if _, ok := known[a.statistics]; !ok {
+				known[a.statistics] = struct{}{}
+
+				asserts = append(asserts, a)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return asserts
+}
+
+// CheckConstraints checks all constraints of a generation, which are assertions, unique repeats and symbol uses of the token graph, and returns true if all of them hold.
+// Every assertion records the result of its check in its statistics. The visible symbols of the token graph are computed before they are checked.
+func CheckConstraints(root token.Token) bool {
+	holds := true
+
+	// symbol uses are checked against the symbols which are visible in the current generation
+	symbols.ResetSymbols(root)
+
+	err := token.Walk(root, func(tok token.Token) error {
+		switch t := tok.(type) {
+		case *Assert:
+			if !t.Check() {
+				holds = false
+			}
+		case *lists.Repeat:
+			if !t.Distinct() {
+				holds = false
+			}
+		case *symbols.SymbolsUseItem:
+			if !t.Defined() {
+				holds = false
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return holds
+}
@@ -1,6 +1,7 @@
 package conditions
 
 import (
+	"sync"
 	"testing"
 
 	. "github.com/zimmski/tavor/test/assert"
@@ -14,6 +15,8 @@ func TestConditionsTokensToBeTokens(t *testing.T) {
 	var tok *token.Token
 
 	Implements(t, tok, &If{})
+	Implements(t, tok, &Switch{})
+	Implements(t, tok, &Assert{})
 }
 
 func TestVariableIf(t *testing.T) {
@@ -69,4 +72,103 @@ func TestVariableElse(t *testing.T) {
 	Equal(t, "b", o.String())
 
 	Equal(t, o.Permutation(1).(*token.PermutationError).Type, token.PermutationErrorIndexOutOfBound)
+
+	// inputs are parsed using the body of the fulfilled condition
+	pars := &token.InternalParser{
+		Data:    "b",
+		DataLen: 1,
+	}
+	nex, errs := o.Parse(pars, 0)
+	Nil(t, errs)
+	Equal(t, 1, nex)
+}
+
+func TestVariableSwitch(t *testing.T) {
+	value := lists.NewOne(primitives.NewConstantInt(1), primitives.NewConstantInt(2), primitives.NewConstantInt(3))
+
+	o := NewSwitch(
+		value,
+		SwitchCase{
+			Values: []token.Token{primitives.NewConstantInt(1)},
+			Body:   primitives.NewConstantString("a"),
+		},
+		SwitchCase{
+			Body: primitives.NewConstantString("c"),
+		},
+		SwitchCase{
+			Values: []token.Token{primitives.NewConstantInt(2), primitives.NewConstantInt(3)},
+			Body:   primitives.NewConstantString("b"),
+		},
+	)
+	Equal(t, "a", o.String())
+	Equal(t, 1, o.Permutations())
+	Equal(t, 1, o.PermutationsAll())
+
+	Nil(t, value.Permutation(1))
+	Equal(t, "b", o.String())
+
+	Nil(t, value.Permutation(2))
+	Equal(t, "b", o.String())
+
+	o2 := o.Clone()
+	Equal(t, o.String(), o2.String())
+
+	Nil(t, o.Permutation(0))
+	Equal(t, o.Permutation(1).(*token.PermutationError).Type, token.PermutationErrorIndexOutOfBound)
+
+	// the default case is used if no case matches
+	o = NewSwitch(
+		primitives.NewConstantInt(4),
+		o.Cases...,
+	)
+	Equal(t, "c", o.String())
+
+	// without a default case nothing is chosen
+	o = NewSwitch(
+		primitives.NewConstantInt(4),
+		o.Cases[0],
+	)
+	Equal(t, "", o.String())
+	Nil(t, o.Case())
+}
+
+func TestAssert(t *testing.T) {
+	a := primitives.NewRangeInt(1, 3)
+
+	o := NewAssert(NewBooleanCompare(CompareLess, a, primitives.NewConstantInt(3)))
+	Equal(t, "", o.String())
+	Equal(t, 1, o.Permutations())
+
+	root := lists.NewConcatenation(a, o, o.Clone())
+	Equal(t, "1", root.String())
+
+	// all copies are checked
+	True(t, CheckConstraints(root))
+	Equal(t, AssertStatistics{Checks: 2, Violations: 0}, o.Statistics())
+
+	Nil(t, a.Permutation(2))
+	False(t, CheckConstraints(root))
+	Equal(t, AssertStatistics{Checks: 4, Violations: 2}, o.Statistics())
+
+	// copies share their statistics
+	asserts := Asserts(root)
+	Equal(t, 1, len(asserts))
+	Equal(t, o, asserts[0])
+
+	// copies can be checked concurrently
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		c := o.Clone().(*Assert)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				c.Check()
+			}
+		}()
+	}
+	wg.Wait()
+	Equal(t, AssertStatistics{Checks: 404, Violations: 402}, o.Statistics())
 }
@@ -2,6 +2,8 @@ package conditions
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/zimmski/tavor/log"
 	"github.com/zimmski/tavor/token"
@@ -18,7 +20,9 @@ type BooleanExpression interface {
 }
 
 // BooleanTrue implements a boolean expression which evaluates to always true
-type BooleanTrue struct{}
+type BooleanTrue struct {
+	token.Origin
+}
 
 // NewBooleanTrue returns a new instance of a BooleanTrue token
 func NewBooleanTrue() *BooleanTrue {
@@ -102,6 +106,8 @@ func (c *BooleanTrue) InternalReplace(oldToken, newToken token.Token) error {
 
 // BooleanEqual implements a boolean expression which compares the value of two tokens
 type BooleanEqual struct {
+	token.Origin
+
 	a, b token.Token
 }
 
@@ -209,8 +215,184 @@ func (c *BooleanEqual) InternalReplace(oldToken, newToken token.Token) error {
 	return nil
 }
 
+// CompareOperator defines the operator of a BooleanCompare expression
+type CompareOperator int
+
+const (
+	// CompareNotEqual the values are not equal
+	CompareNotEqual CompareOperator = iota
+	// CompareLess the first value is less than the second value
+	CompareLess
+	// CompareLessEqual the first value is less than or equal to the second value
+	CompareLessEqual
+	// CompareGreater the first value is greater than the second value
+	CompareGreater
+	// CompareGreaterEqual the first value is greater than or equal to the second value
+	CompareGreaterEqual
+)
+
+var compareOperatorSymbols = map[CompareOperator]string{
+	CompareNotEqual:     "!=",
+	CompareLess:         "<",
+	CompareLessEqual:    "<=",
+	CompareGreater:      ">",
+	CompareGreaterEqual: ">=",
+}
+
+// BooleanCompare implements a boolean expression which compares the value of two tokens with a given operator.
+// The values are compared as integers if both are integers, otherwise they are compared as strings.
+type BooleanCompare struct {
+	token.Origin
+
+	op   CompareOperator
+	a, b token.Token
+}
+
+// NewBooleanCompare returns a new instance of a BooleanCompare token referencing two tokens
+func NewBooleanCompare(op CompareOperator, a, b token.Token) *BooleanCompare {
+	return &BooleanCompare{
+		op: op,
+		a:  a,
+		b:  b,
+	}
+}
+
+// Evaluate evaluates the boolean expression and returns its result
+func (c *BooleanCompare) Evaluate() bool {
+	as, bs := c.a.String(), c.b.String()
+
+	cmp := strings.Compare(as, bs)
+
+	ai, aErr := strconv.Atoi(as)
+	bi, bErr := strconv.Atoi(bs)
+	if aErr == nil && bErr == nil {
+		switch {
+		case ai < bi:
+			cmp = -1
+		case ai > bi:
+			cmp = 1
+		default:
+			cmp = 0
+		}
+	}
+
+	switch c.op {
+	case CompareNotEqual:
+		return cmp != 0
+	case CompareLess:
+		return cmp < 0
+	case CompareLessEqual:
+		return cmp <= 0
+	case CompareGreater:
+		return cmp > 0
+	case CompareGreaterEqual:
+		return cmp >= 0
+	}
+
+	return false
+}
+
+// Operator returns the compare operator of the expression
+func (c *BooleanCompare) Operator() CompareOperator {
+	return c.op
+}
+
+// Token interface methods
+
+// Clone returns a copy of the token and all its children
+func (c *BooleanCompare) Clone() token.Token {
+	return &BooleanCompare{
+		op: c.op,
+		a:  c.a,
+		b:  c.b,
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (c *BooleanCompare) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	panic("This should never happen")
+}
+
+// Permutation sets a specific permutation for this token
+func (c *BooleanCompare) Permutation(i uint) error {
+	// do nothing
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (c *BooleanCompare) Permutations() uint {
+	return 1
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (c *BooleanCompare) PermutationsAll() uint {
+	return 1
+}
+
+func (c *BooleanCompare) String() string {
+	return fmt.Sprintf("(%p)%#v %s (%p)%#v", c.a, c.a, compareOperatorSymbols[c.op], c.b, c.b)
+}
+
+// List interface methods
+
+// Get returns the current referenced token at the given index. The error return argument is not nil, if the index is out of bound.
+func (c *BooleanCompare) Get(i int) (token.Token, error) {
+	return nil, &lists.ListError{
+		Type: lists.ListErrorOutOfBound,
+	}
+}
+
+// Len returns the number of the current referenced tokens
+func (c *BooleanCompare) Len() int {
+	return 0
+}
+
+// InternalGet returns the current referenced internal token at the given index. The error return argument is not nil, if the index is out of bound.
+func (c *BooleanCompare) InternalGet(i int) (token.Token, error) {
+	switch i {
+	case 0:
+		return c.a, nil
+	case 1:
+		return c.b, nil
+	default:
+		return nil, &lists.ListError{
+			Type: lists.ListErrorOutOfBound,
+		}
+	}
+}
+
+// InternalLen returns the number of referenced internal tokens
+func (c *BooleanCompare) InternalLen() int {
+	return 2
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (c *BooleanCompare) InternalLogicalRemove(tok token.Token) token.Token {
+	if tok == c.a || tok == c.b {
+		return nil
+	}
+
+	return c
+}
+
+// InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
+func (c *BooleanCompare) InternalReplace(oldToken, newToken token.Token) error {
+	if oldToken == c.a {
+		c.a = newToken
+	}
+	if oldToken == c.b {
+		c.b = newToken
+	}
+
+	return nil
+}
+
 // VariableDefined implements a boolean expression which evaluates if a variable is defined in a given scope
 type VariableDefined struct {
+	token.Origin
+
 	name          string
 	variableScope *token.VariableScope
 }
@@ -274,6 +456,8 @@ func (c *VariableDefined) SetScope(variableScope *token.VariableScope) {
 
 // ExpressionPointer implements a token pointer to an expression token
 type ExpressionPointer struct {
+	token.Origin
+
 	token token.Token
 }
 
@@ -13,6 +13,7 @@ func TestBooleanExpressionToBeBooleanExpression(t *testing.T) {
 
 	Implements(t, ex, &BooleanTrue{})
 	Implements(t, ex, &BooleanEqual{})
+	Implements(t, ex, &BooleanCompare{})
 }
 
 func TestBooleanTrue(t *testing.T) {
@@ -27,3 +28,25 @@ func TestBooleanEqual(t *testing.T) {
 	o = NewBooleanEqual(primitives.NewConstantInt(1), primitives.NewConstantInt(2))
 	False(t, o.Evaluate())
 }
+
+func TestBooleanCompare(t *testing.T) {
+	for _, c := range []struct {
+		op       CompareOperator
+		a, b     string
+		expected bool
+	}{
+		{CompareNotEqual, "1", "2", true},
+		{CompareNotEqual, "1", "1", false},
+		{CompareLess, "2", "10", true},
+		{CompareLess, "10", "2", false},
+		{CompareLessEqual, "2", "2", true},
+		{CompareGreater, "-1", "-2", true},
+		{CompareGreaterEqual, "1", "2", false},
+		// strings are compared lexicographically
+		{CompareLess, "10", "b", true},
+		{CompareGreater, "b", "a", true},
+	} {
+		o := NewBooleanCompare(c.op, primitives.NewConstantString(c.a), primitives.NewConstantString(c.b))
+		Equal(t, c.expected, o.Evaluate(), "%s %s %s", c.a, compareOperatorSymbols[c.op], c.b)
+	}
+}
@@ -6,6 +6,8 @@ import (
 
 // Optional implements a constraint and optional token which references another token which can be de(activated)
 type Optional struct {
+	token.Origin
+
 	token token.Token
 	value bool
 
@@ -9,6 +9,8 @@ import (
 
 // AddArithmetic implements an arithmetic token adding the values of two tokens
 type AddArithmetic struct {
+	token.Origin
+
 	a token.Token
 	b token.Token
 }
@@ -133,6 +135,8 @@ func (e *AddArithmetic) InternalReplace(oldToken, newToken token.Token) error {
 
 // SubArithmetic implements an arithmetic token subtracting the values of two tokens
 type SubArithmetic struct {
+	token.Origin
+
 	a token.Token
 	b token.Token
 }
@@ -257,6 +261,8 @@ func (e *SubArithmetic) InternalReplace(oldToken, newToken token.Token) error {
 
 // MulArithmetic implements an arithmetic token multiplying the values of two tokens
 type MulArithmetic struct {
+	token.Origin
+
 	a token.Token
 	b token.Token
 }
@@ -381,6 +387,8 @@ func (e *MulArithmetic) InternalReplace(oldToken, newToken token.Token) error {
 
 // DivArithmetic implements an arithmetic token dividing the values of two tokens
 type DivArithmetic struct {
+	token.Origin
+
 	a token.Token
 	b token.Token
 }
@@ -6,6 +6,8 @@ import (
 
 // FuncExpression implements a expression token which executes a given list on output
 type FuncExpression struct {
+	token.Origin
+
 	permutationFunc     func(state interface{}, i uint) interface{}
 	permutationsFunc    func(state interface{}) uint
 	permutationsAllFunc func(state interface{}) uint
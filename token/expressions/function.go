@@ -0,0 +1,214 @@
+package expressions
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/lists"
+	"github.com/zimmski/tavor/token/primitives"
+)
+
+// FunctionCall defines the behaviour of an expression function
+type FunctionCall struct {
+	// MinArguments is the minimum number of arguments of the function
+	MinArguments int
+	// MaxArguments is the maximum number of arguments of the function, -1 means that there is no maximum
+	MaxArguments int
+	// Call returns the output of the function given its arguments
+	Call func(args []token.Token) string
+	// Parse parses the output of the function beginning from the current position in the parser data and sets its arguments accordingly.
+	// If Parse is nil, the current output of the function is parsed.
+	Parse func(args []token.Token, pars *token.InternalParser, cur int) (int, []error)
+}
+
+var functionLookup = make(map[string]FunctionCall)
+
+// RegisterFunction registers an expression function with the given name.
+func RegisterFunction(name string, f FunctionCall) {
+	if f.Call == nil {
+		panic("register function is nil")
+	}
+
+	if _, ok := functionLookup[name]; ok {
+		panic("function " + name + " already registered")
+	}
+
+	functionLookup[name] = f
+}
+
+// FunctionNames returns a sorted list of all registered expression functions
+func FunctionNames() []string {
+	var names []string
+
+	for name := range functionLookup {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// FunctionExists returns true if an expression function with the given name is registered
+func FunctionExists(name string) bool {
+	_, ok := functionLookup[name]
+
+	return ok
+}
+
+// IntArgument returns the integer value of the given function argument or 0 if the value is not an integer
+func IntArgument(tok token.Token) int {
+	i, err := strconv.Atoi(tok.String())
+	if err != nil {
+		return 0
+	}
+
+	return i
+}
+
+// Function implements an expression token which outputs the result of an expression function called with the current values of its arguments
+type Function struct {
+	token.Origin
+
+	name string
+	call FunctionCall
+	args []token.Token
+}
+
+// NewFunction returns a new instance of a Function token calling the given expression function with the given arguments.
+// The error return argument is not nil if the function does not exist or if the number of arguments does not fit the function.
+func NewFunction(name string, args ...token.Token) (*Function, error) {
+	call, ok := functionLookup[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q", name)
+	}
+
+	if len(args) < call.MinArguments || (call.MaxArguments != -1 && len(args) > call.MaxArguments) {
+		if call.MinArguments == call.MaxArguments {
+			return nil, fmt.Errorf("function %q needs %d arguments but got %d", name, call.MinArguments, len(args))
+		}
+
+		return nil, fmt.Errorf("function %q needs at least %d arguments but got %d", name, call.MinArguments, len(args))
+	}
+
+	return &Function{
+		name: name,
+		call: call,
+		args: args,
+	}, nil
+}
+
+// Name returns the name of the called expression function
+func (e *Function) Name() string {
+	return e.name
+}
+
+// Clone returns a copy of the token and all its children
+func (e *Function) Clone() token.Token {
+	args := make([]token.Token, len(e.args))
+	for i, arg := range e.args {
+		args[i] = arg.Clone()
+	}
+
+	return &Function{
+		name: e.name,
+		call: e.call,
+		args: args,
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (e *Function) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	if e.call.Parse != nil {
+		return e.call.Parse(e.args, pars, cur)
+	}
+
+	return primitives.NewConstantString(e.String()).Parse(pars, cur)
+}
+
+// Permutation sets a specific permutation for this token
+func (e *Function) Permutation(i uint) error {
+	permutations := e.Permutations()
+
+	if i >= permutations {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+	// do nothing
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (e *Function) Permutations() uint {
+	return 1
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (e *Function) PermutationsAll() uint {
+	var permutations uint = 1
+
+	for _, arg := range e.args {
+		permutations *= arg.PermutationsAll()
+	}
+
+	return permutations
+}
+
+func (e *Function) String() string {
+	return e.call.Call(e.args)
+}
+
+// List interface methods
+
+// Get returns the current referenced token at the given index. The error return argument is not nil, if the index is out of bound.
+func (e *Function) Get(i int) (token.Token, error) {
+	if i < 0 || i >= len(e.args) {
+		return nil, &lists.ListError{
+			Type: lists.ListErrorOutOfBound,
+		}
+	}
+
+	return e.args[i], nil
+}
+
+// Len returns the number of the current referenced tokens
+func (e *Function) Len() int {
+	return len(e.args)
+}
+
+// InternalGet returns the current referenced internal token at the given index. The error return argument is not nil, if the index is out of bound.
+func (e *Function) InternalGet(i int) (token.Token, error) {
+	return e.Get(i)
+}
+
+// InternalLen returns the number of referenced internal tokens
+func (e *Function) InternalLen() int {
+	return e.Len()
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (e *Function) InternalLogicalRemove(tok token.Token) token.Token {
+	for _, arg := range e.args {
+		if arg == tok {
+			return nil
+		}
+	}
+
+	return e
+}
+
+// InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
+func (e *Function) InternalReplace(oldToken, newToken token.Token) error {
+	for i, arg := range e.args {
+		if arg == oldToken {
+			e.args[i] = newToken
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package expressions
+
+import (
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/lists"
+	"github.com/zimmski/tavor/token/primitives"
+)
+
+func TestFunctionTokensToBeTokens(t *testing.T) {
+	var tok *token.ListToken
+
+	Implements(t, tok, &Function{})
+}
+
+func TestFunction(t *testing.T) {
+	True(t, FunctionExists("upper"))
+	False(t, FunctionExists("unknown"))
+	Contains(t, FunctionNames(), "concat")
+
+	_, err := NewFunction("unknown", primitives.NewConstantString("a"))
+	NotNil(t, err)
+
+	_, err = NewFunction("upper")
+	NotNil(t, err)
+
+	_, err = NewFunction("upper", primitives.NewConstantString("a"), primitives.NewConstantString("b"))
+	NotNil(t, err)
+
+	a := lists.NewOne(primitives.NewConstantString("a"), primitives.NewConstantString("b"))
+	b := primitives.NewRangeInt(1, 3)
+
+	o, err := NewFunction("concat", a, b)
+	Nil(t, err)
+	Equal(t, "concat", o.Name())
+	Equal(t, "a1", o.String())
+	Equal(t, 1, o.Permutations())
+	Equal(t, 6, o.PermutationsAll())
+	Equal(t, 2, o.Len())
+
+	i, err := o.Get(0)
+	Nil(t, err)
+	True(t, Exactly(t, a, i))
+	i, err = o.Get(2)
+	Equal(t, err.(*lists.ListError).Type, lists.ListErrorOutOfBound)
+	Nil(t, i)
+
+	// the function permutes with its arguments
+	Nil(t, a.Permutation(1))
+	Nil(t, b.Permutation(2))
+	Equal(t, "b3", o.String())
+
+	o2 := o.Clone()
+	Equal(t, o.String(), o2.String())
+
+	// inputs are parsed using the current value
+	pars := &token.InternalParser{
+		Data:    "b3",
+		DataLen: 2,
+	}
+	nex, errs := o.Parse(pars, 0)
+	Nil(t, errs)
+	Equal(t, 2, nex)
+}
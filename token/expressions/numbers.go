@@ -0,0 +1,281 @@
+package expressions
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/lists"
+	"github.com/zimmski/tavor/token/primitives"
+)
+
+func init() {
+	for name, base := range map[string]int{
+		"hex": 16,
+		"oct": 8,
+		"bin": 2,
+	} {
+		base := base
+
+		RegisterFunction(name, FunctionCall{
+			MinArguments: 1,
+			MaxArguments: 1,
+			Call: func(args []token.Token) string {
+				return strconv.FormatInt(int64(IntArgument(args[0])), base)
+			},
+			Parse: func(args []token.Token, pars *token.InternalParser, cur int) (int, []error) {
+				v, nex, ok := parseInteger(pars, cur, base)
+				if !ok {
+					return cur, []error{&token.ParserError{
+						Message:  fmt.Sprintf("expected integer with base %d", base),
+						Type:     token.ParseErrorUnexpectedData,
+						Position: pars.GetPosition(cur),
+					}}
+				}
+
+				if errs := parseArgument(args[0], strconv.Itoa(v), pars, cur); errs != nil {
+					return cur, errs
+				}
+
+				return nex, nil
+			},
+		})
+	}
+
+	RegisterFunction("fmt", FunctionCall{
+		MinArguments: 1,
+		MaxArguments: -1,
+		Call:         formatCall,
+		Parse:        parseFormat,
+	})
+}
+
+func formatCall(args []token.Token) string {
+	values := make([]interface{}, len(args)-1)
+	for i, arg := range args[1:] {
+		s := arg.String()
+
+		if v, err := strconv.Atoi(s); err == nil {
+			values[i] = v
+		} else {
+			values[i] = s
+		}
+	}
+
+	return fmt.Sprintf(args[0].String(), values...)
+}
+
+// parseInteger parses an optionally negative integer of the given base beginning from the current position in the parser data
+func parseInteger(pars *token.InternalParser, cur int, base int) (int, int, bool) {
+	i := cur
+
+	if i < pars.DataLen && pars.Data[i] == '-' {
+		i++
+	}
+
+	start := i
+
+	for i < pars.DataLen && isDigit(pars.Data[i], base) {
+		i++
+	}
+
+	if i == start {
+		return 0, cur, false
+	}
+
+	v, err := strconv.ParseInt(pars.Data[cur:i], base, 0)
+	if err != nil {
+		return 0, cur, false
+	}
+
+	return int(v), i, true
+}
+
+func isDigit(c byte, base int) bool {
+	var v int
+
+	switch {
+	case c >= '0' && c <= '9':
+		v = int(c - '0')
+	case c >= 'a' && c <= 'z':
+		v = int(c-'a') + 10
+	case c >= 'A' && c <= 'Z':
+		v = int(c-'A') + 10
+	default:
+		return false
+	}
+
+	return v < base
+}
+
+// parseArgument sets the given argument to the parsed value. Arguments which do not generate their own value, e.g. variable values and token attributes, must already have this value.
+func parseArgument(arg token.Token, value string, pars *token.InternalParser, cur int) []error {
+	tok := arg
+	for {
+		if t, ok := tok.(*primitives.Scope); ok {
+			tok = t.Resolve()
+		} else {
+			break
+		}
+	}
+
+	switch tok.(type) {
+	case *primitives.RangeInt, *primitives.ConstantInt, *lists.One:
+		p := &token.InternalParser{
+			Data:    value,
+			DataLen: len(value),
+		}
+
+		if nex, errs := tok.Parse(p, 0); len(errs) == 0 && nex == p.DataLen {
+			return nil
+		}
+	default:
+		if arg.String() == value {
+			return nil
+		}
+	}
+
+	return []error{&token.ParserError{
+		Message:  fmt.Sprintf("parsed value %s is not valid", value),
+		Type:     token.ParseErrorUnexpectedData,
+		Position: pars.GetPosition(cur),
+	}}
+}
+
+// parseFormat parses the output of the fmt function. Only the integer verbs d, x, X, o and b are parsed into their arguments, every other verb results in parsing the current output of the function.
+func parseFormat(args []token.Token, pars *token.InternalParser, cur int) (int, []error) {
+	format := args[0].String()
+
+	type verb struct {
+		base  int
+		left  bool
+		width int
+	}
+
+	var verbs []verb
+	var literals []string
+	var literal []byte
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			literal = append(literal, format[i])
+
+			continue
+		}
+
+		i++
+
+		v := verb{}
+
+		for ; i < len(format) && strings.IndexByte("+- 0", format[i]) != -1; i++ {
+			if format[i] == '-' {
+				v.left = true
+			}
+		}
+		// width
+		for i < len(format) && format[i] >= '0' && format[i] <= '9' {
+			v.width = v.width*10 + int(format[i]-'0')
+
+			i++
+		}
+
+		if i == len(format) {
+			return primitives.NewConstantString(formatCall(args)).Parse(pars, cur)
+		}
+
+		switch format[i] {
+		case '%':
+			literal = append(literal, '%')
+
+			continue
+		case 'd':
+			v.base = 10
+		case 'x', 'X':
+			v.base = 16
+		case 'o':
+			v.base = 8
+		case 'b':
+			v.base = 2
+		default:
+			return primitives.NewConstantString(formatCall(args)).Parse(pars, cur)
+		}
+
+		literals = append(literals, string(literal))
+		literal = nil
+
+		verbs = append(verbs, v)
+	}
+	literals = append(literals, string(literal))
+
+	if len(verbs) != len(args)-1 {
+		return primitives.NewConstantString(formatCall(args)).Parse(pars, cur)
+	}
+
+	i := cur
+
+	parseLiteral := func(l string) []error {
+		if i+len(l) > pars.DataLen || pars.Data[i:i+len(l)] != l {
+			return []error{&token.ParserError{
+				Message:  fmt.Sprintf("expected %q", l),
+				Type:     token.ParseErrorUnexpectedData,
+				Position: pars.GetPosition(i),
+			}}
+		}
+
+		i += len(l)
+
+		return nil
+	}
+
+	for j, v := range verbs {
+		if errs := parseLiteral(literals[j]); errs != nil {
+			return cur, errs
+		}
+
+		start := i
+
+		// skip padding
+		for i < pars.DataLen && pars.Data[i] == ' ' {
+			i++
+		}
+		if i < pars.DataLen && pars.Data[i] == '+' {
+			i++
+		}
+
+		value, nex, ok := parseInteger(pars, i, v.base)
+		if !ok {
+			return cur, []error{&token.ParserError{
+				Message:  fmt.Sprintf("expected integer with base %d", v.base),
+				Type:     token.ParseErrorUnexpectedData,
+				Position: pars.GetPosition(i),
+			}}
+		}
+
+		if errs := parseArgument(args[j+1], strconv.Itoa(value), pars, i); errs != nil {
+			return cur, errs
+		}
+
+		i = nex
+
+		if v.left {
+			for i < pars.DataLen && pars.Data[i] == ' ' {
+				i++
+			}
+		}
+
+		if i-start < v.width {
+			return cur, []error{&token.ParserError{
+				Message:  fmt.Sprintf("expected integer with width %d", v.width),
+				Type:     token.ParseErrorUnexpectedData,
+				Position: pars.GetPosition(start),
+			}}
+		}
+	}
+
+	if errs := parseLiteral(literals[len(literals)-1]); errs != nil {
+		return cur, errs
+	}
+
+	return i, nil
+}
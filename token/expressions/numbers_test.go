@@ -0,0 +1,84 @@
+package expressions
+
+import (
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/primitives"
+)
+
+func TestNumberFunctions(t *testing.T) {
+	call := func(name string, args ...token.Token) string {
+		o, err := NewFunction(name, args...)
+		Nil(t, err)
+
+		return o.String()
+	}
+
+	n := primitives.NewConstantInt(255)
+
+	Equal(t, "ff", call("hex", n))
+	Equal(t, "377", call("oct", n))
+	Equal(t, "11111111", call("bin", n))
+	Equal(t, "-ff", call("hex", primitives.NewConstantInt(-255)))
+	Equal(t, "00255|FF   |a", call("fmt", primitives.NewConstantString("%05d|%-5X|%s"), n, n, primitives.NewConstantString("a")))
+}
+
+func TestNumberFunctionsParse(t *testing.T) {
+	parse := func(o token.Token, data string) (int, []error) {
+		pars := &token.InternalParser{
+			Data:    data,
+			DataLen: len(data),
+		}
+
+		return o.Parse(pars, 0)
+	}
+
+	r := primitives.NewRangeInt(1, 300)
+
+	o, err := NewFunction("hex", r)
+	Nil(t, err)
+
+	nex, errs := parse(o, "ff;")
+	Nil(t, errs)
+	Equal(t, 2, nex)
+	Equal(t, "255", r.String())
+	Equal(t, "ff", o.String())
+
+	_, errs = parse(o, "fff")
+	NotNil(t, errs)
+	_, errs = parse(o, "x")
+	NotNil(t, errs)
+
+	o, err = NewFunction("bin", primitives.NewConstantInt(5))
+	Nil(t, err)
+
+	nex, errs = parse(o, "101")
+	Nil(t, errs)
+	Equal(t, 3, nex)
+	_, errs = parse(o, "100")
+	NotNil(t, errs)
+
+	o, err = NewFunction("fmt", primitives.NewConstantString("<%04d|%-4x>"), r, r)
+	Nil(t, err)
+
+	nex, errs = parse(o, "<0042|2a  >")
+	Nil(t, errs)
+	Equal(t, 11, nex)
+	Equal(t, "42", r.String())
+
+	_, errs = parse(o, "<42|2a  >")
+	NotNil(t, errs)
+	_, errs = parse(o, "<0042|2a  ")
+	NotNil(t, errs)
+
+	// verbs which are not integers parse the current output
+	o, err = NewFunction("fmt", primitives.NewConstantString("%s!"), primitives.NewConstantString("a"))
+	Nil(t, err)
+
+	nex, errs = parse(o, "a!")
+	Nil(t, errs)
+	Equal(t, 2, nex)
+}
@@ -13,6 +13,8 @@ import (
 
 // Path implements a path query
 type Path struct {
+	token.Origin
+
 	list      token.Token
 	from      token.Token
 	over      token.Token
@@ -104,7 +106,9 @@ func (e *Path) path() []string {
 			cs[j] = e.connectBy[j].String()
 		}
 
-		connects[e.over.String()] = cs
+		// entries with the same identifier add up their connections
+		over := e.over.String()
+		connects[over] = append(connects[over], cs...)
 	}
 
 	token.SetScope(e.from, variableScope)
@@ -0,0 +1,838 @@
+package expressions
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/lists"
+	"github.com/zimmski/tavor/token/primitives"
+	"github.com/zimmski/tavor/token/variables"
+)
+
+// SetValues returns the distinct values of the given tokens in the order of their first occurrence.
+// Repeats, paths and set operations contribute the values of all their current elements, every other token contributes its current value.
+func SetValues(toks ...token.Token) []string {
+	var values []string
+
+	for _, tok := range toks {
+		values = append(values, setValues(tok)...)
+	}
+
+	return Union(values)
+}
+
+func setValues(tok token.Token) []string {
+	for {
+		switch t := tok.(type) {
+		case *primitives.Pointer:
+			tok = t.Resolve()
+		case *primitives.Scope:
+			tok = t.Resolve()
+		case *variables.VariableValue:
+			tok = t.InternalGet()
+		case *variables.VariableReference:
+			tok = t.Reference()
+		case *variables.Variable:
+			tok = t.InternalGet()
+		case *lists.Repeat:
+			values := make([]string, t.Len())
+
+			for i := range values {
+				c, _ := t.Get(i)
+
+				values[i] = c.String()
+			}
+
+			return values
+		case *Path:
+			return t.path()
+		case setOperation:
+			return t.values()
+		default:
+			return []string{tok.String()}
+		}
+	}
+}
+
+// Union returns the distinct values of all given sets in the order of their first occurrence
+func Union(sets ...[]string) []string {
+	var union []string
+	found := make(map[string]struct{})
+
+	for _, set := range sets {
+		for _, v := range set {
+			if _, ok := found[v]; !ok {
+				found[v] = struct{}{}
+				union = append(union, v)
+			}
+		}
+	}
+
+	return union
+}
+
+// Intersection returns the distinct values of the set a which are also in the set b
+func Intersection(a, b []string) []string {
+	var intersection []string
+	in := setLookup(b)
+
+	for _, v := range Union(a) {
+		if _, ok := in[v]; ok {
+			intersection = append(intersection, v)
+		}
+	}
+
+	return intersection
+}
+
+// Difference returns the distinct values of the set a which are not in the set b
+func Difference(a, b []string) []string {
+	var difference []string
+	in := setLookup(b)
+
+	for _, v := range Union(a) {
+		if _, ok := in[v]; !ok {
+			difference = append(difference, v)
+		}
+	}
+
+	return difference
+}
+
+// Subset returns true if all values of the set a are in the set b
+func Subset(a, b []string) bool {
+	return len(Difference(a, b)) == 0
+}
+
+// Disjoint returns true if no value of the set a is in the set b
+func Disjoint(a, b []string) bool {
+	return len(Intersection(a, b)) == 0
+}
+
+func setLookup(set []string) map[string]struct{} {
+	lookup := make(map[string]struct{}, len(set))
+
+	for _, v := range set {
+		lookup[v] = struct{}{}
+	}
+
+	return lookup
+}
+
+// setOperation defines a token which results in a set of values
+type setOperation interface {
+	token.Token
+
+	values() []string
+}
+
+func setString(values []string) string {
+	var buffer bytes.Buffer
+
+	for _, v := range values {
+		if _, err := buffer.WriteString(v); err != nil {
+			panic(err)
+		}
+	}
+
+	return buffer.String()
+}
+
+func cloneSetOperands(a token.Token, b []token.Token) (token.Token, []token.Token) {
+	bc := make([]token.Token, len(b))
+
+	for i, tok := range b {
+		bc[i] = tok.Clone()
+	}
+
+	return a.Clone(), bc
+}
+
+func permutationsAllSetOperands(a token.Token, b []token.Token) uint {
+	sum := a.PermutationsAll()
+
+	for _, tok := range b {
+		sum *= tok.PermutationsAll()
+	}
+
+	return sum
+}
+
+func getSetOperand(a token.Token, b []token.Token, i int) (token.Token, error) {
+	if i < 0 || i > len(b) {
+		return nil, &lists.ListError{
+			Type: lists.ListErrorOutOfBound,
+		}
+	}
+
+	if i == 0 {
+		return a, nil
+	}
+
+	return b[i-1], nil
+}
+
+func replaceSetOperand(a *token.Token, b []token.Token, oldToken, newToken token.Token) {
+	if *a == oldToken {
+		*a = newToken
+	}
+
+	for i := range b {
+		if b[i] == oldToken {
+			b[i] = newToken
+		}
+	}
+}
+
+func hasSetOperand(a token.Token, b []token.Token, tok token.Token) bool {
+	if a == tok {
+		return true
+	}
+
+	for _, t := range b {
+		if t == tok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// UnionSet implements a set token which holds the distinct values of all its operands
+type UnionSet struct {
+	token.Origin
+
+	a token.Token
+	b []token.Token
+}
+
+// NewUnionSet returns a new instance of a UnionSet token
+func NewUnionSet(a token.Token, b []token.Token) *UnionSet {
+	return &UnionSet{
+		a: a,
+		b: b,
+	}
+}
+
+func (e *UnionSet) values() []string {
+	return Union(SetValues(e.a), SetValues(e.b...))
+}
+
+// Clone returns a copy of the token and all its children
+func (e *UnionSet) Clone() token.Token {
+	a, b := cloneSetOperands(e.a, e.b)
+
+	return &UnionSet{
+		a: a,
+		b: b,
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (e *UnionSet) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	return parseSetValues(pars, cur, e.a, e.String)
+}
+
+// Permutation sets a specific permutation for this token
+func (e *UnionSet) Permutation(i uint) error {
+	permutations := e.Permutations()
+
+	if i >= permutations {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+	// do nothing
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (e *UnionSet) Permutations() uint {
+	return 1
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (e *UnionSet) PermutationsAll() uint {
+	return permutationsAllSetOperands(e.a, e.b)
+}
+
+func (e *UnionSet) String() string {
+	return setString(e.values())
+}
+
+// List interface methods
+
+// Get returns the current referenced token at the given index. The error return argument is not nil, if the index is out of bound.
+func (e *UnionSet) Get(i int) (token.Token, error) {
+	return getSetOperand(e.a, e.b, i)
+}
+
+// Len returns the number of the current referenced tokens
+func (e *UnionSet) Len() int {
+	return 1 + len(e.b)
+}
+
+// InternalGet returns the current referenced internal token at the given index. The error return argument is not nil, if the index is out of bound.
+func (e *UnionSet) InternalGet(i int) (token.Token, error) {
+	return e.Get(i)
+}
+
+// InternalLen returns the number of referenced internal tokens
+func (e *UnionSet) InternalLen() int {
+	return e.Len()
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (e *UnionSet) InternalLogicalRemove(tok token.Token) token.Token {
+	if hasSetOperand(e.a, e.b, tok) {
+		return nil
+	}
+
+	return e
+}
+
+// InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
+func (e *UnionSet) InternalReplace(oldToken, newToken token.Token) error {
+	replaceSetOperand(&e.a, e.b, oldToken, newToken)
+
+	return nil
+}
+
+// IntersectionSet implements a set token which holds the distinct values of its first operand which are also in the other operands
+type IntersectionSet struct {
+	token.Origin
+
+	a token.Token
+	b []token.Token
+}
+
+// NewIntersectionSet returns a new instance of a IntersectionSet token
+func NewIntersectionSet(a token.Token, b []token.Token) *IntersectionSet {
+	return &IntersectionSet{
+		a: a,
+		b: b,
+	}
+}
+
+func (e *IntersectionSet) values() []string {
+	return Intersection(SetValues(e.a), SetValues(e.b...))
+}
+
+// Clone returns a copy of the token and all its children
+func (e *IntersectionSet) Clone() token.Token {
+	a, b := cloneSetOperands(e.a, e.b)
+
+	return &IntersectionSet{
+		a: a,
+		b: b,
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (e *IntersectionSet) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	return parseSetValues(pars, cur, e.a, e.String)
+}
+
+// Permutation sets a specific permutation for this token
+func (e *IntersectionSet) Permutation(i uint) error {
+	permutations := e.Permutations()
+
+	if i >= permutations {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+	// do nothing
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (e *IntersectionSet) Permutations() uint {
+	return 1
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (e *IntersectionSet) PermutationsAll() uint {
+	return permutationsAllSetOperands(e.a, e.b)
+}
+
+func (e *IntersectionSet) String() string {
+	return setString(e.values())
+}
+
+// List interface methods
+
+// Get returns the current referenced token at the given index. The error return argument is not nil, if the index is out of bound.
+func (e *IntersectionSet) Get(i int) (token.Token, error) {
+	return getSetOperand(e.a, e.b, i)
+}
+
+// Len returns the number of the current referenced tokens
+func (e *IntersectionSet) Len() int {
+	return 1 + len(e.b)
+}
+
+// InternalGet returns the current referenced internal token at the given index. The error return argument is not nil, if the index is out of bound.
+func (e *IntersectionSet) InternalGet(i int) (token.Token, error) {
+	return e.Get(i)
+}
+
+// InternalLen returns the number of referenced internal tokens
+func (e *IntersectionSet) InternalLen() int {
+	return e.Len()
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (e *IntersectionSet) InternalLogicalRemove(tok token.Token) token.Token {
+	if hasSetOperand(e.a, e.b, tok) {
+		return nil
+	}
+
+	return e
+}
+
+// InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
+func (e *IntersectionSet) InternalReplace(oldToken, newToken token.Token) error {
+	replaceSetOperand(&e.a, e.b, oldToken, newToken)
+
+	return nil
+}
+
+// DifferenceSet implements a set token which holds the distinct values of its first operand which are not in the other operands
+type DifferenceSet struct {
+	token.Origin
+
+	a token.Token
+	b []token.Token
+}
+
+// NewDifferenceSet returns a new instance of a DifferenceSet token
+func NewDifferenceSet(a token.Token, b []token.Token) *DifferenceSet {
+	return &DifferenceSet{
+		a: a,
+		b: b,
+	}
+}
+
+func (e *DifferenceSet) values() []string {
+	return Difference(SetValues(e.a), SetValues(e.b...))
+}
+
+// Clone returns a copy of the token and all its children
+func (e *DifferenceSet) Clone() token.Token {
+	a, b := cloneSetOperands(e.a, e.b)
+
+	return &DifferenceSet{
+		a: a,
+		b: b,
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (e *DifferenceSet) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	return parseSetValues(pars, cur, e.a, e.String)
+}
+
+// Permutation sets a specific permutation for this token
+func (e *DifferenceSet) Permutation(i uint) error {
+	permutations := e.Permutations()
+
+	if i >= permutations {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+	// do nothing
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (e *DifferenceSet) Permutations() uint {
+	return 1
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (e *DifferenceSet) PermutationsAll() uint {
+	return permutationsAllSetOperands(e.a, e.b)
+}
+
+func (e *DifferenceSet) String() string {
+	return setString(e.values())
+}
+
+// List interface methods
+
+// Get returns the current referenced token at the given index. The error return argument is not nil, if the index is out of bound.
+func (e *DifferenceSet) Get(i int) (token.Token, error) {
+	return getSetOperand(e.a, e.b, i)
+}
+
+// Len returns the number of the current referenced tokens
+func (e *DifferenceSet) Len() int {
+	return 1 + len(e.b)
+}
+
+// InternalGet returns the current referenced internal token at the given index. The error return argument is not nil, if the index is out of bound.
+func (e *DifferenceSet) InternalGet(i int) (token.Token, error) {
+	return e.Get(i)
+}
+
+// InternalLen returns the number of referenced internal tokens
+func (e *DifferenceSet) InternalLen() int {
+	return e.Len()
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (e *DifferenceSet) InternalLogicalRemove(tok token.Token) token.Token {
+	if hasSetOperand(e.a, e.b, tok) {
+		return nil
+	}
+
+	return e
+}
+
+// InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
+func (e *DifferenceSet) InternalReplace(oldToken, newToken token.Token) error {
+	replaceSetOperand(&e.a, e.b, oldToken, newToken)
+
+	return nil
+}
+
+// InSet implements a boolean expression token which checks if all values of its first operand are in the other operands.
+// If the check is not fulfilled while the token is output, the token searches beginning with its own permutation for a permutation of the first operand which fulfills the check. The token outputs the value of its first operand if the check is fulfilled, and nothing otherwise.
+type InSet struct {
+	token.Origin
+
+	a token.Token
+	b []token.Token
+
+	permutation uint
+}
+
+// NewInSet returns a new instance of a InSet token
+func NewInSet(a token.Token, b []token.Token) *InSet {
+	return &InSet{
+		a: a,
+		b: b,
+	}
+}
+
+// Evaluate evaluates the boolean expression and returns its result
+func (e *InSet) Evaluate() bool {
+	return Subset(SetValues(e.a), SetValues(e.b...))
+}
+
+// Clone returns a copy of the token and all its children
+func (e *InSet) Clone() token.Token {
+	a, b := cloneSetOperands(e.a, e.b)
+
+	return &InSet{
+		a: a,
+		b: b,
+
+		permutation: e.permutation,
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (e *InSet) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	return parseSetMember(pars, cur, e.a, e.Evaluate)
+}
+
+// Permutation sets a specific permutation for this token
+func (e *InSet) Permutation(i uint) error {
+	permutations := e.Permutations()
+
+	if i >= permutations {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+
+	e.permutation = i
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (e *InSet) Permutations() uint {
+	return permutationsSetMember(e.a)
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (e *InSet) PermutationsAll() uint {
+	return permutationsAllSetOperands(e.a, e.b)
+}
+
+func (e *InSet) String() string {
+	if !e.Evaluate() {
+		// the values of the second operand are only known after they have been generated, which is why the first operand is chosen here
+		if err := permutationSetMember(e.a, e.permutation, e.Evaluate); err != nil || !e.Evaluate() {
+			return ""
+		}
+	}
+
+	return e.a.String()
+}
+
+// List interface methods
+
+// Get returns the current referenced token at the given index. The error return argument is not nil, if the index is out of bound.
+func (e *InSet) Get(i int) (token.Token, error) {
+	return nil, &lists.ListError{
+		Type: lists.ListErrorOutOfBound,
+	}
+}
+
+// Len returns the number of the current referenced tokens
+func (e *InSet) Len() int {
+	return 0
+}
+
+// InternalGet returns the current referenced internal token at the given index. The error return argument is not nil, if the index is out of bound.
+func (e *InSet) InternalGet(i int) (token.Token, error) {
+	return getSetOperand(e.a, e.b, i)
+}
+
+// InternalLen returns the number of referenced internal tokens
+func (e *InSet) InternalLen() int {
+	return 1 + len(e.b)
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (e *InSet) InternalLogicalRemove(tok token.Token) token.Token {
+	if hasSetOperand(e.a, e.b, tok) {
+		return nil
+	}
+
+	return e
+}
+
+// InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
+func (e *InSet) InternalReplace(oldToken, newToken token.Token) error {
+	replaceSetOperand(&e.a, e.b, oldToken, newToken)
+
+	return nil
+}
+
+// NotInSet implements a boolean expression token which checks if no value of its first operand is in the other operands.
+// If the check is not fulfilled while the token is output, the token searches beginning with its own permutation for a permutation of the first operand which fulfills the check. The token outputs the value of its first operand if the check is fulfilled, and nothing otherwise.
+type NotInSet struct {
+	token.Origin
+
+	a token.Token
+	b []token.Token
+
+	permutation uint
+}
+
+// NewNotInSet returns a new instance of a NotInSet token
+func NewNotInSet(a token.Token, b []token.Token) *NotInSet {
+	return &NotInSet{
+		a: a,
+		b: b,
+	}
+}
+
+// Evaluate evaluates the boolean expression and returns its result
+func (e *NotInSet) Evaluate() bool {
+	return Disjoint(SetValues(e.a), SetValues(e.b...))
+}
+
+// Clone returns a copy of the token and all its children
+func (e *NotInSet) Clone() token.Token {
+	a, b := cloneSetOperands(e.a, e.b)
+
+	return &NotInSet{
+		a: a,
+		b: b,
+
+		permutation: e.permutation,
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (e *NotInSet) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	return parseSetMember(pars, cur, e.a, e.Evaluate)
+}
+
+// Permutation sets a specific permutation for this token
+func (e *NotInSet) Permutation(i uint) error {
+	permutations := e.Permutations()
+
+	if i >= permutations {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+
+	e.permutation = i
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (e *NotInSet) Permutations() uint {
+	return permutationsSetMember(e.a)
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (e *NotInSet) PermutationsAll() uint {
+	return permutationsAllSetOperands(e.a, e.b)
+}
+
+func (e *NotInSet) String() string {
+	if !e.Evaluate() {
+		// the values of the second operand are only known after they have been generated, which is why the first operand is chosen here
+		if err := permutationSetMember(e.a, e.permutation, e.Evaluate); err != nil || !e.Evaluate() {
+			return ""
+		}
+	}
+
+	return e.a.String()
+}
+
+// List interface methods
+
+// Get returns the current referenced token at the given index. The error return argument is not nil, if the index is out of bound.
+func (e *NotInSet) Get(i int) (token.Token, error) {
+	return nil, &lists.ListError{
+		Type: lists.ListErrorOutOfBound,
+	}
+}
+
+// Len returns the number of the current referenced tokens
+func (e *NotInSet) Len() int {
+	return 0
+}
+
+// InternalGet returns the current referenced internal token at the given index. The error return argument is not nil, if the index is out of bound.
+func (e *NotInSet) InternalGet(i int) (token.Token, error) {
+	return getSetOperand(e.a, e.b, i)
+}
+
+// InternalLen returns the number of referenced internal tokens
+func (e *NotInSet) InternalLen() int {
+	return 1 + len(e.b)
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (e *NotInSet) InternalLogicalRemove(tok token.Token) token.Token {
+	if hasSetOperand(e.a, e.b, tok) {
+		return nil
+	}
+
+	return e
+}
+
+// InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
+func (e *NotInSet) InternalReplace(oldToken, newToken token.Token) error {
+	replaceSetOperand(&e.a, e.b, oldToken, newToken)
+
+	return nil
+}
+
+// setMember returns the token which holds the permutations of the given token
+func setMember(a token.Token) token.Token {
+	for {
+		switch t := a.(type) {
+		case *primitives.Pointer:
+			a = t.Resolve()
+		case *primitives.Scope:
+			a = t.Resolve()
+		default:
+			return a
+		}
+	}
+}
+
+func permutationsSetMember(a token.Token) uint {
+	if p := setMember(a).Permutations(); p > 0 {
+		return p
+	}
+
+	return 1
+}
+
+// parseSetValues parses the value of a set operation. Since the first operand is generated by the set operation, its permutations are tried until the value of the set operation matches.
+func parseSetValues(pars *token.InternalParser, cur int, a token.Token, value func() string) (int, []error) {
+	a = setMember(a)
+	permutations := a.Permutations()
+
+	var v string
+
+	for i := uint(0); i == 0 || i < permutations; i++ {
+		if permutations > 1 {
+			if err := a.Permutation(i); err != nil {
+				return cur, []error{err}
+			}
+		}
+
+		v = value()
+
+		if strings.HasPrefix(pars.Data[cur:], v) {
+			return cur + len(v), nil
+		}
+	}
+
+	return cur, []error{&token.ParserError{
+		Message: fmt.Sprintf("expected %q", v),
+		Type:    token.ParseErrorUnexpectedData,
+
+		Position: pars.GetPosition(cur),
+	}}
+}
+
+// parseSetMember parses the first operand of a set check. The check has to be fulfilled by the parsed value, or no value is parsed if no permutation of the first operand fulfills the check.
+func parseSetMember(pars *token.InternalParser, cur int, a token.Token, check func() bool) (int, []error) {
+	nex, errs := a.Parse(pars, cur)
+	if len(errs) == 0 {
+		if check() {
+			return nex, nil
+		}
+
+		errs = []error{&token.ParserError{
+			Message: fmt.Sprintf("%q does not fulfill the set check", pars.Data[cur:nex]),
+			Type:    token.ParseErrorUnexpectedData,
+
+			Position: pars.GetPosition(cur),
+		}}
+	}
+
+	if err := permutationSetMember(a, 0, check); err != nil {
+		return cur, []error{err}
+	} else if !check() {
+		// the token outputs nothing if the check cannot be fulfilled
+		return cur, nil
+	}
+
+	return cur, errs
+}
+
+// permutationSetMember permutates the token beginning with the given permutation until the check is fulfilled or all permutations are exhausted
+func permutationSetMember(a token.Token, i uint, check func() bool) error {
+	a = setMember(a)
+	permutations := a.Permutations()
+
+	for j := uint(0); j < permutations; j++ {
+		if err := a.Permutation((i + j) % permutations); err != nil {
+			return err
+		}
+
+		if check() {
+			break
+		}
+	}
+
+	return nil
+}
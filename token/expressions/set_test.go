@@ -0,0 +1,200 @@
+package expressions
+
+import (
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/lists"
+	"github.com/zimmski/tavor/token/primitives"
+	"github.com/zimmski/tavor/token/variables"
+)
+
+func TestSetExpressionTokensToBeTokens(t *testing.T) {
+	var tok *token.ListToken
+
+	Implements(t, tok, &UnionSet{})
+	Implements(t, tok, &IntersectionSet{})
+	Implements(t, tok, &DifferenceSet{})
+	Implements(t, tok, &InSet{})
+	Implements(t, tok, &NotInSet{})
+}
+
+func TestSetFunctions(t *testing.T) {
+	a := []string{"a", "b", "c", "a"}
+	b := []string{"c", "d"}
+
+	Equal(t, []string{"a", "b", "c", "d"}, Union(a, b))
+	Equal(t, []string{"c"}, Intersection(a, b))
+	Equal(t, []string{"a", "b"}, Difference(a, b))
+	Nil(t, Intersection(a, nil))
+
+	True(t, Subset([]string{"c"}, b))
+	False(t, Subset(a, b))
+	True(t, Disjoint([]string{"a", "b"}, b))
+	False(t, Disjoint(a, b))
+}
+
+func TestSetValues(t *testing.T) {
+	Equal(t, []string{"1", "a"}, SetValues(primitives.NewConstantInt(1), primitives.NewConstantString("a"), primitives.NewConstantInt(1)))
+
+	r := lists.NewRepeat(primitives.NewRangeInt(1, 3), 3, 3)
+	Nil(t, r.Permutation(0))
+	Equal(t, []string{"1"}, SetValues(r))
+
+	v := variables.NewVariable("v", primitives.NewScope(r))
+	Equal(t, []string{"1"}, SetValues(variables.NewVariableValue(v)))
+
+	o := lists.NewOne(primitives.NewConstantInt(1), primitives.NewConstantInt(2))
+	Equal(t, []string{"1"}, SetValues(o))
+
+	u := NewUnionSet(o, []token.Token{primitives.NewConstantInt(3)})
+	Equal(t, []string{"1", "3"}, SetValues(u))
+}
+
+func TestUnionSet(t *testing.T) {
+	a := primitives.NewRangeInt(1, 3)
+	b := primitives.NewConstantInt(2)
+
+	o := NewUnionSet(a, []token.Token{b, primitives.NewConstantInt(3)})
+	Equal(t, "123", o.String())
+	Equal(t, 1, o.Permutations())
+	Equal(t, 3, o.PermutationsAll())
+	Equal(t, 3, o.Len())
+
+	i, err := o.Get(0)
+	Nil(t, err)
+	True(t, Exactly(t, a, i))
+	i, err = o.Get(1)
+	Nil(t, err)
+	True(t, Exactly(t, b, i))
+	i, err = o.Get(3)
+	Equal(t, err.(*lists.ListError).Type, lists.ListErrorOutOfBound)
+	Nil(t, i)
+
+	Nil(t, a.Permutation(1))
+	Equal(t, "23", o.String())
+
+	o2 := o.Clone()
+	Equal(t, o.String(), o2.String())
+}
+
+func TestIntersectionSet(t *testing.T) {
+	a := primitives.NewRangeInt(1, 3)
+
+	o := NewIntersectionSet(a, []token.Token{primitives.NewConstantInt(2), primitives.NewConstantInt(3)})
+	Equal(t, "", o.String())
+	Equal(t, 1, o.Permutations())
+
+	Nil(t, a.Permutation(1))
+	Equal(t, "2", o.String())
+
+	o2 := o.Clone()
+	Equal(t, o.String(), o2.String())
+}
+
+func TestDifferenceSet(t *testing.T) {
+	a := primitives.NewRangeInt(1, 3)
+
+	o := NewDifferenceSet(a, []token.Token{primitives.NewConstantInt(2), primitives.NewConstantInt(3)})
+	Equal(t, "1", o.String())
+	Equal(t, 1, o.Permutations())
+
+	Nil(t, a.Permutation(2))
+	Equal(t, "", o.String())
+
+	o2 := o.Clone()
+	Equal(t, o.String(), o2.String())
+
+	// inputs are parsed by choosing a permutation of the first operand
+	pars := &token.InternalParser{
+		Data:    "1",
+		DataLen: 1,
+	}
+	nex, errs := o.Parse(pars, 0)
+	Nil(t, errs)
+	Equal(t, 1, nex)
+	Equal(t, "1", o.String())
+}
+
+func TestInSet(t *testing.T) {
+	a := primitives.NewRangeInt(1, 3)
+	b := primitives.NewConstantInt(2)
+
+	o := NewInSet(a, []token.Token{b})
+	False(t, o.Evaluate())
+	Equal(t, 3, o.Permutations())
+	Equal(t, 0, o.Len())
+	Equal(t, 2, o.InternalLen())
+
+	i, err := o.InternalGet(0)
+	Nil(t, err)
+	True(t, Exactly(t, a, i))
+	i, err = o.InternalGet(1)
+	Nil(t, err)
+	True(t, Exactly(t, b, i))
+
+	// the first operand is chosen while the token is output
+	Nil(t, o.Permutation(0))
+	False(t, o.Evaluate())
+	Equal(t, "2", o.String())
+	True(t, o.Evaluate())
+
+	Nil(t, o.Permutation(2))
+	Equal(t, "2", o.String())
+
+	// the first operand is chosen again if the second operand changes after the permutation
+	b2 := primitives.NewRangeInt(1, 3)
+	o = NewInSet(a, []token.Token{b2})
+	Nil(t, o.Permutation(0))
+	Nil(t, b2.Permutation(2))
+	Equal(t, "3", o.String())
+
+	o2 := o.Clone()
+	Equal(t, o.String(), o2.String())
+
+	// inputs have to fulfill the check
+	o = NewInSet(primitives.NewRangeInt(1, 3), []token.Token{b})
+	pars := &token.InternalParser{
+		Data:    "2",
+		DataLen: 1,
+	}
+	nex, errs := o.Parse(pars, 0)
+	Nil(t, errs)
+	Equal(t, 1, nex)
+
+	pars = &token.InternalParser{
+		Data:    "3",
+		DataLen: 1,
+	}
+	_, errs = o.Parse(pars, 0)
+	NotNil(t, errs)
+
+	// the check cannot be fulfilled
+	o = NewInSet(primitives.NewConstantInt(1), []token.Token{b})
+	Nil(t, o.Permutation(0))
+	False(t, o.Evaluate())
+	Equal(t, "", o.String())
+
+	// nothing is parsed if the check cannot be fulfilled
+	nex, errs = o.Parse(pars, 0)
+	Nil(t, errs)
+	Equal(t, 0, nex)
+}
+
+func TestNotInSet(t *testing.T) {
+	a := primitives.NewScope(lists.NewOne(primitives.NewConstantString("a"), primitives.NewConstantString("b")))
+	b := primitives.NewConstantString("a")
+
+	o := NewNotInSet(a, []token.Token{b})
+	False(t, o.Evaluate())
+	Equal(t, 2, o.Permutations())
+
+	Nil(t, o.Permutation(0))
+	Equal(t, "b", o.String())
+	True(t, o.Evaluate())
+
+	o2 := o.Clone()
+	Equal(t, o.String(), o2.String())
+}
@@ -0,0 +1,116 @@
+package expressions
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/zimmski/tavor/token"
+)
+
+func init() {
+	RegisterFunction("upper", FunctionCall{
+		MinArguments: 1,
+		MaxArguments: 1,
+		Call: func(args []token.Token) string {
+			return strings.ToUpper(args[0].String())
+		},
+	})
+	RegisterFunction("lower", FunctionCall{
+		MinArguments: 1,
+		MaxArguments: 1,
+		Call: func(args []token.Token) string {
+			return strings.ToLower(args[0].String())
+		},
+	})
+	RegisterFunction("reverse", FunctionCall{
+		MinArguments: 1,
+		MaxArguments: 1,
+		Call: func(args []token.Token) string {
+			return Reverse(args[0].String())
+		},
+	})
+	RegisterFunction("substr", FunctionCall{
+		MinArguments: 3,
+		MaxArguments: 3,
+		Call: func(args []token.Token) string {
+			return Substr(args[0].String(), IntArgument(args[1]), IntArgument(args[2]))
+		},
+	})
+	RegisterFunction("pad_left", FunctionCall{
+		MinArguments: 3,
+		MaxArguments: 3,
+		Call: func(args []token.Token) string {
+			return PadLeft(args[0].String(), IntArgument(args[1]), args[2].String())
+		},
+	})
+	RegisterFunction("repeat", FunctionCall{
+		MinArguments: 2,
+		MaxArguments: 2,
+		Call: func(args []token.Token) string {
+			n := IntArgument(args[1])
+			if n < 0 {
+				return ""
+			}
+
+			return strings.Repeat(args[0].String(), n)
+		},
+	})
+	RegisterFunction("concat", FunctionCall{
+		MinArguments: 1,
+		MaxArguments: -1,
+		Call: func(args []token.Token) string {
+			var buffer bytes.Buffer
+
+			for _, arg := range args {
+				if _, err := buffer.WriteString(arg.String()); err != nil {
+					panic(err)
+				}
+			}
+
+			return buffer.String()
+		},
+	})
+}
+
+// Reverse returns the characters of the given string in reverse order
+func Reverse(s string) string {
+	r := []rune(s)
+
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+
+	return string(r)
+}
+
+// Substr returns at most n characters of the given string beginning with the character at index from. Out of bound values are clamped to the string.
+func Substr(s string, from int, n int) string {
+	r := []rune(s)
+
+	if from < 0 {
+		from = 0
+	} else if from > len(r) {
+		from = len(r)
+	}
+
+	if n < 0 {
+		n = 0
+	} else if from+n > len(r) {
+		n = len(r) - from
+	}
+
+	return string(r[from : from+n])
+}
+
+// PadLeft returns the given string prepended by the characters of pad until it is n characters long
+func PadLeft(s string, n int, pad string) string {
+	missing := n - len([]rune(s))
+
+	if missing <= 0 || pad == "" {
+		return s
+	}
+
+	p := []rune(strings.Repeat(pad, missing/len([]rune(pad))+1))
+
+	return string(p[:missing]) + s
+}
@@ -0,0 +1,50 @@
+package expressions
+
+import (
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/primitives"
+)
+
+func TestStringHelpers(t *testing.T) {
+	Equal(t, "cba", Reverse("abc"))
+	Equal(t, "üba", Reverse("abü"))
+	Equal(t, "", Reverse(""))
+
+	Equal(t, "bc", Substr("abcd", 1, 2))
+	Equal(t, "cd", Substr("abcd", 2, 10))
+	Equal(t, "ab", Substr("abcd", -1, 2))
+	Equal(t, "", Substr("abcd", 5, 2))
+	Equal(t, "", Substr("abcd", 1, -1))
+
+	Equal(t, "00ab", PadLeft("ab", 4, "0"))
+	Equal(t, "xyxab", PadLeft("ab", 5, "xy"))
+	Equal(t, "abc", PadLeft("abc", 2, "0"))
+	Equal(t, "ab", PadLeft("ab", 4, ""))
+}
+
+func TestStringFunctions(t *testing.T) {
+	call := func(name string, args ...token.Token) string {
+		o, err := NewFunction(name, args...)
+		Nil(t, err)
+
+		return o.String()
+	}
+
+	s := primitives.NewConstantString("Tavor")
+
+	Equal(t, "TAVOR", call("upper", s))
+	Equal(t, "tavor", call("lower", s))
+	Equal(t, "rovaT", call("reverse", s))
+	Equal(t, "av", call("substr", s, primitives.NewConstantInt(1), primitives.NewConstantInt(2)))
+	Equal(t, "...Tavor", call("pad_left", s, primitives.NewConstantInt(8), primitives.NewConstantString(".")))
+	Equal(t, "TavorTavor", call("repeat", s, primitives.NewConstantInt(2)))
+	Equal(t, "", call("repeat", s, primitives.NewConstantInt(-1)))
+	Equal(t, "Tavor-1", call("concat", s, primitives.NewConstantString("-"), primitives.NewConstantInt(1)))
+
+	// invalid integer arguments are treated as 0
+	Equal(t, "", call("substr", s, primitives.NewConstantInt(1), s))
+}
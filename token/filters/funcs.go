@@ -6,6 +6,8 @@ import (
 
 // FuncFilter implements a filter token which takes a token and filters its output according to a fuzzing function
 type FuncFilter struct {
+	token.Origin
+
 	permutationFunc     func(state interface{}, tok token.Token, i uint) interface{}
 	permutationsFunc    func(state interface{}, tok token.Token) uint
 	permutationsAllFunc func(state interface{}, tok token.Token) uint
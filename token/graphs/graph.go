@@ -0,0 +1,1206 @@
+package graphs
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/lists"
+	"github.com/zimmski/tavor/token/primitives"
+)
+
+// Graph implements a token which generates random directed graphs
+// The nodes of a graph are numbered from 1 to the node count. Every permutation of the token generates a graph which fulfills the constraints of the token. The permutations cover all roots, orders of the nodes, parents and combinations of edges which are relevant for the constraints. A connected graph has a root node from which every other node can be reached, an acyclic graph has no cycles and a tree is a connected acyclic graph in which every node except the root has exactly one parent.
+type Graph struct {
+	token.Origin
+
+	nodes      int
+	density    int
+	acyclic    bool
+	connected  bool
+	tree       bool
+	nodeFormat string
+	edgeFormat string
+
+	order []int
+	edges [][2]int
+}
+
+// maxPathSeeds is the number of seeds which are tried to choose distinct paths of a graph
+const maxPathSeeds = 16
+
+// NewGraph returns a new instance of a Graph token
+// The density is the percentage of all possible edges of the graph which are generated, a connected graph has at least the edges which connect every node to its parent. The node format is a format string for fmt.Sprintf with one integer verb for the node, the edge format has two integer verbs for the source and the target node of an edge. Formats without verbs are emitted as they are.
+func NewGraph(nodes int, density int, acyclic bool, connected bool, tree bool, nodeFormat string, edgeFormat string) *Graph {
+	if nodes < 1 {
+		panic("TODO implement graphs without nodes")
+	}
+
+	g := &Graph{
+		nodes:      nodes,
+		density:    density,
+		acyclic:    acyclic || tree,
+		connected:  connected || tree,
+		tree:       tree,
+		nodeFormat: nodeFormat,
+		edgeFormat: edgeFormat,
+	}
+
+	g.generate(new(big.Int))
+
+	return g
+}
+
+func init() {
+	token.RegisterTyped("Graph", func(argParser token.ArgumentsTypedParser) (token.Token, error) {
+		nodes := argParser.GetInt("nodes", 5)
+		density := argParser.GetInt("density", 30)
+		acyclic := argParser.GetBool("acyclic", false)
+		connected := argParser.GetBool("connected", false)
+		tree := argParser.GetBool("tree", false)
+		nodeFormat := argParser.GetString("node", "%d\n")
+		edgeFormat := argParser.GetString("edge", "%d %d\n")
+
+		if err := argParser.Err(); err != nil {
+			return nil, err
+		}
+
+		if nodes < 1 {
+			return nil, fmt.Errorf("%q needs at least one node", "nodes")
+		}
+		if density < 0 || density > 100 {
+			return nil, fmt.Errorf("%q needs a percentage between 0 and 100", "density")
+		}
+		if s := format(nodeFormat, 1); strings.Contains(s, "%!") {
+			return nil, fmt.Errorf("%q needs a format with one integer verb or none", "node")
+		}
+		if s := format(edgeFormat, 1, 2); strings.Contains(s, "%!") {
+			return nil, fmt.Errorf("%q needs a format with two integer verbs or none", "edge")
+		}
+
+		return NewGraph(nodes, density, acyclic, connected, tree, nodeFormat, edgeFormat), nil
+	})
+}
+
+// format formats the values with the given format, a format without verbs is used as it is
+func format(f string, values ...interface{}) string {
+	if !strings.Contains(f, "%") {
+		return f
+	}
+
+	return fmt.Sprintf(f, values...)
+}
+
+// possibleEdges returns the number of edges which a graph with the constraints of the token can have at most
+func (g *Graph) possibleEdges() int {
+	if g.acyclic {
+		return g.nodes * (g.nodes - 1) / 2
+	}
+
+	return g.nodes * (g.nodes - 1)
+}
+
+// parentEdges returns the number of edges which connect every node of a connected graph to its parent
+func (g *Graph) parentEdges() int {
+	if g.connected {
+		return g.nodes - 1
+	}
+
+	return 0
+}
+
+// densityEdges returns the number of edges which are candidates for the density of the graph and how many of them are chosen
+func (g *Graph) densityEdges() (candidates int, chosen int) {
+	if g.tree {
+		return 0, 0
+	}
+
+	candidates = g.possibleEdges() - g.parentEdges()
+	chosen = (g.density*g.possibleEdges()+50)/100 - g.parentEdges()
+
+	if chosen < 0 {
+		chosen = 0
+	} else if chosen > candidates {
+		chosen = candidates
+	}
+
+	return candidates, chosen
+}
+
+// edgeCount returns the number of edges of every graph of the token
+func (g *Graph) edgeCount() int {
+	_, chosen := g.densityEdges()
+
+	return g.parentEdges() + chosen
+}
+
+// space returns the number of graphs which can be generated for the constraints of the token
+// Every graph consists of a root, the order of the other nodes if it is relevant for the constraints, a parent for every node of a connected graph and a combination of the candidates for the density.
+func (g *Graph) space() *big.Int {
+	s := big.NewInt(int64(g.nodes))
+
+	factorial := new(big.Int).MulRange(1, int64(g.nodes-1))
+	if g.acyclic || g.connected {
+		s.Mul(s, factorial)
+	}
+	if g.connected {
+		s.Mul(s, factorial)
+	}
+
+	candidates, chosen := g.densityEdges()
+
+	return s.Mul(s, new(big.Int).Binomial(int64(candidates), int64(chosen)))
+}
+
+// generate generates the graph with the given index out of the space of the token
+func (g *Graph) generate(index *big.Int) {
+	x := new(big.Int).Set(index)
+	m := new(big.Int)
+
+	next := func(n int) int {
+		x.DivMod(x, big.NewInt(int64(n)), m)
+
+		return int(m.Int64())
+	}
+
+	root := next(g.nodes) + 1
+
+	g.order = []int{root}
+
+	var rest []int
+	for n := 1; n <= g.nodes; n++ {
+		if n != root {
+			rest = append(rest, n)
+		}
+	}
+
+	if g.acyclic || g.connected {
+		for len(rest) > 0 {
+			i := next(len(rest))
+
+			g.order = append(g.order, rest[i])
+			rest = append(rest[:i], rest[i+1:]...)
+		}
+	} else {
+		g.order = append(g.order, rest...)
+	}
+
+	edges := make(map[[2]int]struct{})
+
+	// every node gets a parent which is before it in the order so the root can reach every node without introducing a cycle
+	if g.connected {
+		for i := 1; i < g.nodes; i++ {
+			edges[[2]int{g.order[next(i)], g.order[i]}] = struct{}{}
+		}
+	}
+
+	if _, chosen := g.densityEdges(); chosen > 0 {
+		var candidates [][2]int
+
+		for i := 0; i < g.nodes; i++ {
+			for j := 0; j < g.nodes; j++ {
+				if i == j || (g.acyclic && i > j) {
+					continue
+				}
+
+				e := [2]int{g.order[i], g.order[j]}
+
+				if _, ok := edges[e]; !ok {
+					candidates = append(candidates, e)
+				}
+			}
+		}
+
+		// the rest of the index is the index of the combination of the chosen candidates
+		for i := 0; i < len(candidates) && chosen > 0; i++ {
+			with := new(big.Int).Binomial(int64(len(candidates)-i-1), int64(chosen-1))
+
+			if x.Cmp(with) < 0 {
+				edges[candidates[i]] = struct{}{}
+				chosen--
+			} else {
+				x.Sub(x, with)
+			}
+		}
+	}
+
+	g.edges = make([][2]int, 0, len(edges))
+	for e := range edges {
+		g.edges = append(g.edges, e)
+	}
+
+	sort.Sort(edgesByNodes(g.edges))
+}
+
+type edgesByNodes [][2]int
+
+func (e edgesByNodes) Len() int      { return len(e) }
+func (e edgesByNodes) Swap(i, j int) { e[i], e[j] = e[j], e[i] }
+func (e edgesByNodes) Less(i, j int) bool {
+	if e[i][0] != e[j][0] {
+		return e[i][0] < e[j][0]
+	}
+
+	return e[i][1] < e[j][1]
+}
+
+// Nodes returns the nodes of the current graph in ascending order
+func (g *Graph) Nodes() []int {
+	nodes := make([]int, g.nodes)
+
+	for i := range nodes {
+		nodes[i] = i + 1
+	}
+
+	return nodes
+}
+
+// Edges returns the edges of the current graph ordered by their source and target nodes
+func (g *Graph) Edges() [][2]int {
+	return g.edges
+}
+
+// Root returns the root node of the current graph. For connected graphs every node can be reached from the root.
+func (g *Graph) Root() int {
+	return g.order[0]
+}
+
+// Path returns a path of the current graph beginning at the root node. The next node of the path is chosen by the given seed out of the successors which are not already on the path.
+func (g *Graph) Path(seed uint) []int {
+	r := rand.New(rand.NewSource(int64(seed)))
+
+	successors := make(map[int][]int)
+	for _, e := range g.edges {
+		successors[e[0]] = append(successors[e[0]], e[1])
+	}
+
+	n := g.Root()
+	path := []int{n}
+	visited := map[int]struct{}{
+		n: struct{}{},
+	}
+
+	for {
+		var next []int
+
+		for _, s := range successors[n] {
+			if _, ok := visited[s]; !ok {
+				next = append(next, s)
+			}
+		}
+
+		if len(next) == 0 {
+			break
+		}
+
+		n = next[r.Intn(len(next))]
+		path = append(path, n)
+		visited[n] = struct{}{}
+	}
+
+	return path
+}
+
+func (g *Graph) nodesString(nodes []int) string {
+	var buffer bytes.Buffer
+
+	for _, n := range nodes {
+		if _, err := buffer.WriteString(format(g.nodeFormat, n)); err != nil {
+			panic(err)
+		}
+	}
+
+	return buffer.String()
+}
+
+func (g *Graph) edgesString() string {
+	var buffer bytes.Buffer
+
+	for _, e := range g.edges {
+		if _, err := buffer.WriteString(format(g.edgeFormat, e[0], e[1])); err != nil {
+			panic(err)
+		}
+	}
+
+	return buffer.String()
+}
+
+// parseString parses the given string beginning from the current position in the parser data
+func parseString(pars *token.InternalParser, cur int, v string) (int, error) {
+	nextIndex := len(v) + cur
+
+	if nextIndex > pars.DataLen {
+		return cur, &token.ParserError{
+			Message: fmt.Sprintf("expected %q but got early EOF", v),
+			Type:    token.ParseErrorUnexpectedEOF,
+
+			Position: pars.GetPosition(cur),
+		}
+	}
+
+	if got := pars.Data[cur:nextIndex]; v != got {
+		return cur, &token.ParserError{
+			Message: fmt.Sprintf("expected %q but got %q", v, got),
+			Type:    token.ParseErrorUnexpectedData,
+
+			Position: pars.GetPosition(cur),
+		}
+	}
+
+	return nextIndex, nil
+}
+
+// parseFormat parses the given format beginning from the current position in the parser data and returns the values of its integer verbs
+func parseFormat(pars *token.InternalParser, cur int, f string) ([]int, int, error) {
+	var values []int
+
+	i := cur
+
+	for j := 0; j < len(f); j++ {
+		if f[j] == '%' && j+1 < len(f) {
+			j++
+
+			if f[j] == 'd' {
+				k := i
+				for k < pars.DataLen && pars.Data[k] >= '0' && pars.Data[k] <= '9' {
+					k++
+				}
+
+				v, err := strconv.Atoi(pars.Data[i:k])
+				if err != nil {
+					if k == pars.DataLen {
+						return nil, cur, &token.ParserError{
+							Message: fmt.Sprintf("expected %q but got early EOF", f),
+							Type:    token.ParseErrorUnexpectedEOF,
+
+							Position: pars.GetPosition(cur),
+						}
+					}
+
+					return nil, cur, &token.ParserError{
+						Message: fmt.Sprintf("expected %q but got %q", f, pars.Data[cur:k+1]),
+						Type:    token.ParseErrorUnexpectedData,
+
+						Position: pars.GetPosition(cur),
+					}
+				}
+
+				values = append(values, v)
+				i = k
+
+				continue
+			} else if f[j] != '%' {
+				return nil, cur, &token.ParserError{
+					Message: fmt.Sprintf("format %q cannot be parsed since only integer verbs are supported", f),
+					Type:    token.ParseErrorUnexpectedData,
+
+					Position: pars.GetPosition(cur),
+				}
+			}
+		}
+
+		if i == pars.DataLen {
+			return nil, cur, &token.ParserError{
+				Message: fmt.Sprintf("expected %q but got early EOF", f),
+				Type:    token.ParseErrorUnexpectedEOF,
+
+				Position: pars.GetPosition(cur),
+			}
+		}
+
+		if pars.Data[i] != f[j] {
+			return nil, cur, &token.ParserError{
+				Message: fmt.Sprintf("expected %q but got %q", f, pars.Data[cur:i+1]),
+				Type:    token.ParseErrorUnexpectedData,
+
+				Position: pars.GetPosition(cur),
+			}
+		}
+
+		i++
+	}
+
+	return values, i, nil
+}
+
+// parseNode parses a node in the node format of the graph
+func (g *Graph) parseNode(pars *token.InternalParser, cur int) (int, int, error) {
+	values, next, err := parseFormat(pars, cur, g.nodeFormat)
+	if err != nil {
+		return 0, cur, err
+	}
+
+	if len(values) != 1 {
+		return 0, cur, &token.ParserError{
+			Message: fmt.Sprintf("node format %q cannot be parsed since it has no integer verb", g.nodeFormat),
+			Type:    token.ParseErrorUnexpectedData,
+
+			Position: pars.GetPosition(cur),
+		}
+	}
+
+	if values[0] < 1 || values[0] > g.nodes {
+		return 0, cur, &token.ParserError{
+			Message: fmt.Sprintf("expected node in range 1-%d but got %d", g.nodes, values[0]),
+			Type:    token.ParseErrorUnexpectedData,
+
+			Position: pars.GetPosition(cur),
+		}
+	}
+
+	return values[0], next, nil
+}
+
+// parseEdges parses the edges of the graph and sets them as the current edges if they fulfill the constraints of the token
+func (g *Graph) parseEdges(pars *token.InternalParser, cur int) (int, error) {
+	edges := make([][2]int, 0, g.edgeCount())
+	next := cur
+
+	for len(edges) < g.edgeCount() {
+		values, n, err := parseFormat(pars, next, g.edgeFormat)
+		if err != nil {
+			return cur, err
+		}
+
+		if len(values) != 2 {
+			return cur, &token.ParserError{
+				Message: fmt.Sprintf("edge format %q cannot be parsed since it has no two integer verbs", g.edgeFormat),
+				Type:    token.ParseErrorUnexpectedData,
+
+				Position: pars.GetPosition(cur),
+			}
+		}
+
+		e := [2]int{values[0], values[1]}
+
+		if e[0] < 1 || e[0] > g.nodes || e[1] < 1 || e[1] > g.nodes || e[0] == e[1] {
+			return cur, &token.ParserError{
+				Message: fmt.Sprintf("expected edge between two distinct nodes in range 1-%d but got %d-%d", g.nodes, e[0], e[1]),
+				Type:    token.ParseErrorUnexpectedData,
+
+				Position: pars.GetPosition(next),
+			}
+		}
+
+		// edges are ordered by their nodes which also rules out duplicated edges
+		if len(edges) > 0 && !edgesByNodes([][2]int{edges[len(edges)-1], e}).Less(0, 1) {
+			return cur, &token.ParserError{
+				Message: fmt.Sprintf("expected edge after %d-%d but got %d-%d", edges[len(edges)-1][0], edges[len(edges)-1][1], e[0], e[1]),
+				Type:    token.ParseErrorUnexpectedData,
+
+				Position: pars.GetPosition(next),
+			}
+		}
+
+		edges = append(edges, e)
+		next = n
+	}
+
+	c := *g
+	c.edges = edges
+
+	if !c.validEdges() {
+		return cur, &token.ParserError{
+			Message: fmt.Sprintf("edges %v do not fulfill the constraints of the graph", edges),
+			Type:    token.ParseErrorUnexpectedData,
+
+			Position: pars.GetPosition(cur),
+		}
+	}
+
+	// keep the current root if it fulfills the constraints, otherwise use the first node which does
+	if !c.validRoot(c.Root()) {
+		for _, n := range c.Nodes() {
+			if c.validRoot(n) {
+				c.setRoot(n)
+
+				break
+			}
+		}
+	}
+
+	g.order = c.order
+	g.edges = c.edges
+
+	return next, nil
+}
+
+// validEdges returns true if the current edges fulfill the constraints of the token which do not depend on the root
+func (g *Graph) validEdges() bool {
+	if g.acyclic {
+		in := make(map[int]int)
+		successors := make(map[int][]int)
+		for _, e := range g.edges {
+			in[e[1]]++
+			successors[e[0]] = append(successors[e[0]], e[1])
+		}
+
+		var queue []int
+		for _, n := range g.Nodes() {
+			if in[n] == 0 {
+				queue = append(queue, n)
+			}
+		}
+
+		visited := 0
+		for len(queue) > 0 {
+			n := queue[0]
+			queue = queue[1:]
+			visited++
+
+			for _, s := range successors[n] {
+				in[s]--
+				if in[s] == 0 {
+					queue = append(queue, s)
+				}
+			}
+		}
+
+		if visited != g.nodes {
+			return false
+		}
+	}
+
+	if g.tree {
+		parents := make(map[int]struct{})
+		for _, e := range g.edges {
+			if _, ok := parents[e[1]]; ok {
+				return false
+			}
+
+			parents[e[1]] = struct{}{}
+		}
+	}
+
+	for n := 1; n <= g.nodes; n++ {
+		if g.validRoot(n) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validRoot returns true if the given node can be the root of the current edges
+func (g *Graph) validRoot(root int) bool {
+	if !g.connected {
+		return true
+	}
+
+	successors := make(map[int][]int)
+	for _, e := range g.edges {
+		successors[e[0]] = append(successors[e[0]], e[1])
+	}
+
+	found := map[int]struct{}{
+		root: struct{}{},
+	}
+	queue := []int{root}
+
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+
+		for _, s := range successors[n] {
+			if _, ok := found[s]; !ok {
+				found[s] = struct{}{}
+				queue = append(queue, s)
+			}
+		}
+	}
+
+	return len(found) == g.nodes
+}
+
+// setRoot sets the root of the current graph
+func (g *Graph) setRoot(root int) {
+	order := []int{root}
+
+	for _, n := range g.order {
+		if n != root {
+			order = append(order, n)
+		}
+	}
+
+	g.order = order
+}
+
+// Token interface methods
+
+// Clone returns a copy of the token and all its children
+func (g *Graph) Clone() token.Token {
+	c := *g
+
+	return &c
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (g *Graph) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	next, err := parseString(pars, cur, g.nodesString(g.Nodes()))
+	if err != nil {
+		return cur, []error{err}
+	}
+
+	next, err = g.parseEdges(pars, next)
+	if err != nil {
+		return cur, []error{err}
+	}
+
+	return next, nil
+}
+
+// Permutation sets a specific permutation for this token
+func (g *Graph) Permutation(i uint) error {
+	permutations := g.Permutations()
+
+	if i >= permutations {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+
+	index := new(big.Int).SetUint64(uint64(i))
+
+	// spread the permutations over the whole space if there are too many graphs to count them
+	if space := g.space(); space.Cmp(big.NewInt(math.MaxUint32)) > 0 {
+		index.Mul(index, space)
+		index.Div(index, big.NewInt(math.MaxUint32))
+	}
+
+	g.generate(index)
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (g *Graph) Permutations() uint {
+	space := g.space()
+
+	if space.Cmp(big.NewInt(math.MaxUint32)) > 0 {
+		return math.MaxUint32
+	}
+
+	return uint(space.Uint64())
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (g *Graph) PermutationsAll() uint {
+	return g.Permutations()
+}
+
+func (g *Graph) String() string {
+	return g.nodesString(g.Nodes()) + g.edgesString()
+}
+
+// ResetItem returns a new instance of a GraphResetItem token referencing the graph
+func (g *Graph) ResetItem() *GraphResetItem {
+	return &GraphResetItem{
+		graph: g,
+	}
+}
+
+// NodesItem returns a new instance of a GraphNodesItem token referencing the graph
+func (g *Graph) NodesItem() *GraphNodesItem {
+	return &GraphNodesItem{
+		graph: g,
+	}
+}
+
+// EdgesItem returns a new instance of a GraphEdgesItem token referencing the graph
+func (g *Graph) EdgesItem() *GraphEdgesItem {
+	return &GraphEdgesItem{
+		graph: g,
+	}
+}
+
+// PathItem returns a new instance of a GraphPathItem token referencing the graph
+func (g *Graph) PathItem() *GraphPathItem {
+	return &GraphPathItem{
+		graph: g,
+	}
+}
+
+// RootItem returns a new instance of a GraphRootItem token referencing the graph
+func (g *Graph) RootItem() *GraphRootItem {
+	return &GraphRootItem{
+		graph: g,
+	}
+}
+
+// itemPermutation checks the permutation of an item which has only one permutation
+func itemPermutation(i uint) error {
+	if i != 0 {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+
+	return nil
+}
+
+// GraphResetItem implements a graph item token which chooses a new graph for its referencing graph on every permutation
+// The item does not emit anything. It is used so that the graph is chosen once per generation regardless of which items of the graph are part of the generation.
+type GraphResetItem struct {
+	token.Origin
+
+	graph *Graph
+}
+
+// Clone returns a copy of the token and all its children
+func (g *GraphResetItem) Clone() token.Token {
+	return &GraphResetItem{
+		graph: g.graph,
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (g *GraphResetItem) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	return cur, nil
+}
+
+// Permutation sets a specific permutation for this token
+func (g *GraphResetItem) Permutation(i uint) error {
+	return g.graph.Permutation(i)
+}
+
+// Permutations returns the number of permutations for this token
+func (g *GraphResetItem) Permutations() uint {
+	return g.graph.Permutations()
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (g *GraphResetItem) PermutationsAll() uint {
+	return g.Permutations()
+}
+
+func (g *GraphResetItem) String() string {
+	return ""
+}
+
+// GraphNodesItem implements a graph item token which holds the nodes of the graph
+type GraphNodesItem struct {
+	token.Origin
+
+	graph *Graph
+}
+
+// Clone returns a copy of the token and all its children
+func (g *GraphNodesItem) Clone() token.Token {
+	return &GraphNodesItem{
+		graph: g.graph,
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (g *GraphNodesItem) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	next, err := parseString(pars, cur, g.String())
+	if err != nil {
+		return cur, []error{err}
+	}
+
+	return next, nil
+}
+
+// Permutation sets a specific permutation for this token
+func (g *GraphNodesItem) Permutation(i uint) error {
+	return itemPermutation(i)
+}
+
+// Permutations returns the number of permutations for this token
+func (g *GraphNodesItem) Permutations() uint {
+	return 1
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (g *GraphNodesItem) PermutationsAll() uint {
+	return g.Permutations()
+}
+
+func (g *GraphNodesItem) String() string {
+	return g.graph.nodesString(g.graph.Nodes())
+}
+
+// List interface methods
+
+// Get returns the current referenced token at the given index. The error return argument is not nil, if the index is out of bound.
+func (g *GraphNodesItem) Get(i int) (token.Token, error) {
+	if i < 0 || i >= g.graph.nodes {
+		return nil, &lists.ListError{
+			Type: lists.ListErrorOutOfBound,
+		}
+	}
+
+	return primitives.NewConstantInt(i + 1), nil
+}
+
+// Len returns the number of the current referenced tokens
+func (g *GraphNodesItem) Len() int {
+	return g.graph.nodes
+}
+
+// InternalGet returns the current referenced internal token at the given index. The error return argument is not nil, if the index is out of bound.
+func (g *GraphNodesItem) InternalGet(i int) (token.Token, error) {
+	return nil, &lists.ListError{
+		Type: lists.ListErrorOutOfBound,
+	}
+}
+
+// InternalLen returns the number of referenced internal tokens
+func (g *GraphNodesItem) InternalLen() int {
+	return 0
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (g *GraphNodesItem) InternalLogicalRemove(tok token.Token) token.Token {
+	panic("This should never happen")
+}
+
+// InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
+func (g *GraphNodesItem) InternalReplace(oldToken, newToken token.Token) error {
+	panic("This should never happen")
+}
+
+// GraphEdgesItem implements a graph item token which holds the edges of the graph
+// Every edge is a list of its source and target node. Parsing the token sets the edges of the graph.
+type GraphEdgesItem struct {
+	token.Origin
+
+	graph *Graph
+}
+
+// Clone returns a copy of the token and all its children
+func (g *GraphEdgesItem) Clone() token.Token {
+	return &GraphEdgesItem{
+		graph: g.graph,
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (g *GraphEdgesItem) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	next, err := g.graph.parseEdges(pars, cur)
+	if err != nil {
+		return cur, []error{err}
+	}
+
+	return next, nil
+}
+
+// Permutation sets a specific permutation for this token
+func (g *GraphEdgesItem) Permutation(i uint) error {
+	return itemPermutation(i)
+}
+
+// Permutations returns the number of permutations for this token
+func (g *GraphEdgesItem) Permutations() uint {
+	return 1
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (g *GraphEdgesItem) PermutationsAll() uint {
+	return g.Permutations()
+}
+
+func (g *GraphEdgesItem) String() string {
+	return g.graph.edgesString()
+}
+
+// List interface methods
+
+// Get returns the current referenced token at the given index. The error return argument is not nil, if the index is out of bound.
+func (g *GraphEdgesItem) Get(i int) (token.Token, error) {
+	if i < 0 || i >= len(g.graph.edges) {
+		return nil, &lists.ListError{
+			Type: lists.ListErrorOutOfBound,
+		}
+	}
+
+	e := g.graph.edges[i]
+
+	return lists.NewConcatenation(
+		primitives.NewConstantInt(e[0]),
+		primitives.NewConstantInt(e[1]),
+	), nil
+}
+
+// Len returns the number of the current referenced tokens
+func (g *GraphEdgesItem) Len() int {
+	return len(g.graph.edges)
+}
+
+// InternalGet returns the current referenced internal token at the given index. The error return argument is not nil, if the index is out of bound.
+func (g *GraphEdgesItem) InternalGet(i int) (token.Token, error) {
+	return nil, &lists.ListError{
+		Type: lists.ListErrorOutOfBound,
+	}
+}
+
+// InternalLen returns the number of referenced internal tokens
+func (g *GraphEdgesItem) InternalLen() int {
+	return 0
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (g *GraphEdgesItem) InternalLogicalRemove(tok token.Token) token.Token {
+	panic("This should never happen")
+}
+
+// InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
+func (g *GraphEdgesItem) InternalReplace(oldToken, newToken token.Token) error {
+	panic("This should never happen")
+}
+
+// GraphPathItem implements a graph item token which holds a path of the graph beginning at its root
+// Every permutation chooses a distinct path of the current graph out of the paths of the first maxPathSeeds seeds.
+type GraphPathItem struct {
+	token.Origin
+
+	graph *Graph
+	seed  uint
+	path  []int
+}
+
+// Clone returns a copy of the token and all its children
+func (g *GraphPathItem) Clone() token.Token {
+	return &GraphPathItem{
+		graph: g.graph,
+		seed:  g.seed,
+		path:  g.path,
+	}
+}
+
+// pathSeeds returns the seeds out of the first maxPathSeeds seeds which choose distinct paths of the current graph
+func (g *GraphPathItem) pathSeeds() []uint {
+	var seeds []uint
+	found := make(map[string]struct{})
+
+	for seed := uint(0); seed < maxPathSeeds; seed++ {
+		key := fmt.Sprint(g.graph.Path(seed))
+
+		if _, ok := found[key]; !ok {
+			found[key] = struct{}{}
+			seeds = append(seeds, seed)
+		}
+	}
+
+	return seeds
+}
+
+// current returns the current path which is either the parsed path or the path of the current seed
+func (g *GraphPathItem) current() []int {
+	if g.path != nil {
+		return g.path
+	}
+
+	return g.graph.Path(g.seed)
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (g *GraphPathItem) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	successors := make(map[int][]int)
+	for _, e := range g.graph.edges {
+		successors[e[0]] = append(successors[e[0]], e[1])
+	}
+
+	root, next, err := g.graph.parseNode(pars, cur)
+	if err != nil {
+		return cur, []error{err}
+	}
+
+	if root != g.graph.Root() {
+		return cur, []error{&token.ParserError{
+			Message: fmt.Sprintf("expected root node %d but got %d", g.graph.Root(), root),
+			Type:    token.ParseErrorUnexpectedData,
+
+			Position: pars.GetPosition(cur),
+		}}
+	}
+
+	path := []int{root}
+	visited := map[int]struct{}{
+		root: struct{}{},
+	}
+
+	// the path ends if there are no successors left which are not already on the path
+	for {
+		var left []int
+		for _, s := range successors[path[len(path)-1]] {
+			if _, ok := visited[s]; !ok {
+				left = append(left, s)
+			}
+		}
+
+		if len(left) == 0 {
+			break
+		}
+
+		node, i, err := g.graph.parseNode(pars, next)
+		if err != nil {
+			return cur, []error{err}
+		}
+
+		found := false
+		for _, s := range left {
+			if s == node {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return cur, []error{&token.ParserError{
+				Message: fmt.Sprintf("expected one of the nodes %v but got %d", left, node),
+				Type:    token.ParseErrorUnexpectedData,
+
+				Position: pars.GetPosition(next),
+			}}
+		}
+
+		path = append(path, node)
+		visited[node] = struct{}{}
+		next = i
+	}
+
+	g.path = path
+
+	return next, nil
+}
+
+// Permutation sets a specific permutation for this token
+func (g *GraphPathItem) Permutation(i uint) error {
+	seeds := g.pathSeeds()
+
+	if i >= uint(len(seeds)) {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+
+	g.seed = seeds[i]
+	g.path = nil
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (g *GraphPathItem) Permutations() uint {
+	return uint(len(g.pathSeeds()))
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (g *GraphPathItem) PermutationsAll() uint {
+	return g.Permutations()
+}
+
+func (g *GraphPathItem) String() string {
+	return g.graph.nodesString(g.current())
+}
+
+// List interface methods
+
+// Get returns the current referenced token at the given index. The error return argument is not nil, if the index is out of bound.
+func (g *GraphPathItem) Get(i int) (token.Token, error) {
+	path := g.current()
+
+	if i < 0 || i >= len(path) {
+		return nil, &lists.ListError{
+			Type: lists.ListErrorOutOfBound,
+		}
+	}
+
+	return primitives.NewConstantInt(path[i]), nil
+}
+
+// Len returns the number of the current referenced tokens
+func (g *GraphPathItem) Len() int {
+	return len(g.current())
+}
+
+// InternalGet returns the current referenced internal token at the given index. The error return argument is not nil, if the index is out of bound.
+func (g *GraphPathItem) InternalGet(i int) (token.Token, error) {
+	return nil, &lists.ListError{
+		Type: lists.ListErrorOutOfBound,
+	}
+}
+
+// InternalLen returns the number of referenced internal tokens
+func (g *GraphPathItem) InternalLen() int {
+	return 0
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (g *GraphPathItem) InternalLogicalRemove(tok token.Token) token.Token {
+	panic("This should never happen")
+}
+
+// InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
+func (g *GraphPathItem) InternalReplace(oldToken, newToken token.Token) error {
+	panic("This should never happen")
+}
+
+// GraphRootItem implements a graph item token which holds the root node of the graph
+// Parsing the token sets the root of the graph.
+type GraphRootItem struct {
+	token.Origin
+
+	graph *Graph
+}
+
+// Clone returns a copy of the token and all its children
+func (g *GraphRootItem) Clone() token.Token {
+	return &GraphRootItem{
+		graph: g.graph,
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (g *GraphRootItem) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	values, next, err := parseFormat(pars, cur, "%d")
+	if err != nil {
+		return cur, []error{err}
+	}
+
+	if root := values[0]; root < 1 || root > g.graph.nodes || !g.graph.validRoot(root) {
+		return cur, []error{&token.ParserError{
+			Message: fmt.Sprintf("expected root of the graph but got %d", root),
+			Type:    token.ParseErrorUnexpectedData,
+
+			Position: pars.GetPosition(cur),
+		}}
+	}
+
+	g.graph.setRoot(values[0])
+
+	return next, nil
+}
+
+// Permutation sets a specific permutation for this token
+func (g *GraphRootItem) Permutation(i uint) error {
+	return itemPermutation(i)
+}
+
+// Permutations returns the number of permutations for this token
+func (g *GraphRootItem) Permutations() uint {
+	return 1
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (g *GraphRootItem) PermutationsAll() uint {
+	return g.Permutations()
+}
+
+func (g *GraphRootItem) String() string {
+	return strconv.Itoa(g.graph.Root())
+}
@@ -0,0 +1,322 @@
+package graphs
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/lists"
+)
+
+func TestGraphTokensToBeTokens(t *testing.T) {
+	var tok *token.Token
+
+	Implements(t, tok, &Graph{})
+
+	var list *token.ListToken
+
+	Implements(t, list, &GraphNodesItem{})
+	Implements(t, list, &GraphEdgesItem{})
+	Implements(t, list, &GraphPathItem{})
+
+	Implements(t, tok, &GraphRootItem{})
+}
+
+func reachable(g *Graph) map[int]struct{} {
+	successors := make(map[int][]int)
+	for _, e := range g.Edges() {
+		successors[e[0]] = append(successors[e[0]], e[1])
+	}
+
+	found := map[int]struct{}{
+		g.Root(): struct{}{},
+	}
+	queue := []int{g.Root()}
+
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+
+		for _, s := range successors[n] {
+			if _, ok := found[s]; !ok {
+				found[s] = struct{}{}
+				queue = append(queue, s)
+			}
+		}
+	}
+
+	return found
+}
+
+func acyclic(g *Graph) bool {
+	in := make(map[int]int)
+	successors := make(map[int][]int)
+	for _, e := range g.Edges() {
+		in[e[1]]++
+		successors[e[0]] = append(successors[e[0]], e[1])
+	}
+
+	var queue []int
+	for _, n := range g.Nodes() {
+		if in[n] == 0 {
+			queue = append(queue, n)
+		}
+	}
+
+	visited := 0
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		visited++
+
+		for _, s := range successors[n] {
+			in[s]--
+			if in[s] == 0 {
+				queue = append(queue, s)
+			}
+		}
+	}
+
+	return visited == len(g.Nodes())
+}
+
+func TestGraph(t *testing.T) {
+	o := NewGraph(3, 0, false, false, false, "%d,", "%d-%d,")
+	Equal(t, []int{1, 2, 3}, o.Nodes())
+	Equal(t, 0, len(o.Edges()))
+	Equal(t, "1,2,3,", o.String())
+	// graphs without edges only differ in their root
+	Equal(t, 3, o.Permutations())
+
+	o = NewGraph(3, 100, false, false, false, "%d,", "%d-%d,")
+	Equal(t, "1,2,3,1-2,1-3,2-1,2-3,3-1,3-2,", o.String())
+
+	o2 := o.Clone()
+	Equal(t, o.String(), o2.String())
+
+	o = NewGraph(4, 100, true, false, false, "%d,", "%d-%d,")
+	Equal(t, 6, len(o.Edges()))
+	True(t, acyclic(o))
+
+	// every permutation is a distinct graph
+	graphs := make(map[string]struct{})
+	for i := uint(0); i < o.Permutations(); i++ {
+		Nil(t, o.Permutation(i))
+
+		graphs[fmt.Sprintf("%d %v", o.Root(), o.Edges())] = struct{}{}
+	}
+	Equal(t, int(o.Permutations()), len(graphs))
+
+	o = NewGraph(1, 100, false, false, false, "%d,", "%d-%d,")
+	Equal(t, "1,", o.String())
+	Equal(t, 1, o.Permutations())
+
+	// the density is the exact share of all possible edges
+	o = NewGraph(3, 50, false, false, false, "%d,", "%d-%d,")
+	Equal(t, 3*20, o.Permutations())
+
+	graphs = make(map[string]struct{})
+	for i := uint(0); i < o.Permutations(); i++ {
+		Nil(t, o.Permutation(i))
+		Equal(t, 3, len(o.Edges()))
+
+		graphs[fmt.Sprintf("%d %v", o.Root(), o.Edges())] = struct{}{}
+	}
+	Equal(t, int(o.Permutations()), len(graphs))
+
+	// big graphs spread their permutations over all graphs
+	o = NewGraph(10, 30, false, false, false, "%d,", "%d-%d,")
+	Equal(t, math.MaxUint32, o.Permutations())
+	Equal(t, 27, len(o.Edges()))
+
+	Nil(t, o.Permutation(0))
+	first := o.String()
+	Nil(t, o.Permutation(o.Permutations()-1))
+	NotEqual(t, first, o.String())
+	Equal(t, o.Permutation(o.Permutations()).(*token.PermutationError).Type, token.PermutationErrorIndexOutOfBound)
+}
+
+func TestGraphConstraints(t *testing.T) {
+	for k := uint(0); k < 16; k++ {
+		o := NewGraph(8, 20, true, true, false, "%d", "%d %d")
+		Nil(t, o.Permutation(k*(o.Permutations()/16)))
+		Equal(t, 8, len(reachable(o)))
+		True(t, acyclic(o))
+
+		o = NewGraph(8, 20, false, true, false, "%d", "%d %d")
+		Nil(t, o.Permutation(k*(o.Permutations()/16)))
+		Equal(t, 8, len(reachable(o)))
+		Equal(t, 11, len(o.Edges()))
+
+		o = NewGraph(8, 100, false, false, true, "%d", "%d %d")
+		Nil(t, o.Permutation(k*(o.Permutations()/16)))
+		Equal(t, 7, len(o.Edges()))
+		Equal(t, 8, len(reachable(o)))
+		True(t, acyclic(o))
+
+		parents := make(map[int]struct{})
+		for _, e := range o.Edges() {
+			_, ok := parents[e[1]]
+			False(t, ok)
+
+			parents[e[1]] = struct{}{}
+		}
+	}
+}
+
+func TestGraphItems(t *testing.T) {
+	g := NewGraph(4, 0, false, false, true, "%d,", "%d-%d,")
+
+	nodes := g.NodesItem()
+	Equal(t, "1,2,3,4,", nodes.String())
+	Equal(t, 4, nodes.Len())
+	n, err := nodes.Get(3)
+	Nil(t, err)
+	Equal(t, "4", n.String())
+	n, err = nodes.Get(4)
+	Equal(t, err.(*lists.ListError).Type, lists.ListErrorOutOfBound)
+	Nil(t, n)
+
+	edges := g.EdgesItem()
+	Equal(t, 3, edges.Len())
+	Equal(t, g.edgesString(), edges.String())
+	e, err := edges.Get(0)
+	Nil(t, err)
+	Equal(t, 2, e.(token.ListToken).Len())
+
+	path := g.PathItem()
+	Equal(t, g.Root(), g.Path(0)[0])
+	Equal(t, g.nodesString(g.Path(0)), path.String())
+
+	root := g.RootItem()
+	Equal(t, strconv.Itoa(g.Root()), root.String())
+
+	reset := g.ResetItem()
+	Equal(t, "", reset.String())
+
+	// only the reset item chooses the graph
+	Equal(t, g.Permutations(), reset.Permutations())
+	Equal(t, g.Permutations(), reset.Clone().Permutations())
+	Equal(t, 1, nodes.Permutations())
+	Equal(t, 1, edges.Permutations())
+	Equal(t, 1, root.Permutations())
+	Equal(t, root.Permutation(1).(*token.PermutationError).Type, token.PermutationErrorIndexOutOfBound)
+
+	for i := uint(0); i < reset.Permutations(); i += 97 {
+		Nil(t, reset.Permutation(i))
+
+		// the items share the graph
+		Equal(t, g.edgesString(), edges.String())
+		Equal(t, strconv.Itoa(g.Root()), root.String())
+
+		paths := make(map[string]struct{})
+
+		for k := uint(0); k < path.Permutations(); k++ {
+			Nil(t, path.Permutation(k))
+
+			// every permutation of the path is a distinct path
+			_, ok := paths[path.String()]
+			False(t, ok)
+			paths[path.String()] = struct{}{}
+
+			p := g.Path(path.seed)
+			Equal(t, len(p), path.Len())
+			Equal(t, g.Root(), p[0])
+			for j := 1; j < len(p); j++ {
+				found := false
+				for _, e := range g.Edges() {
+					if e[0] == p[j-1] && e[1] == p[j] {
+						found = true
+					}
+				}
+				True(t, found)
+			}
+		}
+	}
+
+	Equal(t, path.String(), path.Clone().String())
+}
+
+func TestGraphParse(t *testing.T) {
+	g := NewGraph(4, 0, false, false, true, "%d,", "%d-%d,")
+
+	parse := func(tok token.Token, data string) (int, []error) {
+		return tok.Parse(&token.InternalParser{
+			Data:    data,
+			DataLen: len(data),
+		}, 0)
+	}
+
+	next, errs := parse(g, "1,2,3,4,2-1,2-4,4-3,")
+	Nil(t, errs)
+	Equal(t, 20, next)
+	Equal(t, [][2]int{{2, 1}, {2, 4}, {4, 3}}, g.Edges())
+	Equal(t, 2, g.Root())
+
+	// a tree has exactly one parent per node
+	_, errs = parse(g, "1,2,3,4,2-1,2-4,3-4,")
+	Equal(t, token.ParseErrorUnexpectedData, errs[0].(*token.ParserError).Type)
+	_, errs = parse(g, "1,2,3,4,2-1,3-4,4-3,")
+	Equal(t, token.ParseErrorUnexpectedData, errs[0].(*token.ParserError).Type)
+	// edges are ordered
+	_, errs = parse(g, "1,2,3,4,2-4,2-1,4-3,")
+	Equal(t, token.ParseErrorUnexpectedData, errs[0].(*token.ParserError).Type)
+	_, errs = parse(g, "1,2,3,4,2-1,")
+	Equal(t, token.ParseErrorUnexpectedEOF, errs[0].(*token.ParserError).Type)
+	_, errs = parse(g, "1,2,3,")
+	Equal(t, token.ParseErrorUnexpectedEOF, errs[0].(*token.ParserError).Type)
+
+	// items parse into the shared graph
+	next, errs = parse(g.EdgesItem(), "1-2,1-3,3-4,")
+	Nil(t, errs)
+	Equal(t, 12, next)
+	Equal(t, 1, g.Root())
+
+	_, errs = parse(g.RootItem(), "3")
+	Equal(t, token.ParseErrorUnexpectedData, errs[0].(*token.ParserError).Type)
+	next, errs = parse(g.RootItem(), "1")
+	Nil(t, errs)
+	Equal(t, 1, next)
+
+	next, errs = parse(g.NodesItem(), "1,2,3,4,")
+	Nil(t, errs)
+	Equal(t, 8, next)
+
+	path := g.PathItem()
+	next, errs = parse(path, "1,3,4,")
+	Nil(t, errs)
+	Equal(t, 6, next)
+	Equal(t, "1,3,4,", path.String())
+	// a path cannot end if there are successors left
+	_, errs = parse(path, "1,3,")
+	Equal(t, token.ParseErrorUnexpectedEOF, errs[0].(*token.ParserError).Type)
+	_, errs = parse(path, "1,4,")
+	Equal(t, token.ParseErrorUnexpectedData, errs[0].(*token.ParserError).Type)
+
+	next, errs = parse(g.ResetItem(), "1")
+	Nil(t, errs)
+	Equal(t, 0, next)
+
+	// every generated graph can be parsed
+	o := NewGraph(6, 40, true, true, false, "%d\n", "%d %d\n")
+	for i := uint(0); i < o.Permutations(); i += o.Permutations() / 16 {
+		Nil(t, o.Permutation(i))
+
+		data := o.String()
+		c := NewGraph(6, 40, true, true, false, "%d\n", "%d %d\n")
+
+		next, errs := parse(c, data)
+		Nil(t, errs)
+		Equal(t, len(data), next)
+		Equal(t, data, c.String())
+	}
+
+	// edges cannot be parsed if the edge format has no verbs
+	o = NewGraph(2, 100, false, false, false, "%d,", "-")
+	_, errs = parse(o, "1,2,--")
+	Equal(t, token.ParseErrorUnexpectedData, errs[0].(*token.ParserError).Type)
+}
@@ -0,0 +1,257 @@
+package lists
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/primitives"
+)
+
+// BitOrder defines in which order the bits of fields are packed into bytes
+type BitOrder int
+
+const (
+	// BitOrderMSB packs the bits beginning with the most significant bit of the first field into the most significant bit of the first byte
+	BitOrderMSB BitOrder = iota
+	// BitOrderLSB packs the bits beginning with the least significant bit of the first field into the least significant bit of the first byte
+	BitOrderLSB
+)
+
+// BitField holds the width in bits and the integer token of a field of a Bits token
+type BitField struct {
+	Width int
+	Token token.Token
+}
+
+// Bits implements a list token which packs the integer values of its fields into bytes. Field values are truncated to the width of their field.
+type Bits struct {
+	token.Origin
+
+	order  BitOrder
+	fields []BitField
+}
+
+// NewBits returns a new instance of a Bits token given the bit order and the fields. The widths of all fields must add up to whole bytes.
+func NewBits(order BitOrder, fields ...BitField) *Bits {
+	if len(fields) == 0 {
+		panic("at least one field needed")
+	}
+
+	width := 0
+	for _, f := range fields {
+		if f.Width < 1 || f.Width > 32 {
+			panic("field width must be between 1 and 32")
+		}
+
+		width += f.Width
+	}
+
+	if width%8 != 0 {
+		panic("field widths must add up to whole bytes")
+	}
+
+	return &Bits{
+		order:  order,
+		fields: fields,
+	}
+}
+
+// Order returns the bit order of the token
+func (l *Bits) Order() BitOrder {
+	return l.order
+}
+
+// Width returns the width of the field at the given index
+func (l *Bits) Width(i int) int {
+	return l.fields[i].Width
+}
+
+func (l *Bits) bytes() int {
+	width := 0
+	for _, f := range l.fields {
+		width += f.Width
+	}
+
+	return width / 8
+}
+
+// bitIndex returns the byte and the bit inside the byte of the given position in the bit stream
+func (l *Bits) bitIndex(pos int) (int, uint) {
+	if l.order == BitOrderLSB {
+		return pos / 8, uint(pos % 8)
+	}
+
+	return pos / 8, uint(7 - pos%8)
+}
+
+// fieldBit returns the bit of a field value which is packed at the given position of the field
+func (l *Bits) fieldBit(width int, i int) uint {
+	if l.order == BitOrderLSB {
+		return uint(i)
+	}
+
+	return uint(width - 1 - i)
+}
+
+// Token interface methods
+
+// Clone returns a copy of the token and all its children
+func (l *Bits) Clone() token.Token {
+	c := Bits{
+		order:  l.order,
+		fields: make([]BitField, len(l.fields)),
+	}
+
+	for i, f := range l.fields {
+		c.fields[i] = BitField{
+			Width: f.Width,
+			Token: f.Token.Clone(),
+		}
+	}
+
+	return &c
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (l *Bits) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	n := l.bytes()
+	nex := cur + n
+
+	if nex > pars.DataLen {
+		return cur, []error{&token.ParserError{
+			Message: fmt.Sprintf("expected %d bytes of bit fields but got early EOF", n),
+			Type:    token.ParseErrorUnexpectedEOF,
+
+			Position: pars.GetPosition(cur),
+		}}
+	}
+
+	pos := 0
+	for _, f := range l.fields {
+		v := 0
+
+		for i := 0; i < f.Width; i++ {
+			b, bit := l.bitIndex(pos)
+
+			if pars.Data[cur+b]&(1<<bit) != 0 {
+				v |= 1 << l.fieldBit(f.Width, i)
+			}
+
+			pos++
+		}
+
+		if errs := parseIntValue(f.Token, v); errs != nil {
+			return cur, []error{&token.ParserError{
+				Message: fmt.Sprintf("bit field value %d is not valid", v),
+				Type:    token.ParseErrorUnexpectedData,
+
+				Position: pars.GetPosition(cur),
+			}}
+		}
+	}
+
+	return nex, nil
+}
+
+// Permutation sets a specific permutation for this token
+func (l *Bits) Permutation(i uint) error {
+	permutations := l.Permutations()
+
+	if i >= permutations {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+
+	// do nothing
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (l *Bits) Permutations() uint {
+	return 1
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (l *Bits) PermutationsAll() uint {
+	var permutations uint = 1
+
+	for _, f := range l.fields {
+		permutations *= f.Token.PermutationsAll()
+	}
+
+	return permutations
+}
+
+func (l *Bits) String() string {
+	data := make([]byte, l.bytes())
+
+	pos := 0
+	for _, f := range l.fields {
+		v, _ := strconv.Atoi(f.Token.String())
+
+		for i := 0; i < f.Width; i++ {
+			if v&(1<<l.fieldBit(f.Width, i)) != 0 {
+				b, bit := l.bitIndex(pos)
+
+				data[b] |= 1 << bit
+			}
+
+			pos++
+		}
+	}
+
+	return string(data)
+}
+
+// List interface methods
+
+// Get returns the current referenced token at the given index. The error return argument is not nil, if the index is out of bound.
+func (l *Bits) Get(i int) (token.Token, error) {
+	if i < 0 || i >= len(l.fields) {
+		return nil, &ListError{ListErrorOutOfBound}
+	}
+
+	return l.fields[i].Token, nil
+}
+
+// Len returns the number of the current referenced tokens
+func (l *Bits) Len() int {
+	return len(l.fields)
+}
+
+// InternalGet returns the current referenced internal token at the given index. The error return argument is not nil, if the index is out of bound.
+func (l *Bits) InternalGet(i int) (token.Token, error) {
+	return l.Get(i)
+}
+
+// InternalLen returns the number of referenced internal tokens
+func (l *Bits) InternalLen() int {
+	return len(l.fields)
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (l *Bits) InternalLogicalRemove(tok token.Token) token.Token {
+	for i, f := range l.fields {
+		if f.Token == tok {
+			// a removed field is packed as zero
+			l.fields[i].Token = primitives.NewConstantInt(0)
+		}
+	}
+
+	return l
+}
+
+// InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
+func (l *Bits) InternalReplace(oldToken, newToken token.Token) error {
+	for i, f := range l.fields {
+		if f.Token == oldToken {
+			l.fields[i].Token = newToken
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package lists
+
+import (
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/primitives"
+)
+
+func TestBitsTokensToBeTokens(t *testing.T) {
+	var tok *token.ListToken
+
+	Implements(t, tok, &Bits{})
+}
+
+func TestBits(t *testing.T) {
+	version := primitives.NewRangeInt(4, 6)
+	ihl := primitives.NewRangeInt(5, 15)
+
+	o := NewBits(
+		BitOrderMSB,
+		BitField{Width: 4, Token: version},
+		BitField{Width: 4, Token: ihl},
+		BitField{Width: 8, Token: primitives.NewConstantInt(0x1ff)},
+	)
+	Equal(t, "\x45\xff", o.String())
+	Equal(t, 3, o.Len())
+	Equal(t, 1, o.Permutations())
+	Equal(t, 33, o.PermutationsAll())
+	Equal(t, BitOrderMSB, o.Order())
+	Equal(t, 4, o.Width(0))
+
+	i, err := o.Get(0)
+	Nil(t, err)
+	Equal(t, version, i)
+	i, err = o.Get(3)
+	Equal(t, err.(*ListError).Type, ListErrorOutOfBound)
+	Nil(t, i)
+
+	Nil(t, ihl.Permutation(10))
+	Equal(t, "\x4f\xff", o.String())
+
+	o2 := o.Clone()
+	Equal(t, o.String(), o2.String())
+
+	Nil(t, o.InternalReplace(version, primitives.NewConstantInt(6)))
+	Equal(t, "\x6f\xff", o.String())
+
+	o = NewBits(
+		BitOrderLSB,
+		BitField{Width: 1, Token: primitives.NewConstantInt(1)},
+		BitField{Width: 2, Token: primitives.NewConstantInt(0)},
+		BitField{Width: 9, Token: primitives.NewConstantInt(0x1f0)},
+		BitField{Width: 4, Token: primitives.NewConstantInt(0xa)},
+	)
+	Equal(t, "\x81\xaf", o.String())
+}
+
+func TestBitsParse(t *testing.T) {
+	parse := func(o token.Token, data string) (int, []error) {
+		pars := &token.InternalParser{
+			Data:    data,
+			DataLen: len(data),
+		}
+
+		return o.Parse(pars, 0)
+	}
+
+	version := primitives.NewRangeInt(4, 6)
+	ihl := primitives.NewRangeInt(5, 15)
+
+	o := NewBits(
+		BitOrderMSB,
+		BitField{Width: 4, Token: version},
+		BitField{Width: 4, Token: ihl},
+	)
+
+	nex, errs := parse(o, "\x6aabc")
+	Nil(t, errs)
+	Equal(t, 1, nex)
+	Equal(t, "6", version.String())
+	Equal(t, "10", ihl.String())
+	Equal(t, "\x6a", o.String())
+
+	_, errs = parse(o, "\x3a")
+	NotNil(t, errs)
+	_, errs = parse(o, "")
+	NotNil(t, errs)
+
+	o = NewBits(
+		BitOrderLSB,
+		BitField{Width: 4, Token: version},
+		BitField{Width: 12, Token: ihl},
+	)
+
+	nex, errs = parse(o, "\xc5\x00")
+	Nil(t, errs)
+	Equal(t, 2, nex)
+	Equal(t, "5", version.String())
+	Equal(t, "12", ihl.String())
+}
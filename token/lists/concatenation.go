@@ -8,6 +8,8 @@ import (
 
 // Concatenation implements a list token which holds an ordered set of tokens
 type Concatenation struct {
+	token.Origin
+
 	tokens []token.Token
 }
 
@@ -8,6 +8,8 @@ import (
 
 // ListItem implements a list item token which references a List token and holds one index of the list to reference a list item
 type ListItem struct {
+	token.Origin
+
 	index token.Token
 	list  token.ListToken
 }
@@ -95,6 +97,8 @@ func (l *ListItem) SetScope(variableScope *token.VariableScope) {
 
 // IndexItem implements a list item which references an Index token to represent the index itself of this token
 type IndexItem struct {
+	token.Origin
+
 	token token.IndexToken
 }
 
@@ -160,6 +164,8 @@ func (l *IndexItem) SetScope(variableScope *token.VariableScope) {
 
 // UniqueItem implements a list item token which holds an distinct list item of a referenced List token
 type UniqueItem struct {
+	token.Origin
+
 	original *UniqueItem
 	list     token.ListToken
 	picked   map[int]struct{}
@@ -6,6 +6,8 @@ type ListErrorType int
 const (
 	// ListErrorOutOfBound an index not in the bound of available list items was used.
 	ListErrorOutOfBound ListErrorType = iota
+	// ListErrorNotUnique a list item would be duplicated in a list which must have distinct items.
+	ListErrorNotUnique
 )
 
 // ListError holds a list error
@@ -15,6 +17,8 @@ type ListError struct {
 
 func (err *ListError) Error() string {
 	switch err.Type {
+	case ListErrorNotUnique:
+		return "Not unique"
 	default:
 		return "Out of bound"
 	}
@@ -7,9 +7,18 @@ import (
 )
 
 // Once implements a list token which holds a set of tokens that get shuffled on every permutation
+// A Once token can also choose a subset of its tokens. Every permutation then holds between "from" and "to" distinct tokens in any order.
 type Once struct {
+	token.Origin
+
 	tokens []token.Token
 	values []int
+
+	from int
+	to   int
+
+	reducing              bool
+	reducingOriginalValue []int
 }
 
 // NewOnce returns a new instance of a Once token given the set of tokens
@@ -26,9 +35,45 @@ func NewOnce(toks ...token.Token) *Once {
 	return &Once{
 		tokens: toks,
 		values: values,
+
+		from: len(toks),
+		to:   len(toks),
 	}
 }
 
+// NewOnceWithRange returns a new instance of a Once token given the set of tokens which chooses between from and to distinct tokens of the set
+func NewOnceWithRange(from, to int, toks ...token.Token) *Once {
+	if len(toks) == 0 {
+		panic("at least one token needed")
+	}
+	if from < 0 || from > to || to > len(toks) {
+		panic("invalid range")
+	}
+
+	values := make([]int, from)
+	for i := 0; i < len(values); i++ {
+		values[i] = i
+	}
+
+	return &Once{
+		tokens: toks,
+		values: values,
+
+		from: from,
+		to:   to,
+	}
+}
+
+// From returns the minimum number of chosen tokens
+func (l *Once) From() int {
+	return l.from
+}
+
+// To returns the maximum number of chosen tokens
+func (l *Once) To() int {
+	return l.to
+}
+
 // Token interface methods
 
 // Clone returns a copy of the token and all its children
@@ -36,6 +81,9 @@ func (l *Once) Clone() token.Token {
 	c := Once{
 		tokens: make([]token.Token, len(l.tokens)),
 		values: make([]int, len(l.values)),
+
+		from: l.from,
+		to:   l.to,
 	}
 
 	for i, tok := range l.tokens {
@@ -50,35 +98,96 @@ func (l *Once) Clone() token.Token {
 // Parse tries to parse the token beginning from the current position in the parser data.
 // If the parsing is successful the error argument is nil and the next current position after the token is returned.
 func (l *Once) Parse(pars *token.InternalParser, cur int) (int, []error) {
-	panic("TODO implement")
+	used := make([]bool, len(l.tokens))
+
+	nex, values, errs := l.parse(pars, cur, used, nil)
+	if values == nil {
+		return cur, errs
+	}
+
+	l.values = values
+	l.reducing = false
+
+	return nex, nil
+}
+
+// parse tries every unused token at the current position and returns the chosen tokens of the first complete match
+func (l *Once) parse(pars *token.InternalParser, cur int, used []bool, values []int) (int, []int, []error) {
+	var errs []error
+
+	if len(values) < l.to {
+		for i := range l.tokens {
+			if used[i] {
+				continue
+			}
+
+			nex, es := l.tokens[i].Parse(pars, cur)
+			if len(es) > 0 {
+				errs = append(errs, es...)
+
+				continue
+			}
+
+			used[i] = true
+			nex, vs, es := l.parse(pars, nex, used, append(values, i))
+			used[i] = false
+
+			if vs != nil {
+				return nex, vs, nil
+			}
+
+			errs = append(errs, es...)
+		}
+	}
+
+	if len(values) >= l.from {
+		vs := make([]int, len(values))
+		copy(vs, values)
+
+		return cur, vs, nil
+	}
+
+	return cur, nil, errs
+}
+
+// arrangements returns the number of ordered selections of k tokens out of n tokens
+func arrangements(n int, k int) uint {
+	var c uint = 1
+
+	for i := n - k + 1; i <= n; i++ {
+		c *= uint(i)
+	}
+
+	return c
 }
 
 func (l *Once) permutation(i uint) {
 	le := len(l.tokens)
+
+	k := l.from
+	for i >= arrangements(le, k) {
+		i -= arrangements(le, k)
+		k++
+	}
+
 	rest := make([]int, le)
 	for j := 0; j < len(rest); j++ {
 		rest[j] = j
 	}
-	v := make([]int, 0, le)
+	v := make([]int, 0, k)
 
-	n := uint(le)
-	for n > 0 {
-		var pers uint = 1
-		for j := uint(2); j <= n; j++ {
-			pers *= j
-		}
-		split := pers / n
+	for j := 0; j < k; j++ {
+		split := arrangements(len(rest)-1, k-j-1)
 
 		ti := i / split
 		i = i % split
 
 		v = append(v, rest[ti])
 		rest = append(rest[:ti], rest[ti+1:]...)
-
-		n = uint(len(rest))
 	}
 
 	l.values = v
+	l.reducing = false
 }
 
 // Permutation sets a specific permutation for this token
@@ -98,10 +207,10 @@ func (l *Once) Permutation(i uint) error {
 
 // Permutations returns the number of permutations for this token
 func (l *Once) Permutations() uint {
-	var sum uint = 1
+	var sum uint
 
-	for i := 2; i <= len(l.tokens); i++ {
-		sum *= uint(i)
+	for k := l.from; k <= l.to; k++ {
+		sum += arrangements(len(l.tokens), k)
 	}
 
 	return sum
@@ -109,10 +218,22 @@ func (l *Once) Permutations() uint {
 
 // PermutationsAll returns the number of all possible permutations for this token including its children
 func (l *Once) PermutationsAll() uint {
-	sum := l.Permutations()
+	// chosen[k] holds the number of permutations of all unordered selections of k tokens
+	chosen := make([]uint, l.to+1)
+	chosen[0] = 1
 
 	for _, tok := range l.tokens {
-		sum *= tok.PermutationsAll()
+		p := tok.PermutationsAll()
+
+		for k := l.to; k > 0; k-- {
+			chosen[k] += chosen[k-1] * p
+		}
+	}
+
+	var sum uint
+
+	for k := l.from; k <= l.to; k++ {
+		sum += chosen[k] * arrangements(k, k)
 	}
 
 	return sum
@@ -162,27 +283,36 @@ func (l *Once) InternalLen() int {
 
 // InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
 func (l *Once) InternalLogicalRemove(tok token.Token) token.Token {
-	for i := 0; i < len(l.values); i++ {
-		it := l.values[i]
+	for it := 0; it < len(l.tokens); it++ {
+		if l.tokens[it] != tok {
+			continue
+		}
 
-		if l.tokens[it] == tok {
-			l.tokens = append(l.tokens[:it], l.tokens[it+1:]...)
-			l.values = append(l.values[:i], l.values[i+1:]...)
+		l.tokens = append(l.tokens[:it], l.tokens[it+1:]...)
 
-			for j := 0; j < len(l.values); j++ {
-				if l.values[j] > it {
-					l.values[j]--
-				}
+		for j := 0; j < len(l.values); j++ {
+			if l.values[j] == it {
+				l.values = append(l.values[:j], l.values[j+1:]...)
+				j--
+			} else if l.values[j] > it {
+				l.values[j]--
 			}
-
-			i--
 		}
+
+		it--
 	}
 
-	if len(l.values) == 0 {
+	if len(l.tokens) == 0 {
 		return nil
 	}
 
+	if l.to > len(l.tokens) {
+		l.to = len(l.tokens)
+	}
+	if l.from > l.to {
+		l.from = l.to
+	}
+
 	return l
 }
 
@@ -196,3 +326,90 @@ func (l *Once) InternalReplace(oldToken, newToken token.Token) error {
 
 	return nil
 }
+
+// ReduceToken interface methods
+
+// Reduce sets a specific reduction for this token
+func (l *Once) Reduce(i uint) error {
+	var count uint
+	reduces := l.reduces()
+	for _, le := range reduces {
+		count += le
+	}
+
+	if count <= 1 || i >= count {
+		return &token.ReduceError{
+			Type: token.ReduceErrorIndexOutOfBound,
+		}
+	}
+
+	if !l.reducing {
+		l.reducing = true
+		l.reducingOriginalValue = l.values
+	}
+
+	j := 0
+	for i >= reduces[j] {
+		i -= reduces[j]
+		j++
+	}
+
+	var sel []int
+
+	ch, cancel := combinations(len(l.reducingOriginalValue), j+l.from)
+	for c := range ch {
+		if i == 0 {
+			sel = c
+
+			close(cancel)
+
+			break
+		}
+
+		i--
+	}
+
+	values := make([]int, len(sel))
+
+	for i, c := range sel {
+		values[i] = l.reducingOriginalValue[c]
+	}
+
+	l.values = values
+
+	return nil
+}
+
+func (l *Once) reduces() []uint {
+	n := uint(len(l.values))
+	if l.reducing {
+		n = uint(len(l.reducingOriginalValue))
+	}
+
+	if n < uint(l.from) {
+		return nil
+	}
+
+	reduces := make([]uint, 0, n-uint(l.from)+1)
+
+	for k := uint(l.from); k <= n; k++ {
+		reduces = append(reduces, arrangements(int(n), int(k))/arrangements(int(k), int(k)))
+	}
+
+	return reduces
+}
+
+// Reduces returns the number of reductions for this token
+func (l *Once) Reduces() uint {
+	if l.reducing || l.from < len(l.values) {
+		var count uint
+		r := l.reduces()
+		for _, le := range r {
+			count += le
+		}
+
+		return count
+	}
+
+	return 0
+}
@@ -65,3 +65,120 @@ func TestOnce(t *testing.T) {
 	o2 := o.Clone()
 	Equal(t, o.String(), o2.String())
 }
+
+func TestOnceWithRange(t *testing.T) {
+	a := primitives.NewConstantString("a")
+	b := primitives.NewConstantString("b")
+	c := primitives.NewConstantString("c")
+
+	o := NewOnceWithRange(1, 2, a, b, c)
+	Equal(t, "a", o.String())
+	Equal(t, 1, o.Len())
+	Equal(t, 9, o.Permutations())
+	Equal(t, 9, o.PermutationsAll())
+
+	for i, s := range []string{
+		"a",
+		"b",
+		"c",
+		"ab",
+		"ac",
+		"ba",
+		"bc",
+		"ca",
+		"cb",
+	} {
+		Nil(t, o.Permutation(uint(i)))
+		Equal(t, s, o.String())
+	}
+
+	d := primitives.NewRangeInt(1, 2)
+	o = NewOnceWithRange(0, 2, a, d)
+	Equal(t, "", o.String())
+	Equal(t, 5, o.Permutations())
+	Equal(t, 8, o.PermutationsAll())
+
+	o2 := o.Clone().(*Once)
+	Equal(t, 0, o2.From())
+	Equal(t, 2, o2.To())
+}
+
+func TestOnceParse(t *testing.T) {
+	a := primitives.NewConstantString("a")
+	b := primitives.NewConstantString("b")
+	c := primitives.NewConstantString("c")
+
+	o := NewOnce(a, b, c)
+
+	for _, s := range []string{"abc", "cab", "bca"} {
+		pars := &token.InternalParser{Data: s, DataLen: len(s)}
+
+		nex, errs := o.Parse(pars, 0)
+		Nil(t, errs)
+		Equal(t, len(s), nex)
+		Equal(t, s, o.String())
+	}
+
+	pars := &token.InternalParser{Data: "ab", DataLen: 2}
+	_, errs := o.Parse(pars, 0)
+	NotNil(t, errs)
+
+	o = NewOnceWithRange(1, 2, a, b, c)
+
+	for _, s := range []string{"a", "cb", "ba"} {
+		pars := &token.InternalParser{Data: s, DataLen: len(s)}
+
+		nex, errs := o.Parse(pars, 0)
+		Nil(t, errs)
+		Equal(t, len(s), nex)
+		Equal(t, s, o.String())
+	}
+
+	pars = &token.InternalParser{Data: "abc", DataLen: 3}
+	nex, errs := o.Parse(pars, 0)
+	Nil(t, errs)
+	Equal(t, 2, nex)
+
+	pars = &token.InternalParser{Data: "d", DataLen: 1}
+	_, errs = o.Parse(pars, 0)
+	NotNil(t, errs)
+
+	// the first parsed token must not block the remaining tokens
+	o = NewOnce(primitives.NewConstantString("x"), primitives.NewConstantString("xy"))
+
+	pars = &token.InternalParser{Data: "xyx", DataLen: 3}
+	nex, errs = o.Parse(pars, 0)
+	Nil(t, errs)
+	Equal(t, 3, nex)
+	Equal(t, "xyx", o.String())
+}
+
+func TestOnceReduce(t *testing.T) {
+	a := primitives.NewConstantString("a")
+	b := primitives.NewConstantString("b")
+	c := primitives.NewConstantString("c")
+
+	o := NewOnce(a, b, c)
+	Equal(t, 0, o.Reduces())
+
+	o = NewOnceWithRange(1, 3, a, b, c)
+	Nil(t, o.Permutation(13))
+	Equal(t, "cab", o.String())
+	Equal(t, 7, o.Reduces())
+
+	for i, s := range []string{
+		"c",
+		"a",
+		"b",
+		"ca",
+		"cb",
+		"ab",
+		"cab",
+	} {
+		Nil(t, o.Reduce(uint(i)))
+		Equal(t, s, o.String())
+	}
+
+	Equal(t, 7, o.Reduces())
+	NotNil(t, o.Reduce(7))
+}
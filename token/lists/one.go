@@ -7,6 +7,8 @@ import (
 // One implements a list token which chooses of a set of referenced token exactly one token
 // Every permutation chooses one token out of the token set.
 type One struct {
+	token.Origin
+
 	tokens []token.Token
 	value  int
 }
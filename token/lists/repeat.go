@@ -2,6 +2,7 @@ package lists
 
 import (
 	"bytes"
+	"fmt"
 	"math"
 	"strconv"
 
@@ -10,12 +11,17 @@ import (
 )
 
 // Repeat implements a list token which repeats a referenced token by a given range
+// A unique Repeat token requires that all repeated values have distinct string values.
 type Repeat struct {
+	token.Origin
+
 	from  token.Token
 	to    token.Token
 	token token.Token
 	value []token.Token
 
+	unique bool
+
 	reducing              bool
 	reducingOriginalValue []token.Token
 }
@@ -67,15 +73,78 @@ func (l *Repeat) To() int64 {
 	return int64(iTo)
 }
 
+// Unique returns true if the repeated values must have distinct string values
+func (l *Repeat) Unique() bool {
+	return l.unique
+}
+
+// SetUnique sets if the repeated values must have distinct string values
+func (l *Repeat) SetUnique(unique bool) {
+	l.unique = unique
+}
+
+// Distinct returns false if the token is unique and at least two repeated values have the same string value
+func (l *Repeat) Distinct() bool {
+	if !l.unique {
+		return true
+	}
+
+	known := make(map[string]struct{}, len(l.value))
+
+	for _, tok := range l.value {
+		s := tok.String()
+
+		if _, ok := known[s]; ok {
+			return false
+		}
+
+		known[s] = struct{}{}
+	}
+
+	return true
+}
+
+// Duplicate inserts a copy of the repeated value at the given index right after it. The error return argument is not nil, if the index is out of bound, if the repeat holds already the maximum of repeated values or if the repeat is unique, since a copy would violate the uniqueness of the repeat.
+func (l *Repeat) Duplicate(i int) error {
+	if i < 0 || i >= len(l.value) || int64(len(l.value)) >= l.To() {
+		return &ListError{ListErrorOutOfBound}
+	}
+
+	if l.unique {
+		return &ListError{ListErrorNotUnique}
+	}
+
+	value := make([]token.Token, 0, len(l.value)+1)
+	value = append(value, l.value[:i+1]...)
+	value = append(value, l.value[i].Clone())
+	l.value = append(value, l.value[i+1:]...)
+
+	return nil
+}
+
+// Remove removes the repeated value at the given index. The error return argument is not nil, if the index is out of bound or if the repeat holds only the minimum of repeated values.
+func (l *Repeat) Remove(i int) error {
+	if i < 0 || i >= len(l.value) || int64(len(l.value)) <= l.From() {
+		return &ListError{ListErrorOutOfBound}
+	}
+
+	value := make([]token.Token, 0, len(l.value)-1)
+	value = append(value, l.value[:i]...)
+	l.value = append(value, l.value[i+1:]...)
+
+	return nil
+}
+
 // Token interface methods
 
 // Clone returns a copy of the token and all its children
 func (l *Repeat) Clone() token.Token {
 	c := Repeat{
-		from:  l.from,
-		to:    l.to,
-		token: l.token.Clone(),
-		value: make([]token.Token, len(l.value)),
+		from:   l.from,
+		to:     l.to,
+		token:  l.token.Clone(),
+		value:  make([]token.Token, len(l.value)),
+		unique: l.unique,
 	}
 
 	for i, tok := range l.value {
@@ -89,6 +158,7 @@ func (l *Repeat) Clone() token.Token {
 // If the parsing is successful the error argument is nil and the next current position after the token is returned.
 func (l *Repeat) Parse(pars *token.InternalParser, cur int) (int, []error) {
 	var toks []token.Token
+	known := make(map[string]struct{})
 
 	i := 1
 
@@ -101,6 +171,20 @@ func (l *Repeat) Parse(pars *token.InternalParser, cur int) (int, []error) {
 			return cur, errs
 		}
 
+		if l.unique {
+			s := pars.Data[cur:nex]
+
+			if _, ok := known[s]; ok {
+				return cur, []error{&token.ParserError{
+					Message:  fmt.Sprintf("expected unique value but got duplicate %q", s),
+					Type:     token.ParseErrorUnexpectedData,
+					Position: pars.GetPosition(cur),
+				}}
+			}
+
+			known[s] = struct{}{}
+		}
+
 		cur = nex
 		toks = append(toks, tok)
 
@@ -116,6 +200,16 @@ func (l *Repeat) Parse(pars *token.InternalParser, cur int) (int, []error) {
 			break
 		}
 
+		if l.unique {
+			s := pars.Data[cur:nex]
+
+			if _, ok := known[s]; ok {
+				break
+			}
+
+			known[s] = struct{}{}
+		}
+
 		cur = nex
 		toks = append(toks, tok)
 
@@ -90,6 +90,72 @@ func TestRepeat(t *testing.T) {
 	Equal(t, o.String(), o2.String())
 }
 
+func TestRepeatUnique(t *testing.T) {
+	o := NewRepeat(NewOne(primitives.NewConstantString("a"), primitives.NewConstantString("b")), 2, 3)
+	False(t, o.Unique())
+	Equal(t, "aa", o.String())
+	True(t, o.Distinct())
+
+	o.SetUnique(true)
+	True(t, o.Unique())
+	False(t, o.Distinct())
+
+	Nil(t, o.value[1].Permutation(1))
+	Equal(t, "ab", o.String())
+	True(t, o.Distinct())
+
+	o2 := o.Clone().(*Repeat)
+	True(t, o2.Unique())
+
+	// duplicated values are not parsed
+	p := &token.InternalParser{
+		Data:    "aba",
+		DataLen: 3,
+	}
+
+	nex, errs := o.Parse(p, 0)
+	Nil(t, errs)
+	Equal(t, 2, nex)
+	Equal(t, "ab", o.String())
+
+	p = &token.InternalParser{
+		Data:    "aab",
+		DataLen: 3,
+	}
+
+	_, errs = o.Parse(p, 0)
+	Equal(t, token.ParseErrorUnexpectedData, errs[0].(*token.ParserError).Type)
+}
+
+func TestRepeatDuplicateRemove(t *testing.T) {
+	o := NewRepeat(primitives.NewRangeInt(1, 9), 1, 3)
+	Nil(t, o.Permutation(1))
+
+	i, _ := o.Get(0)
+	Nil(t, i.Permutation(2))
+	i, _ = o.Get(1)
+	Nil(t, i.Permutation(4))
+	Equal(t, "35", o.String())
+
+	Nil(t, o.Duplicate(0))
+	Equal(t, "335", o.String())
+	Equal(t, ListErrorOutOfBound, o.Duplicate(0).(*ListError).Type)
+
+	Nil(t, o.Remove(1))
+	Equal(t, "35", o.String())
+	Nil(t, o.Remove(0))
+	Equal(t, "5", o.String())
+	Equal(t, ListErrorOutOfBound, o.Remove(0).(*ListError).Type)
+
+	Equal(t, ListErrorOutOfBound, o.Duplicate(1).(*ListError).Type)
+
+	// unique repeats cannot be duplicated
+	o = NewRepeat(primitives.NewRangeInt(1, 9), 1, 3)
+	o.SetUnique(true)
+	Equal(t, ListErrorNotUnique, o.Duplicate(0).(*ListError).Type)
+	Equal(t, "1", o.String())
+}
+
 func TestRepeatReduces(t *testing.T) {
 	a := primitives.NewConstantString("a")
 
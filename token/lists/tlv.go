@@ -0,0 +1,330 @@
+package lists
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/zimmski/tavor/token"
+)
+
+// IntEncoding defines the binary encoding of an unsigned integer
+type IntEncoding struct {
+	// Size is the number of bytes of the encoded integer
+	Size int
+	// LittleEndian is true if the least significant byte is encoded first
+	LittleEndian bool
+}
+
+// IntEncodings holds all known unsigned integer encodings by their names
+var IntEncodings = map[string]IntEncoding{
+	"UInt8":    {Size: 1},
+	"UInt16be": {Size: 2},
+	"UInt16le": {Size: 2, LittleEndian: true},
+	"UInt32be": {Size: 4},
+	"UInt32le": {Size: 4, LittleEndian: true},
+}
+
+// Max returns the maximum value of the encoding
+func (e IntEncoding) Max() int {
+	return 1<<uint(8*e.Size) - 1
+}
+
+// Encode returns the given value encoded. Values which do not fit into the encoding are truncated.
+func (e IntEncoding) Encode(v int) string {
+	data := make([]byte, e.Size)
+
+	for i := 0; i < e.Size; i++ {
+		b := byte(v >> uint(8*i))
+
+		if e.LittleEndian {
+			data[i] = b
+		} else {
+			data[e.Size-1-i] = b
+		}
+	}
+
+	return string(data)
+}
+
+// Decode returns the value of the given encoded data which must be exactly the size of the encoding
+func (e IntEncoding) Decode(data string) int {
+	v := 0
+
+	for i := 0; i < e.Size; i++ {
+		var b byte
+
+		if e.LittleEndian {
+			b = data[i]
+		} else {
+			b = data[e.Size-1-i]
+		}
+
+		v |= int(b) << uint(8*i)
+	}
+
+	return v
+}
+
+// TLV implements a list token which frames its value with an optional type and the length in bytes of the value.
+// The length is always computed from the current value, only an additional length delta, which is for example set by fuzzing filters, can make the length inconsistent.
+type TLV struct {
+	token.Origin
+
+	typeEncoding   IntEncoding
+	typ            token.Token
+	lengthEncoding IntEncoding
+	value          token.Token
+	delta          token.Token
+}
+
+// NewTLV returns a new instance of a TLV token given the encoding and token of the type, the encoding of the length and the value token. The type token can be nil if the frame has no type.
+func NewTLV(typeEncoding IntEncoding, typ token.Token, lengthEncoding IntEncoding, value token.Token) *TLV {
+	return &TLV{
+		typeEncoding:   typeEncoding,
+		typ:            typ,
+		lengthEncoding: lengthEncoding,
+		value:          value,
+	}
+}
+
+// LengthDelta returns the token which is added to the length of the value or nil if the length is consistent
+func (l *TLV) LengthDelta() token.Token {
+	return l.delta
+}
+
+// SetLengthDelta sets the token which is added to the length of the value. Setting nil makes the length consistent again.
+func (l *TLV) SetLengthDelta(delta token.Token) {
+	l.delta = delta
+}
+
+func (l *TLV) children() []token.Token {
+	var children []token.Token
+
+	if l.typ != nil {
+		children = append(children, l.typ)
+	}
+
+	children = append(children, l.value)
+
+	if l.delta != nil {
+		children = append(children, l.delta)
+	}
+
+	return children
+}
+
+func (l *TLV) lengthDelta() int {
+	if l.delta == nil {
+		return 0
+	}
+
+	d, _ := strconv.Atoi(l.delta.String())
+
+	return d
+}
+
+// Token interface methods
+
+// Clone returns a copy of the token and all its children
+func (l *TLV) Clone() token.Token {
+	c := TLV{
+		typeEncoding:   l.typeEncoding,
+		lengthEncoding: l.lengthEncoding,
+		value:          l.value.Clone(),
+	}
+
+	if l.typ != nil {
+		c.typ = l.typ.Clone()
+	}
+	if l.delta != nil {
+		c.delta = l.delta.Clone()
+	}
+
+	return &c
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (l *TLV) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	i := cur
+
+	if l.typ != nil {
+		if i+l.typeEncoding.Size > pars.DataLen {
+			return cur, []error{&token.ParserError{
+				Message:  fmt.Sprintf("expected %d bytes of type but got early EOF", l.typeEncoding.Size),
+				Type:     token.ParseErrorUnexpectedEOF,
+				Position: pars.GetPosition(i),
+			}}
+		}
+
+		v := l.typeEncoding.Decode(pars.Data[i : i+l.typeEncoding.Size])
+
+		if errs := parseIntValue(l.typ, v); errs != nil {
+			return cur, []error{&token.ParserError{
+				Message:  fmt.Sprintf("type %d is not valid", v),
+				Type:     token.ParseErrorUnexpectedData,
+				Position: pars.GetPosition(i),
+			}}
+		}
+
+		i += l.typeEncoding.Size
+	}
+
+	if i+l.lengthEncoding.Size > pars.DataLen {
+		return cur, []error{&token.ParserError{
+			Message:  fmt.Sprintf("expected %d bytes of length but got early EOF", l.lengthEncoding.Size),
+			Type:     token.ParseErrorUnexpectedEOF,
+			Position: pars.GetPosition(i),
+		}}
+	}
+
+	n := l.lengthEncoding.Decode(pars.Data[i:i+l.lengthEncoding.Size]) - l.lengthDelta()
+
+	i += l.lengthEncoding.Size
+
+	if n < 0 || i+n > pars.DataLen {
+		return cur, []error{&token.ParserError{
+			Message:  fmt.Sprintf("expected %d bytes of value but got early EOF", n),
+			Type:     token.ParseErrorUnexpectedEOF,
+			Position: pars.GetPosition(i),
+		}}
+	}
+
+	// the value must consume exactly the given length
+	p := &token.InternalParser{
+		Data:    pars.Data[i : i+n],
+		DataLen: n,
+	}
+
+	if nex, errs := l.value.Parse(p, 0); len(errs) != 0 {
+		return cur, errs
+	} else if nex != n {
+		return cur, []error{&token.ParserError{
+			Message:  fmt.Sprintf("expected value of %d bytes but got %d bytes", n, nex),
+			Type:     token.ParseErrorUnexpectedData,
+			Position: pars.GetPosition(i + nex),
+		}}
+	}
+
+	return i + n, nil
+}
+
+// parseIntValue parses the decimal representation of the given value with the given token
+func parseIntValue(tok token.Token, v int) []error {
+	value := strconv.Itoa(v)
+	p := &token.InternalParser{
+		Data:    value,
+		DataLen: len(value),
+	}
+
+	if i, errs := tok.Parse(p, 0); len(errs) != 0 {
+		return errs
+	} else if i != p.DataLen {
+		return []error{&token.ParserError{
+			Message: fmt.Sprintf("expected value %d", v),
+			Type:    token.ParseErrorUnexpectedData,
+		}}
+	}
+
+	return nil
+}
+
+// Permutation sets a specific permutation for this token
+func (l *TLV) Permutation(i uint) error {
+	permutations := l.Permutations()
+
+	if i >= permutations {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+
+	// do nothing
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (l *TLV) Permutations() uint {
+	return 1
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (l *TLV) PermutationsAll() uint {
+	var permutations uint = 1
+
+	for _, c := range l.children() {
+		permutations *= c.PermutationsAll()
+	}
+
+	return permutations
+}
+
+func (l *TLV) String() string {
+	var s string
+
+	if l.typ != nil {
+		v, _ := strconv.Atoi(l.typ.String())
+
+		s = l.typeEncoding.Encode(v)
+	}
+
+	value := l.value.String()
+
+	return s + l.lengthEncoding.Encode(len(value)+l.lengthDelta()) + value
+}
+
+// List interface methods
+
+// Get returns the current referenced token at the given index. The error return argument is not nil, if the index is out of bound.
+func (l *TLV) Get(i int) (token.Token, error) {
+	children := l.children()
+
+	if i < 0 || i >= len(children) {
+		return nil, &ListError{ListErrorOutOfBound}
+	}
+
+	return children[i], nil
+}
+
+// Len returns the number of the current referenced tokens
+func (l *TLV) Len() int {
+	return len(l.children())
+}
+
+// InternalGet returns the current referenced internal token at the given index. The error return argument is not nil, if the index is out of bound.
+func (l *TLV) InternalGet(i int) (token.Token, error) {
+	return l.Get(i)
+}
+
+// InternalLen returns the number of referenced internal tokens
+func (l *TLV) InternalLen() int {
+	return l.Len()
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (l *TLV) InternalLogicalRemove(tok token.Token) token.Token {
+	switch tok {
+	case l.delta:
+		l.delta = nil
+	case l.typ, l.value:
+		return nil
+	}
+
+	return l
+}
+
+// InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
+func (l *TLV) InternalReplace(oldToken, newToken token.Token) error {
+	if l.typ == oldToken {
+		l.typ = newToken
+	}
+	if l.value == oldToken {
+		l.value = newToken
+	}
+	if l.delta == oldToken {
+		l.delta = newToken
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package lists
+
+import (
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/primitives"
+)
+
+func TestTLVTokensToBeTokens(t *testing.T) {
+	var tok *token.ListToken
+
+	Implements(t, tok, &TLV{})
+}
+
+func TestIntEncoding(t *testing.T) {
+	for name, expected := range map[string]string{
+		"UInt8":    "\x04",
+		"UInt16be": "\x03\x04",
+		"UInt16le": "\x04\x03",
+		"UInt32be": "\x01\x02\x03\x04",
+		"UInt32le": "\x04\x03\x02\x01",
+	} {
+		e := IntEncodings[name]
+
+		Equal(t, expected, e.Encode(0x01020304&e.Max()), name)
+		Equal(t, 0x01020304&e.Max(), e.Decode(expected), name)
+	}
+
+	// values are truncated
+	Equal(t, "\xff", IntEncodings["UInt8"].Encode(-1))
+	Equal(t, "\x00\x01", IntEncodings["UInt16le"].Encode(0x10000+0x100))
+}
+
+func TestTLV(t *testing.T) {
+	typ := primitives.NewRangeInt(1, 2)
+	value := NewOne(
+		primitives.NewConstantString("ab"),
+		primitives.NewConstantString("hello"),
+	)
+
+	o := NewTLV(IntEncodings["UInt8"], typ, IntEncodings["UInt16be"], value)
+	Equal(t, "\x01\x00\x02ab", o.String())
+	Equal(t, 2, o.Len())
+	Equal(t, 1, o.Permutations())
+	Equal(t, 4, o.PermutationsAll())
+	Nil(t, o.LengthDelta())
+
+	i, err := o.Get(0)
+	Nil(t, err)
+	Equal(t, typ, i)
+	i, err = o.Get(1)
+	Nil(t, err)
+	Equal(t, value, i)
+	i, err = o.Get(2)
+	Equal(t, err.(*ListError).Type, ListErrorOutOfBound)
+	Nil(t, i)
+
+	// the length follows the value
+	Nil(t, value.Permutation(1))
+	Equal(t, "\x01\x00\x05hello", o.String())
+
+	// without type
+	o2 := NewTLV(IntEncoding{}, nil, IntEncodings["UInt8"], primitives.NewConstantString("x"))
+	Equal(t, "\x01x", o2.String())
+	Equal(t, 1, o2.Len())
+
+	// inconsistent length
+	o.SetLengthDelta(primitives.NewConstantInt(-1))
+	Equal(t, "\x01\x00\x04hello", o.String())
+	Equal(t, 3, o.Len())
+
+	o3 := o.Clone().(*TLV)
+	Equal(t, o.String(), o3.String())
+
+	Nil(t, o.InternalReplace(typ, primitives.NewConstantInt(3)))
+	Equal(t, "\x03\x00\x04hello", o.String())
+	Equal(t, "\x01\x00\x04hello", o3.String())
+
+	Equal(t, o, o.InternalLogicalRemove(o.LengthDelta()))
+	Equal(t, "\x03\x00\x05hello", o.String())
+	Nil(t, o.InternalLogicalRemove(value))
+}
+
+func TestTLVParse(t *testing.T) {
+	o := NewTLV(
+		IntEncodings["UInt8"],
+		primitives.NewRangeInt(1, 2),
+		IntEncodings["UInt16le"],
+		NewOne(
+			primitives.NewConstantString("ab"),
+			primitives.NewConstantString("hello"),
+		),
+	)
+
+	parse := func(data string) (int, []error) {
+		return o.Parse(&token.InternalParser{
+			Data:    data,
+			DataLen: len(data),
+		}, 0)
+	}
+
+	i, errs := parse("\x02\x05\x00hello!")
+	Nil(t, errs)
+	Equal(t, 8, i)
+
+	for _, data := range []string{
+		"",
+		"\x03\x02\x00ab",
+		"\x01\x02",
+		"\x01\x06\x00hello",
+		"\x01\x04\x00hello",
+		"\x01\x03\x00abc",
+	} {
+		i, errs = parse(data)
+		NotNil(t, errs, data)
+		Equal(t, 0, i)
+	}
+
+	// an inconsistent length is expected while parsing
+	o.SetLengthDelta(primitives.NewConstantInt(1))
+
+	i, errs = parse("\x01\x03\x00ab")
+	Nil(t, errs)
+	Equal(t, 5, i)
+}
@@ -4,9 +4,9 @@ package token
 
 import "fmt"
 
-const _ParserErrorType_name = "ParseErrorNoStartParseErrorNewLineNeededParseErrorEarlyNewLineParseErrorEmptyExpressionIsInvalidParseErrorEmptyStringParseErrorEmptyTokenDefinitionParseErrorInvalidArgumentValueParseErrorInvalidTokenNameParseErrorInvalidTokenTypeParseErrorUnusedTokenParseErrorMissingTypedTokenArgumentParseErrorNonTerminatedStringParseErrorNoTokenForVariableParseErrorNotAlwaysUsedAsAVariableParseErrorRepeatWithOptionalTermParseErrorTokenAlreadyDefinedParseErrorTokenNotDefinedParseErrorTypeNotDefinedForTypedTokenParseErrorExpectRuneParseErrorExpectOperatorParseErrorUnknownBooleanOperatorParseErrorUnknownConditionParseErrorUnkownOperatorParseErrorUnknownTypedTokenArgumentParseErrorUnknownTypedTokenTypeParseErrorUnknownTokenAttributeParseErrorUnexpectedTokenDefinitionTerminationParseErrorExpectedExpressionTermParseErrEndlessLoopDetectedParseErrorExpectedEOFParseErrorRootIsNilParseErrorUnexpectedEOFParseErrorUnexpectedData"
+const _ParserErrorType_name = "ParseErrorNoStartParseErrorNewLineNeededParseErrorEarlyNewLineParseErrorEmptyExpressionIsInvalidParseErrorEmptyStringParseErrorEmptyTokenDefinitionParseErrorInvalidArgumentValueParseErrorInvalidTokenNameParseErrorInvalidTokenTypeParseErrorUnusedTokenParseErrorMissingTypedTokenArgumentParseErrorNonTerminatedStringParseErrorNoTokenForVariableParseErrorNotAlwaysUsedAsAVariableParseErrorRepeatWithOptionalTermParseErrorTokenAlreadyDefinedParseErrorTokenNotDefinedParseErrorTypeNotDefinedForTypedTokenParseErrorExpectRuneParseErrorExpectOperatorParseErrorUnknownBooleanOperatorParseErrorUnknownConditionParseErrorUnkownOperatorParseErrorUnknownTypedTokenArgumentParseErrorUnknownTypedTokenTypeParseErrorUnknownTokenAttributeParseErrorUnexpectedTokenDefinitionTerminationParseErrorExpectedExpressionTermParseErrEndlessLoopDetectedParseErrorUnknownConstantParseErrorInvalidConstantValueParseErrorInvalidRepeatBoundsParseErrorInvalidSwitchParseErrorUnknownFunctionParseErrorInvalidFunctionArgumentsParseErrorInvalidBitsParseErrorInvalidTLVParseErrorInvalidAssertParseErrorInvalidCharacterClassParseErrorInvalidLiteralParseErrorInvalidTestParseErrorInvalidPermutationGroupParseErrorExpectedEOFParseErrorRootIsNilParseErrorUnexpectedEOFParseErrorUnexpectedData"
 
-var _ParserErrorType_index = [...]uint16{0, 17, 40, 62, 96, 117, 147, 177, 203, 229, 250, 285, 314, 342, 376, 408, 437, 462, 499, 519, 543, 575, 601, 625, 660, 691, 722, 768, 800, 827, 848, 867, 890, 914}
+var _ParserErrorType_index = [...]uint16{0, 17, 40, 62, 96, 117, 147, 177, 203, 229, 250, 285, 314, 342, 376, 408, 437, 462, 499, 519, 543, 575, 601, 625, 660, 691, 722, 768, 800, 827, 852, 882, 911, 934, 959, 993, 1014, 1034, 1057, 1088, 1112, 1133, 1166, 1187, 1206, 1229, 1253}
 
 func (i ParserErrorType) String() string {
 	if i < 0 || i >= ParserErrorType(len(_ParserErrorType_index)-1) {
@@ -2,9 +2,9 @@ package primitives
 
 import (
 	"fmt"
-	"io"
+	"sort"
 	"strconv"
-	"strings"
+	"text/scanner"
 	"unicode"
 	"unicode/utf8"
 
@@ -14,7 +14,10 @@ import (
 
 var simpleEscapes = map[rune]rune{
 	'-':  '-',
+	'[':  '[',
 	'\\': '\\',
+	']':  ']',
+	'^':  '^',
 	'f':  '\f',
 	'n':  '\n',
 	'r':  '\r',
@@ -30,6 +33,8 @@ var characterClassEscapes = map[rune][]rune{
 // CharacterClass implements a char token which holds a pattern of characters and character classes
 // Each character class characters is added to the set of characters of the token. Every permutations chooses one character out of the available set of characters as the current value of the token.
 type CharacterClass struct {
+	token.Origin
+
 	chars       []rune
 	charsLookup map[rune]struct{}
 	charRanges  []characterRange
@@ -45,35 +50,128 @@ type characterRange struct {
 	from, to rune
 }
 
+// characterClassUniverse holds all characters which can be matched by a negated character class which are all Unicode code points except the surrogates
+var characterClassUniverse = []characterRange{
+	{0, 0xD7FF},
+	{0xE000, unicode.MaxRune},
+}
+
 // NewCharacterClass returns a new instance of a CharacterClass token
+// The function panics if the pattern is invalid, ParseCharacterClass should be used for patterns which are not known to be valid.
 func NewCharacterClass(pattern string) *CharacterClass {
-	if pattern == "" {
-		panic("pattern is empty")
+	c, err := ParseCharacterClass(pattern)
+	if err != nil {
+		panic(err)
+	}
+
+	return c
+}
+
+// ParseCharacterClass returns a new instance of a CharacterClass token or an error if the pattern is invalid
+// The position of a returned parser error is relative to the beginning of the pattern.
+func ParseCharacterClass(pattern string) (*CharacterClass, error) {
+	p := &characterClassParser{
+		pattern: pattern,
+	}
+
+	chars, charRanges, err := p.parseClass(false)
+	if err != nil {
+		return nil, err
+	}
+
+	var first rune
+	charsLookup := make(map[rune]struct{})
+
+	if len(chars) != 0 {
+		first = chars[0]
+
+		for _, v := range chars {
+			charsLookup[v] = struct{}{}
+		}
+	} else {
+		first = charRanges[0].from
 	}
 
-	/*
+	var permutations = uint(len(chars))
+
+	for _, v := range charRanges {
+		permutations += uint(v.to-v.from) + 1
+	}
+
+	return &CharacterClass{
+		chars:       chars,
+		charsLookup: charsLookup,
+		charRanges:  charRanges,
+
+		permutations: permutations,
 
-		TODO FIXME FIXME FIXME
+		pattern: pattern,
+
+		value: first,
+	}, nil
+}
+
+type characterClassParser struct {
+	pattern string
+	offset  int
+}
+
+func (p *characterClassParser) peek() (rune, bool) {
+	if p.offset >= len(p.pattern) {
+		return 0, false
+	}
 
-		This part of the code is full of bad code, especially the error handling is not addressed at all, we simple panic!
-		There is a time and place where this can be redone, but it is not now and here.
+	c, _ := utf8.DecodeRuneInString(p.pattern[p.offset:])
 
-	*/
+	return c, true
+}
+
+func (p *characterClassParser) next() (rune, bool) {
+	if p.offset >= len(p.pattern) {
+		return 0, false
+	}
 
+	c, size := utf8.DecodeRuneInString(p.pattern[p.offset:])
+	p.offset += size
+
+	return c, true
+}
+
+func (p *characterClassParser) errorf(offset int, format string, args ...interface{}) error {
+	return &token.ParserError{
+		Message: fmt.Sprintf(format, args...),
+		Type:    token.ParseErrorInvalidCharacterClass,
+		Position: scanner.Position{
+			Offset: offset,
+			Line:   1,
+			Column: utf8.RuneCountInString(p.pattern[:offset]) + 1,
+		},
+	}
+}
+
+// parseClass parses a character class pattern until its end or until the closing bracket of a nested class
+func (p *characterClassParser) parseClass(nested bool) ([]rune, []characterRange, error) {
 	var chars []rune
 	var charRanges []characterRange
+	var subtract []characterRange
 	var lastCharIsRangeChar = false
 	var lastChar rune
 	var isRange = false
+	var rangeOffset int
 
-	runes := strings.NewReader(pattern)
+	start := p.offset
 
-	c, _, err := runes.ReadRune()
+	negate := false
+	if c, ok := p.peek(); ok && c == '^' {
+		negate = true
 
-	add := func(c rune) {
+		p.next()
+	}
+
+	add := func(c rune, offset int) error {
 		if isRange {
 			if lastChar > c {
-				panic("Range to character is lower than range from character")
+				return p.errorf(offset, "range to character %q is lower than range from character %q", c, lastChar)
 			}
 
 			charRanges = append(charRanges, characterRange{
@@ -87,162 +185,328 @@ func NewCharacterClass(pattern string) *CharacterClass {
 			lastCharIsRangeChar = false
 		} else {
 			chars = append(chars, c)
+			lastChar = c
+			lastCharIsRangeChar = true
 		}
+
+		return nil
 	}
 
-	checkHex := func(c rune) bool {
-		return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+	addRanges := func(ranges []characterRange, offset int) error {
+		if isRange {
+			return p.errorf(offset, "range operator without range to character")
+		}
+
+		charRanges = append(charRanges, ranges...)
+		lastCharIsRangeChar = false
+
+		return nil
 	}
 
 PARSING:
-	for err != io.EOF {
-		if unicode.IsDigit(c) || unicode.IsLetter(c) || unicode.IsSpace(c) {
-			add(c)
-			lastChar = c
-			lastCharIsRangeChar = true
-		} else {
+	for {
+		offset := p.offset
+
+		c, ok := p.next()
+		if !ok {
+			if nested {
+				return nil, nil, p.errorf(offset, "character class subtraction is not terminated")
+			}
+
+			break
+		}
+
+		switch c {
+		case ']':
+			if !nested {
+				return nil, nil, p.errorf(offset, "unexpected %q, it has to be escaped", c)
+			}
+
+			break PARSING
+		case '[':
+			return nil, nil, p.errorf(offset, "unexpected %q, it has to be escaped or used for a subtraction", c)
+		case '-':
+			if n, ok := p.peek(); ok && n == '[' {
+				if isRange {
+					return nil, nil, p.errorf(offset, "range operator without range to character")
+				}
+
+				p.next()
+
+				subChars, subRanges, err := p.parseClass(true)
+				if err != nil {
+					return nil, nil, err
+				}
+
+				subtract = normalizeCharacterRanges(subChars, subRanges)
+
+				// the subtraction must be the last part of a class
+				end := p.offset
+				if n, ok := p.peek(); ok && (!nested || n != ']') {
+					return nil, nil, p.errorf(end, "character class subtraction must be the last part of a character class")
+				}
+
+				continue
+			}
+
+			if !lastCharIsRangeChar {
+				return nil, nil, p.errorf(offset, "range operator without range from character")
+			}
+
+			isRange = true
+			rangeOffset = offset
+			lastCharIsRangeChar = false
+		case '\\':
+			c, ok = p.next()
+			if !ok {
+				return nil, nil, p.errorf(offset, "early EOF for escaped character")
+			}
+
 			switch c {
-			case '-':
-				if !lastCharIsRangeChar {
-					panic("Range operator without range from character")
+			case 'x':
+				x, err := p.parseHex(offset)
+				if err != nil {
+					return nil, nil, err
 				}
 
-				isRange = true
-			case '\\':
-				c, _, err = runes.ReadRune()
-				if err == io.EOF {
-					panic("early EOF for escaped character")
-				} else if err != nil {
-					break PARSING
+				if err := add(x, offset); err != nil {
+					return nil, nil, err
+				}
+			case 'p', 'P':
+				ranges, err := p.parseUnicodeClass(offset, c == 'P')
+				if err != nil {
+					return nil, nil, err
 				}
 
-				switch c {
-				case 'x':
-					x, _, err := runes.ReadRune()
-					if err == io.EOF {
-						panic("early EOF for escaped character")
-					} else if err != nil {
-						break PARSING
+				if err := addRanges(ranges, offset); err != nil {
+					return nil, nil, err
+				}
+			default:
+				if simp, ok := simpleEscapes[c]; ok {
+					if err := add(simp, offset); err != nil {
+						return nil, nil, err
 					}
-
-					var xses string
-
-					if x == '{' {
-						for {
-							x, _, err = runes.ReadRune()
-							if err == io.EOF {
-								panic("early EOF for escaped character")
-							} else if err != nil {
-								break PARSING
-							} else if x == '}' {
-								break
-							} else if !checkHex(x) {
-								panic("x escaping needs HEX characters")
-							}
-
-							xses += string(x)
-						}
-
-						if len(xses) < 2 {
-							panic("x escaping needs two HEX characters")
-						}
-					} else {
-						if !checkHex(x) {
-							panic("x escaping needs two HEX characters")
-						}
-
-						xses += string(x)
-
-						x, _, err = runes.ReadRune()
-						if err == io.EOF {
-							panic("early EOF for escaped character")
-						} else if err != nil {
-							break PARSING
-						} else if !checkHex(x) {
-							panic("x escaping needs two HEX characters")
-						}
-
-						xses += string(x)
+				} else {
+					if isRange {
+						return nil, nil, p.errorf(offset, "range operator without range to character")
 					}
 
-					s, e := strconv.Unquote(`"\U` + strings.Repeat("0", 8-len(xses)) + xses + `"`)
-					if e != nil {
-						panic(e)
+					esc, ok := characterClassEscapes[c]
+					if !ok {
+						return nil, nil, p.errorf(offset, "unknown escape character %q", c)
 					}
 
-					c, _ = utf8.DecodeRuneInString(s)
-
-					add(c)
-					lastChar = c
-					lastCharIsRangeChar = true
-				default:
-					if simp, ok := simpleEscapes[c]; ok {
-						add(simp)
-						lastChar = simp
-						lastCharIsRangeChar = true
-					} else {
-						if isRange {
-							panic("Range operator without range to character")
-						}
-
-						esc, ok := characterClassEscapes[c]
-						if !ok {
-							panic(fmt.Sprintf("Unknown escape character %q", c))
-						}
-
-						for _, v := range esc {
-							add(v)
-						}
-
-						lastCharIsRangeChar = false
+					for _, v := range esc {
+						chars = append(chars, v)
 					}
+
+					lastCharIsRangeChar = false
 				}
-			default:
-				panic(fmt.Sprintf("Unknown character %q", c))
+			}
+		default:
+			if err := add(c, offset); err != nil {
+				return nil, nil, err
 			}
 		}
-
-		c, _, err = runes.ReadRune()
 	}
 
-	if err != nil && err != io.EOF {
-		panic(err)
+	if isRange {
+		return nil, nil, p.errorf(rangeOffset, "range operator without range to character")
 	}
 
 	if len(chars) == 0 && len(charRanges) == 0 {
-		panic("empty character class is not allowed")
+		return nil, nil, p.errorf(start, "empty character class is not allowed")
 	}
 
-	var first rune
-	charsLookup := make(map[rune]struct{})
+	if !negate && subtract == nil {
+		return chars, charRanges, nil
+	}
 
-	if len(chars) != 0 {
-		first = chars[0]
+	ranges := normalizeCharacterRanges(chars, charRanges)
 
-		for _, v := range chars {
-			charsLookup[v] = struct{}{}
+	if negate {
+		ranges = subtractCharacterRanges(characterClassUniverse, ranges)
+	}
+	if subtract != nil {
+		ranges = subtractCharacterRanges(ranges, subtract)
+	}
+
+	if len(ranges) == 0 {
+		return nil, nil, p.errorf(start, "character class does not hold any character")
+	}
+
+	return nil, ranges, nil
+}
+
+// parseHex parses the hexadecimal code point of a \x escape either in the form of two hexadecimal characters or up to 8 hexadecimal characters in braces
+func (p *characterClassParser) parseHex(offset int) (rune, error) {
+	checkHex := func(c rune) bool {
+		return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+	}
+
+	var xses string
+
+	x, ok := p.next()
+	if !ok {
+		return 0, p.errorf(offset, "early EOF for escaped character")
+	}
+
+	if x == '{' {
+		for {
+			x, ok = p.next()
+			if !ok {
+				return 0, p.errorf(offset, "early EOF for escaped character")
+			} else if x == '}' {
+				break
+			} else if !checkHex(x) {
+				return 0, p.errorf(offset, "x escaping needs HEX characters")
+			}
+
+			xses += string(x)
+		}
+
+		if len(xses) < 2 || len(xses) > 8 {
+			return 0, p.errorf(offset, "x escaping needs two to eight HEX characters")
 		}
 	} else {
-		first = charRanges[0].from
+		if !checkHex(x) {
+			return 0, p.errorf(offset, "x escaping needs two HEX characters")
+		}
+
+		xses += string(x)
+
+		x, ok = p.next()
+		if !ok || !checkHex(x) {
+			return 0, p.errorf(offset, "x escaping needs two HEX characters")
+		}
+
+		xses += string(x)
 	}
 
-	var permutations = uint(len(chars))
+	v, _ := strconv.ParseUint(xses, 16, 32)
+	if v > unicode.MaxRune || (v >= 0xD800 && v <= 0xDFFF) {
+		return 0, p.errorf(offset, "x escaping %q is not a valid Unicode code point", xses)
+	}
 
-	for _, v := range charRanges {
-		permutations += uint(v.to-v.from) + 1
+	return rune(v), nil
+}
+
+// parseUnicodeClass parses the name of a Unicode category or script of a \p or \P escape either as one letter or in braces
+func (p *characterClassParser) parseUnicodeClass(offset int, negate bool) ([]characterRange, error) {
+	var name string
+
+	c, ok := p.next()
+	if !ok {
+		return nil, p.errorf(offset, "early EOF for escaped character")
 	}
 
-	return &CharacterClass{
-		chars:       chars,
-		charsLookup: charsLookup,
-		charRanges:  charRanges,
+	if c == '{' {
+		for {
+			c, ok = p.next()
+			if !ok {
+				return nil, p.errorf(offset, "early EOF for escaped character")
+			} else if c == '}' {
+				break
+			}
 
-		permutations: permutations,
+			name += string(c)
+		}
+	} else {
+		name = string(c)
+	}
 
-		pattern: pattern,
+	table, ok := unicode.Categories[name]
+	if !ok {
+		table, ok = unicode.Scripts[name]
+	}
+	if !ok {
+		return nil, p.errorf(offset, "unknown Unicode category or script %q", name)
+	}
 
-		value: first,
+	var ranges []characterRange
+
+	for _, r := range table.R16 {
+		ranges = appendStrideRange(ranges, rune(r.Lo), rune(r.Hi), rune(r.Stride))
+	}
+	for _, r := range table.R32 {
+		ranges = appendStrideRange(ranges, rune(r.Lo), rune(r.Hi), rune(r.Stride))
 	}
+
+	if negate {
+		ranges = subtractCharacterRanges(characterClassUniverse, normalizeCharacterRanges(nil, ranges))
+	}
+
+	return ranges, nil
+}
+
+// appendStrideRange appends the characters from lo to hi with the given stride as ranges
+func appendStrideRange(ranges []characterRange, lo rune, hi rune, stride rune) []characterRange {
+	if stride == 1 {
+		return append(ranges, characterRange{from: lo, to: hi})
+	}
+
+	for c := lo; c <= hi; c += stride {
+		ranges = append(ranges, characterRange{from: c, to: c})
+	}
+
+	return ranges
+}
+
+// normalizeCharacterRanges returns the sorted and merged ranges of the given characters and ranges
+func normalizeCharacterRanges(chars []rune, charRanges []characterRange) []characterRange {
+	ranges := make([]characterRange, 0, len(chars)+len(charRanges))
+
+	for _, c := range chars {
+		ranges = append(ranges, characterRange{from: c, to: c})
+	}
+	ranges = append(ranges, charRanges...)
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].from < ranges[j].from
+	})
+
+	var merged []characterRange
+
+	for _, r := range ranges {
+		if l := len(merged) - 1; l >= 0 && r.from <= merged[l].to+1 {
+			if r.to > merged[l].to {
+				merged[l].to = r.to
+			}
+
+			continue
+		}
+
+		merged = append(merged, r)
+	}
+
+	return merged
+}
+
+// subtractCharacterRanges returns the ranges of a which are not in b, both have to be normalized
+func subtractCharacterRanges(a []characterRange, b []characterRange) []characterRange {
+	var ranges []characterRange
+
+	for _, r := range a {
+		from := r.from
+
+		for _, s := range b {
+			if s.to < from || s.from > r.to {
+				continue
+			}
+
+			if s.from > from {
+				ranges = append(ranges, characterRange{from: from, to: s.from - 1})
+			}
+
+			from = s.to + 1
+		}
+
+		if from <= r.to {
+			ranges = append(ranges, characterRange{from: from, to: r.to})
+		}
+	}
+
+	return ranges
 }
 
 // Clone returns a copy of the token and all its children
@@ -280,15 +544,14 @@ func (c *CharacterClass) Clone() token.Token {
 func (c *CharacterClass) Parse(pars *token.InternalParser, cur int) (int, []error) {
 	if cur+1 > pars.DataLen {
 		return cur, []error{&token.ParserError{
-			Message: fmt.Sprintf("expected %q but got early EOF", c.charsLookup),
+			Message: fmt.Sprintf("expected [%s] but got early EOF", c.pattern),
 			Type:    token.ParseErrorUnexpectedEOF,
 
 			Position: pars.GetPosition(cur),
 		}}
 	}
 
-	// TODO FIXME NOW we can see the need to put pars.Data into a reader... since we cannot do a readRune here!
-	v := rune(pars.Data[cur])
+	v, size := utf8.DecodeRuneInString(pars.Data[cur:])
 
 	if _, ok := c.charsLookup[v]; !ok {
 		found := false
@@ -303,7 +566,7 @@ func (c *CharacterClass) Parse(pars *token.InternalParser, cur int) (int, []erro
 
 		if !found {
 			return cur, []error{&token.ParserError{
-				Message: fmt.Sprintf("expected %q or %+v but got %q", c.charsLookup, c.charRanges, v),
+				Message: fmt.Sprintf("expected [%s] but got %q", c.pattern, v),
 				Type:    token.ParseErrorUnexpectedData,
 
 				Position: pars.GetPosition(cur),
@@ -315,7 +578,7 @@ func (c *CharacterClass) Parse(pars *token.InternalParser, cur int) (int, []erro
 
 	log.Debugf("Parsed %q", v)
 
-	return cur + 1, nil
+	return cur + size, nil
 }
 
 func (c *CharacterClass) permutation(i uint) {
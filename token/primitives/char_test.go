@@ -2,6 +2,7 @@ package primitives
 
 import (
 	"testing"
+	"unicode"
 
 	. "github.com/zimmski/tavor/test/assert"
 
@@ -86,3 +87,96 @@ func TestCharacterClass(t *testing.T) {
 	o2 := o.Clone()
 	Equal(t, o.String(), o2.String())
 }
+
+func TestCharacterClassExtensions(t *testing.T) {
+	// negation
+	o := NewCharacterClass(`^b-\x{10FFFF}`)
+	Equal(t, "\x00", o.String())
+	Equal(t, 98, o.Permutations())
+
+	// Unicode categories and scripts
+	o = NewCharacterClass(`\p{Greek}`)
+	for i := uint(0); i < o.Permutations(); i += 17 {
+		Nil(t, o.Permutation(i))
+		True(t, unicode.Is(unicode.Greek, []rune(o.String())[0]), o.String())
+	}
+
+	o = NewCharacterClass(`\pNa`)
+	Equal(t, "a", o.String())
+	Nil(t, o.Permutation(1))
+	Equal(t, "0", o.String())
+
+	o = NewCharacterClass(`^\P{Lu}`)
+	for i := uint(0); i < o.Permutations(); i += 7 {
+		Nil(t, o.Permutation(i))
+		True(t, unicode.IsUpper([]rune(o.String())[0]), o.String())
+	}
+
+	// subtraction
+	o = NewCharacterClass(`a-z-[aeiou]`)
+	Equal(t, 21, o.Permutations())
+	Equal(t, "b", o.String())
+	Nil(t, o.Permutation(3))
+	Equal(t, "f", o.String())
+
+	o = NewCharacterClass(`a-z-[^b-d]`)
+	Equal(t, 3, o.Permutations())
+
+	o = NewCharacterClass(`a-z-[a-y-[b]]`)
+	Equal(t, 2, o.Permutations())
+	Equal(t, "b", o.String())
+	Nil(t, o.Permutation(1))
+	Equal(t, "z", o.String())
+
+	// escapes and characters which are not letters
+	o = NewCharacterClass(`\[\]\^"/`)
+	Equal(t, 5, o.Permutations())
+
+	// parse Unicode characters
+	o = NewCharacterClass(`\p{Greek}`)
+	p := &token.InternalParser{
+		Data:    "λx",
+		DataLen: len("λx"),
+	}
+
+	nex, errs := o.Parse(p, 0)
+	Nil(t, errs)
+	Equal(t, len("λ"), nex)
+	Equal(t, "λ", o.String())
+
+	_, errs = o.Parse(p, nex)
+	NotNil(t, errs)
+
+	// invalid patterns
+	for _, c := range []struct {
+		pattern string
+		column  int
+	}{
+		{``, 1},
+		{`^`, 1},
+		{`^\x00-\x{10FFFF}`, 1},
+		{`b-a`, 3},
+		{`-a`, 1},
+		{`a-`, 2},
+		{`a-\d`, 3},
+		{`\q`, 1},
+		{`ab\`, 3},
+		{`\x{110000}`, 1},
+		{`\xG1`, 1},
+		{`\p{Foo}`, 1},
+		{`a]`, 2},
+		{`a[`, 2},
+		{`a-z-[a-z]`, 1},
+		{`a-z-[b`, 7},
+		{`a-[b]c`, 6},
+	} {
+		o, err := ParseCharacterClass(c.pattern)
+		Nil(t, o, c.pattern)
+		Equal(t, token.ParseErrorInvalidCharacterClass, err.(*token.ParserError).Type, c.pattern)
+		Equal(t, c.column, err.(*token.ParserError).Position.Column, c.pattern)
+	}
+
+	Panics(t, func() {
+		NewCharacterClass(`b-a`)
+	})
+}
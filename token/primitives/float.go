@@ -0,0 +1,288 @@
+package primitives
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/zimmski/tavor/log"
+	"github.com/zimmski/tavor/token"
+)
+
+// FormatFloat returns the canonical decimal representation of a floating point number. The representation always holds a decimal point or an exponent to distinguish it from an integer.
+func FormatFloat(v float64) string {
+	var s string
+
+	if abs := math.Abs(v); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		s = strconv.FormatFloat(v, 'e', -1, 64)
+	} else {
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	if !strings.ContainsAny(s, ".eIN") {
+		s += ".0"
+	}
+
+	return s
+}
+
+// ConstantFloat implements a floating point token which holds a constant floating point number
+type ConstantFloat struct {
+	token.Origin
+
+	value float64
+}
+
+// NewConstantFloat returns a new instance of a ConstantFloat token
+func NewConstantFloat(value float64) *ConstantFloat {
+	return &ConstantFloat{
+		value: value,
+	}
+}
+
+// SetValue sets the value of the token
+func (p *ConstantFloat) SetValue(v float64) {
+	p.value = v
+}
+
+// Value returns the value of the token
+func (p *ConstantFloat) Value() float64 {
+	return p.value
+}
+
+// Token interface methods
+
+// Clone returns a copy of the token and all its children
+func (p *ConstantFloat) Clone() token.Token {
+	return &ConstantFloat{
+		value: p.value,
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (p *ConstantFloat) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	v := p.String()
+	vLen := len(v)
+
+	nextIndex := vLen + cur
+
+	if nextIndex > pars.DataLen {
+		return cur, []error{&token.ParserError{
+			Message: fmt.Sprintf("expected %q but got early EOF", v),
+			Type:    token.ParseErrorUnexpectedEOF,
+
+			Position: pars.GetPosition(cur),
+		}}
+	}
+
+	if got := pars.Data[cur:nextIndex]; v != got {
+		return cur, []error{&token.ParserError{
+			Message: fmt.Sprintf("expected %q but got %q", v, got),
+			Type:    token.ParseErrorUnexpectedData,
+
+			Position: pars.GetPosition(cur),
+		}}
+	}
+
+	log.Debugf("Parsed %q", v)
+
+	return nextIndex, nil
+}
+
+// Permutation sets a specific permutation for this token
+func (p *ConstantFloat) Permutation(i uint) error {
+	permutations := p.Permutations()
+
+	if i >= permutations {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+
+	// do nothing
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (p *ConstantFloat) Permutations() uint {
+	return 1
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (p *ConstantFloat) PermutationsAll() uint {
+	return p.Permutations()
+}
+
+func (p *ConstantFloat) String() string {
+	return FormatFloat(p.value)
+}
+
+// RangeFloat implements a floating point token holding a range of floating point numbers
+// Every permutation generates a new value within the defined range and step. For example the range 0.0 to 1.0 with step 0.25 can hold the numbers 0.0, 0.25, 0.5, 0.75 and 1.0.
+type RangeFloat struct {
+	token.Origin
+
+	from float64
+	to   float64
+	step float64
+
+	value float64
+}
+
+// NewRangeFloat returns a new instance of a RangeFloat token with the given range and step value
+func NewRangeFloat(from, to, step float64) *RangeFloat {
+	if from > to {
+		panic("TODO implement that From can be bigger than To")
+	}
+	if step <= 0 {
+		panic("TODO implement 0 and negative step")
+	}
+
+	return &RangeFloat{
+		from: from,
+		to:   to,
+		step: step,
+
+		value: from,
+	}
+}
+
+func init() {
+	token.RegisterTyped("Float", func(argParser token.ArgumentsTypedParser) (token.Token, error) {
+		from := argParser.GetFloat("from", 0.0)
+		to := argParser.GetFloat("to", 1.0)
+		step := argParser.GetFloat("step", 0.1)
+
+		if err := argParser.Err(); err != nil {
+			return nil, err
+		}
+
+		if from > to {
+			return nil, fmt.Errorf("\"from\" must not be bigger than \"to\"")
+		} else if step <= 0 {
+			return nil, fmt.Errorf("\"step\" must be positive")
+		}
+
+		return NewRangeFloat(from, to, step), nil
+	})
+}
+
+// From returns the from value of the range
+func (p *RangeFloat) From() float64 {
+	return p.from
+}
+
+// To returns the to value of the range
+func (p *RangeFloat) To() float64 {
+	return p.to
+}
+
+// Step returns the step value
+func (p *RangeFloat) Step() float64 {
+	return p.step
+}
+
+// round removes the rounding errors of the step arithmetic
+func (p *RangeFloat) round(v float64) float64 {
+	r, _ := strconv.ParseFloat(strconv.FormatFloat(v, 'g', 12, 64), 64)
+
+	return r
+}
+
+// Token interface methods
+
+// Clone returns a copy of the token and all its children
+func (p *RangeFloat) Clone() token.Token {
+	return &RangeFloat{
+		from: p.from,
+		to:   p.to,
+		step: p.step,
+
+		value: p.value,
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (p *RangeFloat) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	if cur == pars.DataLen {
+		return cur, []error{&token.ParserError{
+			Message: fmt.Sprintf("expected floating point number in range %s-%s with step %s but got early EOF", FormatFloat(p.from), FormatFloat(p.to), FormatFloat(p.step)),
+			Type:    token.ParseErrorUnexpectedEOF,
+
+			Position: pars.GetPosition(cur),
+		}}
+	}
+
+	end := cur
+	for end < pars.DataLen && ((pars.Data[end] == '-' && end == cur) || pars.Data[end] == '.' || (pars.Data[end] >= '0' && pars.Data[end] <= '9')) {
+		end++
+	}
+
+	// the longest number within the range wins since the following data can start with digits too
+	for i := end; i > cur; i-- {
+		v, err := strconv.ParseFloat(pars.Data[cur:i], 64)
+		if err != nil {
+			continue
+		}
+
+		if steps := (v - p.from) / p.step; v < p.from || v > p.to || math.Abs(steps-math.Floor(steps+0.5)) > 1e-9 {
+			continue
+		}
+
+		p.value = v
+
+		log.Debugf("Parsed %q", pars.Data[cur:i])
+
+		return i, nil
+	}
+
+	return cur, []error{&token.ParserError{
+		Message: fmt.Sprintf("expected floating point number in range %s-%s with step %s but got %q", FormatFloat(p.from), FormatFloat(p.to), FormatFloat(p.step), pars.Data[cur:end]),
+		Type:    token.ParseErrorUnexpectedData,
+
+		Position: pars.GetPosition(cur),
+	}}
+}
+
+func (p *RangeFloat) permutation(i uint) {
+	p.value = p.round(p.from + float64(i)*p.step)
+}
+
+// Permutation sets a specific permutation for this token
+func (p *RangeFloat) Permutation(i uint) error {
+	permutations := p.Permutations()
+
+	if i >= permutations {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+
+	p.permutation(i)
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (p *RangeFloat) Permutations() uint {
+	perms := math.Floor(p.round((p.to-p.from)/p.step)) + 1
+
+	if perms > math.MaxUint32 {
+		return math.MaxUint32
+	}
+
+	return uint(perms)
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (p *RangeFloat) PermutationsAll() uint {
+	return p.Permutations()
+}
+
+func (p *RangeFloat) String() string {
+	return FormatFloat(p.value)
+}
@@ -0,0 +1,78 @@
+package primitives
+
+import (
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/token"
+)
+
+func TestFloatTokensToBeTokens(t *testing.T) {
+	var tok *token.Token
+
+	Implements(t, tok, &ConstantFloat{})
+	Implements(t, tok, &RangeFloat{})
+}
+
+func TestFormatFloat(t *testing.T) {
+	Equal(t, "1.0", FormatFloat(1))
+	Equal(t, "-1.5", FormatFloat(-1.5))
+	Equal(t, "0.0", FormatFloat(0))
+	Equal(t, "1000000.0", FormatFloat(1e6))
+	Equal(t, "1e+21", FormatFloat(1e21))
+	Equal(t, "1e-07", FormatFloat(1e-7))
+}
+
+func TestConstantFloat(t *testing.T) {
+	o := NewConstantFloat(1.5)
+	Equal(t, "1.5", o.String())
+
+	Equal(t, 1, o.Permutations())
+
+	Nil(t, o.Permutation(0))
+	Equal(t, "1.5", o.String())
+
+	Equal(t, o.Permutation(1).(*token.PermutationError).Type, token.PermutationErrorIndexOutOfBound)
+
+	o2 := o.Clone()
+	Equal(t, o.String(), o2.String())
+}
+
+func TestRangeFloat(t *testing.T) {
+	o := NewRangeFloat(0.0, 0.3, 0.1)
+	Equal(t, "0.0", o.String())
+
+	Equal(t, 4, o.Permutations())
+
+	Nil(t, o.Permutation(1))
+	Equal(t, "0.1", o.String())
+	Nil(t, o.Permutation(3))
+	Equal(t, "0.3", o.String())
+
+	Equal(t, o.Permutation(4).(*token.PermutationError).Type, token.PermutationErrorIndexOutOfBound)
+
+	o2 := o.Clone()
+	Equal(t, o.String(), o2.String())
+
+	// the longest number within the range is parsed
+	o = NewRangeFloat(0.5, 1.5, 0.25)
+
+	p := &token.InternalParser{
+		Data:    "1.256",
+		DataLen: 5,
+	}
+
+	nex, errs := o.Parse(p, 0)
+	Nil(t, errs)
+	Equal(t, 4, nex)
+	Equal(t, "1.25", o.String())
+
+	p = &token.InternalParser{
+		Data:    "2.0",
+		DataLen: 3,
+	}
+
+	_, errs = o.Parse(p, 0)
+	NotNil(t, errs)
+}
@@ -11,6 +11,8 @@ import (
 
 // ConstantInt implements an integer token which holds a constant integer
 type ConstantInt struct {
+	token.Origin
+
 	value int
 }
 
@@ -103,6 +105,8 @@ func (p *ConstantInt) String() string {
 // RangeInt implements an integer token holding a range of integers
 // Every permutation generates a new value within the defined range and step. For example the range 1 to 10 with step 2 can hold the integers 1, 3, 5, 7 and 9.
 type RangeInt struct {
+	token.Origin
+
 	from int
 	to   int
 	step int
@@ -8,6 +8,8 @@ import (
 
 // Pointer implements a general pointer token which references a token
 type Pointer struct {
+	token.Origin
+
 	token token.Token
 	typ   reflect.Type
 
@@ -6,6 +6,8 @@ import (
 
 // Scope implements a general scope token which references a token
 type Scope struct {
+	token.Origin
+
 	token token.Token
 }
 
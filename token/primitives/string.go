@@ -9,6 +9,8 @@ import (
 
 // ConstantString implements a string token which holds a constant string
 type ConstantString struct {
+	token.Origin
+
 	value string
 }
 
@@ -3,6 +3,7 @@ package token
 import (
 	"fmt"
 	"io"
+	"reflect"
 	"strings"
 )
 
@@ -12,7 +13,7 @@ func PrettyPrintTree(w io.Writer, root Token) {
 }
 
 func prettyPrintTreeRek(w io.Writer, tok Token, level int) {
-	_, _ = fmt.Fprintf(w, "%s(%p)%#v %d Permutations\n", strings.Repeat("\t", level), tok, tok, tok.Permutations())
+	_, _ = fmt.Fprintf(w, "%s(%p)%s %d Permutations%s\n", strings.Repeat("\t", level), tok, goString(tok), tok.Permutations(), SourceSuffix(tok))
 
 	switch t := tok.(type) {
 	case ForwardToken:
@@ -34,7 +35,7 @@ func PrettyPrintInternalTree(w io.Writer, root Token) {
 }
 
 func prettyPrintInternalTreeRek(w io.Writer, tok Token, level int) {
-	_, _ = fmt.Fprintf(w, "%s(%p)%#v\n", strings.Repeat("\t", level), tok, tok)
+	_, _ = fmt.Fprintf(w, "%s(%p)%s%s\n", strings.Repeat("\t", level), tok, goString(tok), SourceSuffix(tok))
 
 	switch t := tok.(type) {
 	case ForwardToken:
@@ -49,3 +50,39 @@ func prettyPrintInternalTreeRek(w io.Writer, tok Token, level int) {
 		}
 	}
 }
+
+// goString returns the Go-syntax representation of the token without its origin, which is printed separately
+func goString(tok Token) string {
+	v := reflect.ValueOf(tok)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Sprintf("%#v", tok)
+	}
+
+	s := v.Elem()
+	t := s.Type()
+
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type == reflect.TypeOf(Origin{}) {
+			continue
+		}
+
+		fv := s.Field(i)
+
+		// referenced values are printed as addresses like nested values of %#v
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				fields = append(fields, fmt.Sprintf("%s:(%s)(nil)", f.Name, fv.Type()))
+			} else {
+				fields = append(fields, fmt.Sprintf("%s:(%s)(%#x)", f.Name, fv.Type(), fv.Pointer()))
+			}
+
+			continue
+		}
+
+		fields = append(fields, fmt.Sprintf("%s:%#v", f.Name, fv))
+	}
+
+	return fmt.Sprintf("&%s{%s}", t, strings.Join(fields, ", "))
+}
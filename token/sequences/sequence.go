@@ -12,6 +12,8 @@ import (
 // Sequence implements a general sequence token which can generate Item tokens to use the internal sequence
 // The sequence starts its numeration at the given start value and increases with every new sequence numeration its current value by the given step value.
 type Sequence struct {
+	token.Origin
+
 	start int
 	step  int
 	value int
@@ -181,6 +183,8 @@ func (s *Sequence) String() string { panic("unusable token") }
 // SequenceItem implements a sequence item token which holds one distinct value of the sequence
 // A new sequence value is generated on every token permutation.
 type SequenceItem struct {
+	token.Origin
+
 	sequence *Sequence
 	value    int
 }
@@ -242,6 +246,8 @@ func (s *SequenceItem) Reset() error {
 // SequenceExistingItem implements a sequence item token which holds one existing value of the sequence
 // A new existing sequence value is choosen on every token permutation.
 type SequenceExistingItem struct {
+	token.Origin
+
 	sequence *Sequence
 	value    int
 	except   []token.Token
@@ -389,6 +395,8 @@ func (s *SequenceExistingItem) SetScope(variableScope *token.VariableScope) {
 
 // SequenceResetItem implements a sequence token item which resets its referencing sequence on every permutation
 type SequenceResetItem struct {
+	token.Origin
+
 	sequence *Sequence
 }
 
@@ -0,0 +1,120 @@
+package token
+
+import (
+	"fmt"
+	"reflect"
+	"text/scanner"
+)
+
+// Source holds the origin of a token in a format file
+type Source struct {
+	// Name is the name of the token definition the token originates from
+	Name string
+	// Position is the position of the token in the format file
+	Position scanner.Position
+}
+
+func (s Source) String() string {
+	if s.Name == "" {
+		return fmt.Sprintf("L:%d, C:%d", s.Position.Line, s.Position.Column)
+	}
+
+	return fmt.Sprintf("%s L:%d, C:%d", s.Name, s.Position.Line, s.Position.Column)
+}
+
+// SourceToken defines a token which can carry its origin
+type SourceToken interface {
+	// Source returns the origin of the token. The second return argument is false if the origin of the token is unknown.
+	Source() (Source, bool)
+	// SetSource sets the origin of the token
+	SetSource(src Source)
+}
+
+// Origin implements the SourceToken interface and can be embedded into tokens so that they carry their origin
+type Origin struct {
+	source *Source
+}
+
+// Incomparable marks origins as irrelevant for comparisons of tokens since they do not change the behaviour of a token
+func (o Origin) Incomparable() {}
+
+// Source returns the origin of the token. The second return argument is false if the origin of the token is unknown.
+func (o *Origin) Source() (Source, bool) {
+	if o.source == nil {
+		return Source{}, false
+	}
+
+	return *o.source, true
+}
+
+// SetSource sets the origin of the token
+func (o *Origin) SetSource(src Source) {
+	o.source = &src
+}
+
+// SetSource sets the origin of the token if the token can carry its origin
+func SetSource(tok Token, src Source) {
+	if t, ok := tok.(SourceToken); ok {
+		t.SetSource(src)
+	}
+}
+
+// GetSource returns the origin of the token. The second return argument is false if the origin of the token is unknown.
+func GetSource(tok Token) (Source, bool) {
+	if t, ok := tok.(SourceToken); ok {
+		return t.Source()
+	}
+
+	return Source{}, false
+}
+
+// SourceSuffix returns the origin of the token formatted as " [NAME L:line, C:column]" so that it can be appended to a token description, or an empty string if the origin of the token is unknown.
+func SourceSuffix(tok Token) string {
+	src, ok := GetSource(tok)
+	if !ok {
+		return ""
+	}
+
+	return " [" + src.String() + "]"
+}
+
+// CopySources copies the origins of the token graph beginning with the original token to the token graph beginning with the clone.
+// Only tokens of the clone which have no origin and have the same type and position in the graph as their original are set.
+func CopySources(original Token, clone Token) {
+	copySourcesRek(original, clone, make(map[Token]struct{}))
+}
+
+func copySourcesRek(original Token, clone Token, visited map[Token]struct{}) {
+	if original == nil || clone == nil || reflect.TypeOf(original) != reflect.TypeOf(clone) {
+		return
+	}
+
+	if _, ok := visited[original]; ok {
+		return
+	}
+	visited[original] = struct{}{}
+
+	if src, ok := GetSource(original); ok {
+		if _, ok := GetSource(clone); !ok {
+			SetSource(clone, src)
+		}
+	}
+
+	switch o := original.(type) {
+	case ForwardToken:
+		copySourcesRek(o.InternalGet(), clone.(ForwardToken).InternalGet(), visited)
+	case ListToken:
+		c := clone.(ListToken)
+
+		if o.InternalLen() != c.InternalLen() {
+			return
+		}
+
+		for i := 0; i < o.InternalLen(); i++ {
+			oc, _ := o.InternalGet(i)
+			cc, _ := c.InternalGet(i)
+
+			copySourcesRek(oc, cc, visited)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package token_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"text/scanner"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/lists"
+	"github.com/zimmski/tavor/token/primitives"
+)
+
+func TestSources(t *testing.T) {
+	a := primitives.NewConstantString("a")
+	b := primitives.NewConstantString("b")
+	root := lists.NewConcatenation(a, b)
+
+	_, ok := token.GetSource(a)
+	False(t, ok)
+	Equal(t, "", token.SourceSuffix(a))
+
+	token.SetSource(root, token.Source{Name: "START", Position: scanner.Position{Line: 1, Column: 1}})
+	token.SetSource(a, token.Source{Name: "A", Position: scanner.Position{Line: 2, Column: 5}})
+
+	src, ok := token.GetSource(a)
+	True(t, ok)
+	Equal(t, "A L:2, C:5", src.String())
+	Equal(t, " [A L:2, C:5]", token.SourceSuffix(a))
+
+	// clones have the same origins
+	clone := root.Clone().(*lists.Concatenation)
+	token.CopySources(root, clone)
+
+	src, ok = token.GetSource(clone)
+	True(t, ok)
+	Equal(t, "START", src.Name)
+
+	ca, _ := clone.InternalGet(0)
+	src, ok = token.GetSource(ca)
+	True(t, ok)
+	Equal(t, "A", src.Name)
+
+	cb, _ := clone.InternalGet(1)
+	_, ok = token.GetSource(cb)
+	False(t, ok)
+}
+
+func TestSourcesPrintAndCompare(t *testing.T) {
+	a := primitives.NewConstantString("a")
+	root := lists.NewConcatenation(a)
+
+	token.SetSource(a, token.Source{Name: "A", Position: scanner.Position{Line: 2, Column: 5}})
+
+	// origins are only printed as suffix
+	var buf bytes.Buffer
+	token.PrettyPrintInternalTree(&buf, root)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	Equal(t, 2, len(lines))
+	True(t, strings.HasSuffix(lines[1], `&primitives.ConstantString{value:"a"} [A L:2, C:5]`), lines[1])
+	False(t, strings.Contains(buf.String(), "Origin"))
+
+	// origins do not change the equality of tokens
+	Equal(t, lists.NewConcatenation(primitives.NewConstantString("a")), root)
+	NotEqual(t, lists.NewConcatenation(primitives.NewConstantString("b")), root)
+}
@@ -0,0 +1,357 @@
+package symbols
+
+import (
+	"fmt"
+
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/lists"
+)
+
+// Symbols implements a symbol table token which records defined values of arbitrary tokens to use them later on
+// Symbols are recorded through SymbolsDefineItem tokens and chosen through SymbolsUseItem tokens. A symbol is visible to all following uses in the scope which encloses the scope of its definition, i.e. a token definition which holds the definition of a symbol acts as a declaration for the scope using the token definition. If the definition is repeated through a repeat outside of the enclosing scope, e.g. as one alternative of a repeated statement, the symbol is visible in the scope of the repeat. The visible symbols are computed by ResetSymbols.
+type Symbols struct {
+	token.Origin
+
+	tokens []token.Token
+}
+
+// NewSymbols returns a new instance of a Symbols token
+func NewSymbols() *Symbols {
+	return &Symbols{}
+}
+
+func init() {
+	token.RegisterTyped("Symbols", func(argParser token.ArgumentsTypedParser) (token.Token, error) {
+		if err := argParser.Err(); err != nil {
+			return nil, err
+		}
+
+		return NewSymbols(), nil
+	})
+}
+
+// key returns the name under which the last visible definition of the symbol table is saved in a variable scope
+func (s *Symbols) key() string {
+	return fmt.Sprintf("$Symbols(%p)", s)
+}
+
+// DefineItem returns a new instance of a SymbolsDefineItem token referencing the symbol table and the given token which generates the values of the symbols
+func (s *Symbols) DefineItem(tok token.Token) *SymbolsDefineItem {
+	s.tokens = append(s.tokens, tok)
+
+	return &SymbolsDefineItem{
+		symbols: s,
+		token:   tok,
+	}
+}
+
+// UseItem returns a new instance of a SymbolsUseItem token referencing the symbol table
+func (s *Symbols) UseItem() *SymbolsUseItem {
+	return &SymbolsUseItem{
+		symbols: s,
+	}
+}
+
+// Symbols is an unusable token
+
+// Clone returns a copy of the token and all its children
+func (s *Symbols) Clone() token.Token { panic("unusable token") }
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (s *Symbols) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	panic("unusable token")
+}
+
+// Permutation sets a specific permutation for this token
+func (s *Symbols) Permutation(i uint) error { panic("unusable token") }
+
+// Permutations returns the number of permutations for this token
+func (s *Symbols) Permutations() uint { panic("unusable token") }
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (s *Symbols) PermutationsAll() uint { panic("unusable token") }
+
+func (s *Symbols) String() string { panic("unusable token") }
+
+// SymbolsDefineItem implements a symbol table item token which defines the value of its referenced token as a symbol
+type SymbolsDefineItem struct {
+	token.Origin
+
+	symbols *Symbols
+	token   token.Token
+
+	previous *SymbolsDefineItem
+}
+
+// Clone returns a copy of the token and all its children
+func (s *SymbolsDefineItem) Clone() token.Token {
+	return &SymbolsDefineItem{
+		symbols: s.symbols,
+		token:   s.token.Clone(),
+	}
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+func (s *SymbolsDefineItem) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	return s.token.Parse(pars, cur)
+}
+
+// Permutation sets a specific permutation for this token
+func (s *SymbolsDefineItem) Permutation(i uint) error {
+	permutations := s.Permutations()
+
+	if i >= permutations {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+
+	// do nothing
+
+	return nil
+}
+
+// Permutations returns the number of permutations for this token
+func (s *SymbolsDefineItem) Permutations() uint {
+	return 1
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (s *SymbolsDefineItem) PermutationsAll() uint {
+	return s.token.PermutationsAll()
+}
+
+func (s *SymbolsDefineItem) String() string {
+	return s.token.String()
+}
+
+// ForwardToken interface methods
+
+// Get returns the current referenced token
+func (s *SymbolsDefineItem) Get() token.Token {
+	return s.token
+}
+
+// InternalGet returns the current referenced internal token
+func (s *SymbolsDefineItem) InternalGet() token.Token {
+	return s.token
+}
+
+// InternalLogicalRemove removes the referenced internal token and returns the replacement for the current token or nil if the current token should be removed.
+func (s *SymbolsDefineItem) InternalLogicalRemove(tok token.Token) token.Token {
+	if s.token == tok {
+		return nil
+	}
+
+	return s
+}
+
+// InternalReplace replaces an old with a new internal token if it is referenced by this token. The error return argument is not nil, if the replacement is not suitable.
+func (s *SymbolsDefineItem) InternalReplace(oldToken, newToken token.Token) error {
+	if s.token == oldToken {
+		s.token = newToken
+	}
+
+	return nil
+}
+
+// setScope declares the symbol in the scope which encloses the scope of the token, or in the scope of the repeat which repeats the token if the repeat is outside of the enclosing scope
+func (s *SymbolsDefineItem) setScope(variableScope *token.VariableScope, repeatScope *token.VariableScope) {
+	if p := variableScope.Parent(); p != nil {
+		variableScope = p
+	}
+
+	if repeatScope != nil {
+		for p := variableScope.Parent(); p != nil; p = p.Parent() {
+			if p == repeatScope {
+				variableScope = repeatScope
+
+				break
+			}
+		}
+	}
+
+	key := s.symbols.key()
+
+	s.previous = nil
+	if previous, ok := variableScope.Get(key).(*SymbolsDefineItem); ok {
+		s.previous = previous
+	}
+
+	variableScope.Set(key, s)
+}
+
+// SymbolsUseItem implements a symbol table item token which holds one symbol which is visible to the token
+// A new visible symbol is chosen on every token permutation. The visible symbols are computed by ResetSymbols.
+type SymbolsUseItem struct {
+	token.Origin
+
+	symbols *Symbols
+	index   uint
+	visible []*SymbolsDefineItem
+	value   token.Token
+}
+
+// Defined returns true if the current symbol of the token is visible to the token. The visible symbols are the ones of the last call to ResetSymbols.
+func (s *SymbolsUseItem) Defined() bool {
+	return s.value == nil && len(s.visible) != 0
+}
+
+// Clone returns a copy of the token and all its children
+func (s *SymbolsUseItem) Clone() token.Token {
+	c := SymbolsUseItem{
+		symbols: s.symbols,
+		index:   s.index,
+		visible: s.visible,
+	}
+
+	if s.value != nil {
+		c.value = s.value.Clone()
+	}
+
+	return &c
+}
+
+// Parse tries to parse the token beginning from the current position in the parser data.
+// If the parsing is successful the error argument is nil and the next current position after the token is returned.
+// Every value which can be parsed by a token of a symbol definition is accepted since the visible symbols are not known while parsing.
+func (s *SymbolsUseItem) Parse(pars *token.InternalParser, cur int) (int, []error) {
+	var errs []error
+
+	for _, tok := range s.symbols.tokens {
+		// tokens of definitions can still be pointers since they are not part of the token graph
+		value, err := token.UnrollPointers(tok.Clone())
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		nex, e := value.Parse(pars, cur)
+		if len(e) != 0 {
+			errs = append(errs, e...)
+
+			continue
+		}
+
+		s.value = value
+
+		return nex, nil
+	}
+
+	if len(errs) == 0 {
+		errs = append(errs, &token.ParserError{
+			Message:  "expected a symbol but the symbol table has no definitions",
+			Type:     token.ParseErrorUnexpectedData,
+			Position: pars.GetPosition(cur),
+		})
+	}
+
+	return cur, errs
+}
+
+func (s *SymbolsUseItem) permutation(i uint) error {
+	s.index = i
+	s.value = nil
+
+	return nil
+}
+
+// Permutation sets a specific permutation for this token
+func (s *SymbolsUseItem) Permutation(i uint) error {
+	permutations := s.Permutations()
+
+	if i >= permutations {
+		return &token.PermutationError{
+			Type: token.PermutationErrorIndexOutOfBound,
+		}
+	}
+
+	return s.permutation(i)
+}
+
+// Permutations returns the number of permutations for this token
+func (s *SymbolsUseItem) Permutations() uint {
+	if len(s.visible) == 0 {
+		return 1
+	}
+
+	return uint(len(s.visible))
+}
+
+// PermutationsAll returns the number of all possible permutations for this token including its children
+func (s *SymbolsUseItem) PermutationsAll() uint {
+	return s.Permutations()
+}
+
+func (s *SymbolsUseItem) String() string {
+	if s.value != nil {
+		return s.value.String()
+	} else if len(s.visible) == 0 {
+		return ""
+	}
+
+	return s.visible[int(s.index%uint(len(s.visible)))].String()
+}
+
+// setScope sets the visible symbols of the token to the symbols which are visible in the given scope
+func (s *SymbolsUseItem) setScope(variableScope *token.VariableScope) {
+	s.visible = nil
+
+	d, _ := variableScope.Get(s.symbols.key()).(*SymbolsDefineItem)
+	for ; d != nil; d = d.previous {
+		s.visible = append(s.visible, d)
+	}
+
+	// keep a parsed value if it is still visible
+	if s.value != nil {
+		for i, d := range s.visible {
+			if d.String() == s.value.String() {
+				s.index = uint(i)
+				s.value = nil
+
+				break
+			}
+		}
+	}
+}
+
+// ResetSymbols computes the visible symbols of all symbol uses of the token graph in the order of the current generation.
+// This has to be done for every generation since the visible symbols depend on the generated definitions before a use.
+func ResetSymbols(root token.Token) {
+	resetSymbols(root, token.NewVariableScope(), nil)
+}
+
+func resetSymbols(tok token.Token, variableScope *token.VariableScope, repeatScope *token.VariableScope) {
+	if _, ok := tok.(token.Scoping); ok {
+		variableScope = variableScope.Push()
+	}
+
+	switch t := tok.(type) {
+	case *SymbolsDefineItem:
+		t.setScope(variableScope, repeatScope)
+	case *SymbolsUseItem:
+		t.setScope(variableScope)
+	case *lists.Repeat:
+		repeatScope = variableScope
+	}
+
+	if t, ok := tok.(token.Follow); ok && !t.Follow() {
+		return
+	}
+
+	switch t := tok.(type) {
+	case token.ForwardToken:
+		if v := t.Get(); v != nil {
+			resetSymbols(v, variableScope, repeatScope)
+		}
+	case token.ListToken:
+		for i := 0; i < t.Len(); i++ {
+			c, _ := t.Get(i)
+
+			resetSymbols(c, variableScope, repeatScope)
+		}
+	}
+}
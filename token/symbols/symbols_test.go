@@ -0,0 +1,146 @@
+package symbols
+
+import (
+	"testing"
+
+	. "github.com/zimmski/tavor/test/assert"
+
+	"github.com/zimmski/tavor/token"
+	"github.com/zimmski/tavor/token/lists"
+	"github.com/zimmski/tavor/token/primitives"
+)
+
+func TestSymbolsTokensToBeTokens(t *testing.T) {
+	var tok *token.Token
+
+	Implements(t, tok, &Symbols{})
+	Implements(t, tok, &SymbolsDefineItem{})
+	Implements(t, tok, &SymbolsUseItem{})
+
+	var forward *token.ForwardToken
+
+	Implements(t, forward, &SymbolsDefineItem{})
+}
+
+func TestSymbols(t *testing.T) {
+	s := NewSymbols()
+
+	a := s.DefineItem(primitives.NewConstantString("a"))
+	b := s.DefineItem(primitives.NewConstantString("b"))
+	inner := s.UseItem()
+	outer := s.UseItem()
+	before := s.UseItem()
+
+	// definitions are visible in the scope which uses the scope of the definition
+	root := primitives.NewScope(lists.NewConcatenation(
+		before,
+		primitives.NewScope(a),
+		primitives.NewScope(lists.NewConcatenation(
+			primitives.NewScope(b),
+			inner,
+		)),
+		outer,
+	))
+
+	Equal(t, "", inner.String())
+	False(t, inner.Defined())
+
+	ResetSymbols(root)
+
+	Equal(t, "", before.String())
+	False(t, before.Defined())
+	Equal(t, 1, before.Permutations())
+
+	True(t, inner.Defined())
+	Equal(t, 2, inner.Permutations())
+	Equal(t, "b", inner.String())
+	Nil(t, inner.Permutation(1))
+	Equal(t, "a", inner.String())
+
+	True(t, outer.Defined())
+	Equal(t, 1, outer.Permutations())
+	Equal(t, "a", outer.String())
+
+	Equal(t, "abaa", root.String())
+
+	o := inner.Clone()
+	Equal(t, inner.String(), o.String())
+
+	// uses parse every value of a definition
+	p := &token.InternalParser{
+		Data:    "b",
+		DataLen: 1,
+	}
+
+	nex, errs := outer.Parse(p, 0)
+	Nil(t, errs)
+	Equal(t, 1, nex)
+	Equal(t, "b", outer.String())
+	False(t, outer.Defined())
+
+	ResetSymbols(root)
+
+	Equal(t, "b", outer.String())
+	False(t, outer.Defined())
+
+	nex, errs = inner.Parse(p, 0)
+	Nil(t, errs)
+	Equal(t, 1, nex)
+
+	ResetSymbols(root)
+
+	Equal(t, "b", inner.String())
+	True(t, inner.Defined())
+
+	p = &token.InternalParser{
+		Data:    "c",
+		DataLen: 1,
+	}
+
+	_, errs = inner.Parse(p, 0)
+	NotNil(t, errs)
+}
+
+func TestSymbolsRepeat(t *testing.T) {
+	s := NewSymbols()
+
+	// a definition which is repeated as one alternative of a statement is visible to the following statements
+	stmt := primitives.NewScope(lists.NewOne(
+		primitives.NewScope(s.DefineItem(primitives.NewConstantString("a"))),
+		s.UseItem(),
+	))
+	repeat := lists.NewRepeat(stmt, 2, 2)
+	root := primitives.NewScope(repeat)
+
+	Nil(t, repeat.Permutation(0))
+
+	statement := func(i int) *lists.One {
+		c, err := repeat.Get(i)
+		Nil(t, err)
+
+		return c.(*primitives.Scope).InternalGet().(*lists.One)
+	}
+	use := func(i int) *SymbolsUseItem {
+		c, err := statement(i).Get(0)
+		Nil(t, err)
+
+		return c.(*SymbolsUseItem)
+	}
+
+	Nil(t, statement(0).Permutation(0))
+	Nil(t, statement(1).Permutation(1))
+
+	ResetSymbols(root)
+
+	True(t, use(1).Defined())
+	Equal(t, "aa", root.String())
+
+	// the use is only defined after the definition
+	Nil(t, statement(0).Permutation(1))
+	Nil(t, statement(1).Permutation(0))
+
+	ResetSymbols(root)
+
+	False(t, use(0).Defined())
+	Equal(t, "a", root.String())
+}
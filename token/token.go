@@ -2,6 +2,7 @@ package token
 
 import (
 	"fmt"
+	"strings"
 	"text/scanner"
 )
 
@@ -336,6 +337,11 @@ func (s *VariableScope) Set(name string, tok Token) {
 	s.variables[name] = tok
 }
 
+// Parent returns the parent scope, or nil if there is no parent scope
+func (s *VariableScope) Parent() *VariableScope {
+	return s.parent
+}
+
 // Pop returns the parent scope, or panics if there is no parent scope
 func (s *VariableScope) Pop() *VariableScope {
 	p := s.parent
@@ -450,6 +456,32 @@ const (
 	ParseErrorExpectedExpressionTerm
 	// ParseErrEndlessLoopDetected an invalid loop was detected
 	ParseErrEndlessLoopDetected
+	// ParseErrorUnknownConstant there is no constant with this name
+	ParseErrorUnknownConstant
+	// ParseErrorInvalidConstantValue the value of the constant is invalid
+	ParseErrorInvalidConstantValue
+	// ParseErrorInvalidRepeatBounds the bounds of the repeat are invalid
+	ParseErrorInvalidRepeatBounds
+	// ParseErrorInvalidSwitch the switch statement is invalid
+	ParseErrorInvalidSwitch
+	// ParseErrorUnknownFunction the expression function is unknown
+	ParseErrorUnknownFunction
+	// ParseErrorInvalidFunctionArguments the arguments of the expression function are invalid
+	ParseErrorInvalidFunctionArguments
+	// ParseErrorInvalidBits the bit fields are invalid
+	ParseErrorInvalidBits
+	// ParseErrorInvalidTLV the type-length-value frame is invalid
+	ParseErrorInvalidTLV
+	// ParseErrorInvalidAssert the assert statement is invalid
+	ParseErrorInvalidAssert
+	// ParseErrorInvalidCharacterClass the character class is invalid
+	ParseErrorInvalidCharacterClass
+	// ParseErrorInvalidLiteral the literal is invalid
+	ParseErrorInvalidLiteral
+	// ParseErrorInvalidTest the embedded test is invalid
+	ParseErrorInvalidTest
+	// ParseErrorInvalidPermutationGroup the permutation group is invalid
+	ParseErrorInvalidPermutationGroup
 
 	// ParseErrorExpectedEOF expected EOF
 	ParseErrorExpectedEOF
@@ -473,4 +505,27 @@ func (err *ParserError) Error() string {
 	return fmt.Sprintf("L:%d, C:%d - %s", err.Position.Line, err.Position.Column, err.Message)
 }
 
+// ParserErrors holds multiple independent parser errors sorted by their positions
+type ParserErrors []*ParserError
+
+func (errs ParserErrors) Error() string {
+	messages := make([]string, len(errs))
+
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+func (errs ParserErrors) Len() int      { return len(errs) }
+func (errs ParserErrors) Swap(i, j int) { errs[i], errs[j] = errs[j], errs[i] }
+func (errs ParserErrors) Less(i, j int) bool {
+	if errs[i].Position.Line != errs[j].Position.Line {
+		return errs[i].Position.Line < errs[j].Position.Line
+	}
+
+	return errs[i].Position.Column < errs[j].Position.Column
+}
+
 ////////////////////////
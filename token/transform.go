@@ -190,6 +190,7 @@ func UnrollPointers(root Token) (Token, error) {
 
 					// we want to clone only original structures so we always clone the clone since the original could have been changed in the meantime
 					originalClones[child] = child.Clone()
+					CopySources(child, originalClones[child])
 				}
 			}
 
@@ -199,6 +200,7 @@ func UnrollPointers(root Token) (Token, error) {
 				pointerlessLoopDetection = make(map[Token]struct{})
 
 				c := originalClones[original].Clone()
+				CopySources(originalClones[original], c)
 
 				counts := make(map[Token]int)
 				for k, v := range iTok.counts {
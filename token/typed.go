@@ -7,11 +7,20 @@ import (
 )
 
 // ArgumentsTypedParser defines a parser for the arguments of a typed token.
-// Parsing stops unrecoverably at the first error. The return value of Err must be checked before using the values returned by precedings calls to GetInt.
+// Parsing stops unrecoverably at the first error. The return value of Err must be checked before using the values returned by precedings calls to GetInt, GetFloat, GetBool and GetString.
 type ArgumentsTypedParser interface {
 	// GetInt tries to parse the argument name and returns its integer value or defaultValue if the argument is not found.
 	// The return value is valid only if Err returns nil.
 	GetInt(name string, defaultValue int) int
+	// GetFloat tries to parse the argument name and returns its floating point value or defaultValue if the argument is not found.
+	// The return value is valid only if Err returns nil.
+	GetFloat(name string, defaultValue float64) float64
+	// GetBool tries to parse the argument name and returns its boolean value or defaultValue if the argument is not found.
+	// The return value is valid only if Err returns nil.
+	GetBool(name string, defaultValue bool) bool
+	// GetString tries to parse the argument name and returns its string value or defaultValue if the argument is not found.
+	// The return value is valid only if Err returns nil.
+	GetString(name string, defaultValue string) string
 	// Err returns the first error encountered by the ArgumentsTypedParser.
 	Err() error
 }
@@ -10,6 +10,8 @@ import (
 
 // Variable implements general variable token which references a token as its value and forwards all token functions to its token.
 type Variable struct {
+	token.Origin
+
 	name  string
 	token token.Token
 }
@@ -49,7 +51,7 @@ func (v *Variable) Clone() token.Token {
 // Parse tries to parse the token beginning from the current position in the parser data.
 // If the parsing is successful the error argument is nil and the next current position after the token is returned.
 func (v *Variable) Parse(pars *token.InternalParser, cur int) (int, []error) {
-	panic("TODO implement")
+	return v.token.Parse(pars, cur)
 }
 
 // Permutation sets a specific permutation for this token
@@ -130,6 +132,8 @@ func (v *Variable) SetScope(variableScope *token.VariableScope) {
 
 // VariableItem implements a token which references a Variable token to output its referenced token
 type VariableItem struct {
+	token.Origin
+
 	index    token.Token
 	variable token.VariableToken
 }
@@ -269,6 +273,8 @@ func (v *VariableItem) SetScope(variableScope *token.VariableScope) {
 
 // VariableSave is based on the general Variable token but does prevent the output of the referenced token
 type VariableSave struct {
+	token.Origin
+
 	Variable
 }
 
@@ -300,6 +306,8 @@ func NewVariableSave(name string, token token.Token) *VariableSave {
 
 // VariableReference implements a token which references a Variable token to output its referenced token
 type VariableReference struct {
+	token.Origin
+
 	variable token.VariableToken
 }
 
@@ -388,6 +396,8 @@ func (v *VariableReference) SetScope(variableScope *token.VariableScope) {
 
 // VariableValue implements a token which references a Variable token to output its referenced token
 type VariableValue struct {
+	token.Origin
+
 	variable token.VariableToken
 }
 